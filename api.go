@@ -0,0 +1,203 @@
+package kenwoodutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+const apiPrefix = "/api/"
+
+// APIServer exposes a radio over a small JSON API for dashboards and home
+// automation:
+//
+//	GET /api/bands/A               status of band A
+//	GET /api/bands/A/frequency     VFO frequency
+//	PUT /api/bands/A/frequency     {"MHz": "145.500"}
+//	PUT /api/bands/A/channel       {"Channel": 12}, tune to memory
+//	GET /api/channels/12           memory channel
+//	GET /api/ptt                   PTT band and transmit state
+//
+// Queries are served concurrently, the radio queues their commands. Changes
+// read and modify radio state and are served one at a time.
+type APIServer struct {
+	Radio *Radio
+
+	mu sync.RWMutex
+}
+
+type apiError struct {
+	Error string
+}
+
+type apiFrequency struct {
+	Band      string
+	Frequency uint32
+	MHz       string
+}
+
+type apiBandStatus struct {
+	BandStatus
+	Busy bool
+}
+
+type apiPTT struct {
+	Band         string
+	Transmitting bool
+}
+
+func NewAPIServer(r *Radio) *APIServer {
+	return &APIServer{Radio: r}
+}
+
+func (s *APIServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodGet {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+	} else {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	path := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, apiPrefix), "/"), "/")
+	var (
+		v   interface{}
+		err error
+	)
+	switch {
+	case len(path) >= 2 && path[0] == "bands":
+		v, err = s.band(req, path[1], path[2:])
+	case len(path) == 2 && path[0] == "channels":
+		v, err = s.channel(req, path[1])
+	case len(path) == 1 && path[0] == "ptt":
+		v, err = s.ptt(req)
+	default:
+		err = errNotFound
+	}
+	if err != nil {
+		writeJSON(w, apiStatus(err), apiError{err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+var (
+	errNotFound         = errors.New("not found")
+	errMethodNotAllowed = errors.New("method not allowed")
+)
+
+type badRequest struct{ error }
+
+func apiStatus(err error) int {
+	var bad badRequest
+	switch {
+	case errors.As(err, &bad), errors.Is(err, ErrOutOfRange):
+		return http.StatusBadRequest
+	case err == errNotFound, errors.Is(err, ErrEmptyChannel):
+		return http.StatusNotFound
+	case err == errMethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	case errors.Is(err, ErrTimeout):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func readJSON(req *http.Request, v interface{}) error {
+	if err := json.NewDecoder(req.Body).Decode(v); err != nil {
+		return badRequest{fmt.Errorf("error parsing request: %w", err)}
+	}
+	return nil
+}
+
+func (s *APIServer) band(req *http.Request, name string, rest []string) (interface{}, error) {
+	band, err := units.ParseBand(name)
+	if err != nil {
+		return nil, badRequest{err}
+	}
+	switch {
+	case len(rest) == 0 && req.Method == http.MethodGet:
+		st, err := s.Radio.BandStatus(band)
+		if err != nil {
+			return nil, err
+		}
+		busy, err := s.Radio.GetBusy(band)
+		return apiBandStatus{st, busy}, err
+
+	case len(rest) == 1 && rest[0] == "frequency" && req.Method == http.MethodGet:
+		hz, err := s.Radio.GetFrequency(band)
+		return apiFrequency{units.BandName(band), hz, units.FormatMHz(hz)}, err
+
+	case len(rest) == 1 && rest[0] == "frequency" && req.Method == http.MethodPut:
+		var f apiFrequency
+		if err := readJSON(req, &f); err != nil {
+			return nil, err
+		}
+		hz, err := units.ParseMHz(f.MHz)
+		if err != nil {
+			return nil, badRequest{err}
+		}
+		if err := s.Radio.SetFrequency(band, hz); err != nil {
+			return nil, err
+		}
+		return apiFrequency{units.BandName(band), hz, units.FormatMHz(hz)}, nil
+
+	case len(rest) == 1 && rest[0] == "channel" && req.Method == http.MethodPut:
+		var c struct{ Channel int }
+		if err := readJSON(req, &c); err != nil {
+			return nil, err
+		}
+		if c.Channel < 0 || c.Channel >= s.Radio.MemorySize() {
+			return nil, badRequest{fmt.Errorf("error: channel %d out of range", c.Channel)}
+		}
+		if err := s.Radio.SelectMemoryChannel(band, c.Channel); err != nil {
+			return nil, err
+		}
+		return s.Radio.BandStatus(band)
+
+	case len(rest) == 1 && (rest[0] == "frequency" || rest[0] == "channel"):
+		return nil, errMethodNotAllowed
+	}
+	return nil, errNotFound
+}
+
+func (s *APIServer) channel(req *http.Request, number string) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 0 || n >= s.Radio.MemorySize() {
+		return nil, badRequest{fmt.Errorf("error: bad channel number %q", number)}
+	}
+	m, err := s.Radio.ReadChannel(n)
+	if err != nil {
+		return nil, err
+	}
+	if m.RXFrequency == 0 {
+		return nil, fmt.Errorf("error reading channel %d: %w", n, ErrEmptyChannel)
+	}
+	return m, nil
+}
+
+func (s *APIServer) ptt(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	_, ptt, err := s.Radio.GetBand()
+	if err != nil {
+		return nil, err
+	}
+	return apiPTT{units.BandName(ptt), s.Radio.Transmitting()}, nil
+}
@@ -0,0 +1,77 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// APRSConfig is the APRS setup of a radio with a built-in TNC, which a reset
+// wipes.
+type APRSConfig struct {
+	Callsign string `json:",omitempty"`
+	Beacon   string `json:",omitempty"`
+	Path     string `json:",omitempty"`
+	Symbol   string `json:",omitempty"`
+}
+
+// aprsFields maps APRSConfig fields to the commands setting them, following
+// the TH-D7 family mnemonics.
+var aprsFields = []struct {
+	Mnemonic string
+	Field    func(c *APRSConfig) *string
+}{
+	{"MYC", func(c *APRSConfig) *string { return &c.Callsign }},
+	{"STAT", func(c *APRSConfig) *string { return &c.Beacon }},
+	{"PP", func(c *APRSConfig) *string { return &c.Path }},
+	{"ICO", func(c *APRSConfig) *string { return &c.Symbol }},
+}
+
+// Lines returns the commands programming c. Empty fields are left alone.
+func (c *APRSConfig) Lines() (v []string) {
+	for _, f := range aprsFields {
+		if s := *f.Field(c); s != "" {
+			v = append(v, f.Mnemonic+" "+s)
+		}
+	}
+	return v
+}
+
+// ReadAPRSConfig reads the APRS setup, or returns nil for radios without a
+// TNC.
+func (r *Radio) ReadAPRSConfig() (*APRSConfig, error) {
+	if !r.Capabilities().HasTNC {
+		return nil, nil
+	}
+	c := &APRSConfig{}
+	for _, f := range aprsFields {
+		line, err := r.WriteReadString(f.Mnemonic + "\r")
+		if errors.Is(err, ErrRadioNAK) {
+			log.Warn().Str("command", f.Mnemonic).Msg("radio does not support APRS command, skipping")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading APRS config: %w", err)
+		}
+		payload := strings.TrimSuffix(line, "\r")
+		if !strings.HasPrefix(payload, f.Mnemonic+" ") {
+			return nil, parseError(f.Mnemonic+" line", line, "")
+		}
+		*f.Field(c) = strings.TrimPrefix(payload, f.Mnemonic+" ")
+	}
+	return c, nil
+}
+
+func (r *Radio) WriteAPRSConfig(c *APRSConfig) error {
+	if err := r.checkTNC(); err != nil {
+		return err
+	}
+	for _, l := range c.Lines() {
+		if _, err := r.WriteReadString(l + "\r"); err != nil {
+			return fmt.Errorf("error writing APRS config: %w", err)
+		}
+	}
+	return nil
+}
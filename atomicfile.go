@@ -0,0 +1,107 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const backupSuffix = ".bak"
+
+// StdioPath is the file argument standing for standard input when read and
+// standard output when written, for pipelines.
+const StdioPath = "-"
+
+var stdinRead bool
+
+// ReadFileOrStdin reads path, or standard input for "-". Standard input can
+// only be read once.
+func ReadFileOrStdin(path string) ([]byte, error) {
+	if path != StdioPath {
+		return os.ReadFile(path)
+	}
+	if stdinRead {
+		return nil, errors.New("standard input was already read")
+	}
+	stdinRead = true
+	return io.ReadAll(os.Stdin)
+}
+
+// WriteFileAtomic writes data to path so that a crash leaves either the old
+// or the new contents, never a mix: data goes to a temporary file next to
+// path which is synced and renamed over it, so that path always exists.
+// With keepBackup the previous version is kept as path.bak. Data for "-" goes to standard output.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode, keepBackup bool) error {
+	if path == StdioPath {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	dir := filepath.Dir(path)
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		return err
+	}
+
+	if keepBackup {
+		if err := keepPrevious(path); err != nil {
+			return fmt.Errorf("error keeping previous version of %s: %w", path, err)
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// keepPrevious links path to path.bak, or copies it where hard links are
+// not supported, leaving path in place for the rename over it.
+func keepPrevious(path string) error {
+	bak := path + backupSuffix
+	if err := os.Remove(bak); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err := os.Link(path, bak)
+	if err == nil || os.IsNotExist(err) {
+		return nil
+	}
+	old, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(bak, old, st.Mode().Perm())
+}
+
+// syncDir makes a rename in dir durable. Not every platform can sync a
+// directory, so errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
@@ -0,0 +1,90 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// AuditFinding is a problem found in memory, involving one or more
+// channels.
+type AuditFinding struct {
+	Problem  string
+	Channels []uint16
+	Detail   string
+}
+
+// Audit looks for signs of a channel list gone out of hand: a frequency
+// stored with different tones, names used more than once or becoming the
+// same when cut to nameLength, and empty channels left with a name.
+func Audit(memory []MemoryEntry, nameLength int) (v []AuditFinding) {
+	byFreq := map[uint32][]MemoryEntry{}
+	byName := map[string][]MemoryEntry{}
+	byCut := map[string][]MemoryEntry{}
+	for _, m := range memory {
+		if m.RXFrequency == 0 {
+			if m.Name != "" {
+				v = append(v, AuditFinding{Problem: "empty channel with a name", Channels: []uint16{m.Number}, Detail: m.Name})
+			}
+			continue
+		}
+		byFreq[m.RXFrequency] = append(byFreq[m.RXFrequency], m)
+		if m.Name == "" {
+			continue
+		}
+		byName[m.Name] = append(byName[m.Name], m)
+		cut := m.Name
+		if len(cut) > nameLength {
+			cut = cut[:nameLength]
+		}
+		byCut[cut] = append(byCut[cut], m)
+	}
+
+	numbers := func(channels []MemoryEntry) []uint16 {
+		var n []uint16
+		for _, m := range channels {
+			n = append(n, m.Number)
+		}
+		return n
+	}
+	for hz, channels := range byFreq {
+		tones := map[string]bool{}
+		var labels []string
+		for _, m := range channels {
+			t := ToneLabel(m)
+			if t == "" {
+				t = "no tone"
+			}
+			if !tones[t] {
+				tones[t] = true
+				labels = append(labels, t)
+			}
+		}
+		if len(tones) > 1 {
+			v = append(v, AuditFinding{Problem: "same frequency, different tones", Channels: numbers(channels), Detail: units.FormatMHz(hz) + " MHz: " + strings.Join(labels, ", ")})
+		}
+	}
+	for name, channels := range byName {
+		if len(channels) > 1 {
+			v = append(v, AuditFinding{Problem: "duplicate name", Channels: numbers(channels), Detail: name})
+		}
+	}
+	for cut, channels := range byCut {
+		names := map[string]bool{}
+		for _, m := range channels {
+			names[m.Name] = true
+		}
+		if len(names) > 1 {
+			v = append(v, AuditFinding{Problem: fmt.Sprintf("names the same when cut to %d characters", nameLength), Channels: numbers(channels), Detail: cut})
+		}
+	}
+	sort.Slice(v, func(i, j int) bool {
+		if v[i].Channels[0] != v[j].Channels[0] {
+			return v[i].Channels[0] < v[j].Channels[0]
+		}
+		return v[i].Problem < v[j].Problem
+	})
+	return v
+}
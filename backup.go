@@ -0,0 +1,161 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// BackupInfo marks a dump as a safety backup: Channels are the channels
+// read before they were changed, those missing from Memory were empty.
+type BackupInfo struct {
+	Time     time.Time
+	Channels []uint16
+}
+
+// radioBackup is the backup of a Radio, made of the channels it was about
+// to change so far.
+type radioBackup struct {
+	path  string
+	dump  *Dump
+	saved map[uint16]bool
+}
+
+// Backup reads those of channels not backed up yet and adds them to the
+// backup file of r, created in r.BackupDir on the first call. It does
+// nothing when r.BackupDir is empty.
+func (r *Radio) Backup(channels []int) (err error) {
+	if r.BackupDir == "" {
+		return nil
+	}
+	if r.backup == nil {
+		r.backup = &radioBackup{saved: map[uint16]bool{}}
+	}
+	b := r.backup
+	var todo []int
+	for _, n := range channels {
+		if !b.saved[uint16(n)] {
+			todo = append(todo, n)
+		}
+	}
+	if len(todo) == 0 {
+		return nil
+	}
+	span := r.startSpan("backup", "channels", fmt.Sprint(len(todo)))
+	defer func() { r.endSpan(span, err) }()
+
+	if b.dump == nil {
+		if err := os.MkdirAll(r.BackupDir, 0755); err != nil {
+			return fmt.Errorf("error creating backup directory: %w", err)
+		}
+		now := time.Now()
+		b.path = filepath.Join(r.BackupDir, now.Format("2006-01-02T15-04-05")+".json")
+		for i := 2; ; i++ {
+			if _, err := os.Stat(b.path); os.IsNotExist(err) {
+				break
+			}
+			b.path = filepath.Join(r.BackupDir, fmt.Sprintf("%s-%d.json", now.Format("2006-01-02T15-04-05"), i))
+		}
+		b.dump = &Dump{Radio: r.Info(), Backup: &BackupInfo{Time: now.UTC()}}
+	}
+	for _, n := range todo {
+		m, err := r.readChannelRetrying(n)
+		if err != nil {
+			return fmt.Errorf("error backing up channel %d: %w", n, err)
+		}
+		if m.RXFrequency != 0 {
+			b.dump.Memory = append(b.dump.Memory, m)
+		}
+		b.dump.Backup.Channels = append(b.dump.Backup.Channels, uint16(n))
+	}
+	sort.Slice(b.dump.Memory, func(i, j int) bool { return b.dump.Memory[i].Number < b.dump.Memory[j].Number })
+	sort.Slice(b.dump.Backup.Channels, func(i, j int) bool { return b.dump.Backup.Channels[i] < b.dump.Backup.Channels[j] })
+	// the file only ever grows, there is nothing to keep of it
+	if err := b.dump.save(b.path, false); err != nil {
+		return fmt.Errorf("error saving backup: %w", err)
+	}
+	for _, n := range todo {
+		b.saved[uint16(n)] = true
+	}
+	log.Info().Str("file", b.path).Int("channels", len(b.dump.Backup.Channels)).Msg("Channels backed up.")
+	return nil
+}
+
+// LatestBackup returns the newest backup file in dir.
+func LatestBackup(dir string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return "", fmt.Errorf("error listing backups: %w", err)
+	}
+	var latest string
+	var latestTime time.Time
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if latest == "" || fi.ModTime().After(latestTime) || fi.ModTime().Equal(latestTime) && f > latest {
+			latest, latestTime = f, fi.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("error: no backups in %s", dir)
+	}
+	return latest, nil
+}
+
+// RestoreBackup puts the channels of a backup back as they were, clearing
+// those that were empty.
+func (r *Radio) RestoreBackup(d *Dump) (s WriteSummary, err error) {
+	span := r.startSpan("restore backup")
+	defer func() { r.endSpan(span, err) }()
+	if d.Backup == nil {
+		return s, errors.New("error: not a backup file")
+	}
+	if d.Radio != nil && d.Radio.Model != "" && !strings.EqualFold(d.Radio.Model, r.Model) {
+		return s, fmt.Errorf("error: backup is of a %s, not of this %s", d.Radio.Model, r.Model)
+	}
+	r.sizeMemory()
+	for _, n := range d.Backup.Channels {
+		if err := r.CheckChannel(int(n)); err != nil {
+			return s, err
+		}
+	}
+	channels := make([]int, len(d.Backup.Channels))
+	for i, n := range d.Backup.Channels {
+		channels[i] = int(n)
+	}
+	if err := r.Backup(channels); err != nil {
+		return s, err
+	}
+	for _, n := range d.Backup.Channels {
+		m, ok := d.Channel(n)
+		if !ok {
+			if err := r.ClearChannel(int(n)); err != nil {
+				return s, err
+			}
+			if err := r.SetChannel(MemoryEntry{Number: n}); err != nil {
+				return s, err
+			}
+			continue
+		}
+		if err := r.SetChannel(m); err != nil {
+			return s, err
+		}
+		nameSkipped, err := r.WriteChannel(int(n))
+		if err != nil {
+			return s, err
+		}
+		s.Written++
+		if nameSkipped {
+			s.NamesSkipped = append(s.NamesSkipped, n)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,41 @@
+package kenwoodutil
+
+import "fmt"
+
+func (r *Radio) checkCrossBand() (string, error) {
+	key := r.Capabilities().CrossBandSetting
+	if key == "" {
+		return "", fmt.Errorf("error: %s has no PC command for cross-band repeat, it is switched from the front panel", r.Model)
+	}
+	return key, nil
+}
+
+// CrossBandRepeat reports whether the radio repeats each band on the other.
+func (r *Radio) CrossBandRepeat() (bool, error) {
+	key, err := r.checkCrossBand()
+	if err != nil {
+		return false, err
+	}
+	s, err := r.ReadSettings()
+	if err != nil {
+		return false, err
+	}
+	v, ok := s[key]
+	if !ok {
+		return false, fmt.Errorf("error: %s does not report setting %s", r.Model, key)
+	}
+	return v != "0", nil
+}
+
+// SetCrossBandRepeat switches cross-band repeat on or off.
+func (r *Radio) SetCrossBandRepeat(on bool) error {
+	key, err := r.checkCrossBand()
+	if err != nil {
+		return err
+	}
+	v := "0"
+	if on {
+		v = "1"
+	}
+	return r.SetSetting(key, v)
+}
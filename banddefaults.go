@@ -0,0 +1,60 @@
+package kenwoodutil
+
+import (
+	"fmt"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// BandDefaults are channel settings imports fill in for channels between
+// Low and High MHz when the source leaves them blank. Transmit power is set
+// per band on the radio, not stored in channels, so it has no default.
+type BandDefaults struct {
+	Name string  `json:",omitempty"`
+	Low  float64 // MHz
+	High float64 // MHz
+	Step float64 `json:",omitempty"` // kHz
+	Mode string  `json:",omitempty"`
+	// Tone is the repeater access tone in Hz, only given to channels with
+	// a shift and no tone, CTCSS or DCS of their own.
+	Tone float64 `json:",omitempty"`
+}
+
+func (b BandDefaults) covers(hz uint32) bool {
+	mhz := float64(hz) / 1e6
+	return mhz >= b.Low && mhz <= b.High
+}
+
+// ApplyBandDefaults fills in fields of m from the first of defaults covering
+// its frequency. given tells which channel fields the source had, named as
+// spreadsheet fields.
+func ApplyBandDefaults(m *MemoryEntry, defaults []BandDefaults, given func(field string) bool) error {
+	for _, b := range defaults {
+		if !b.covers(m.RXFrequency) {
+			continue
+		}
+		if b.Step > 0 && !given("step") {
+			step, err := units.StepIndex(b.Step)
+			if err != nil {
+				return fmt.Errorf("error in %s band defaults: %w", b.Name, err)
+			}
+			m.RXStepSize = step
+		}
+		if b.Mode != "" && !given("mode") {
+			mode, err := units.ParseMode(b.Mode)
+			if err != nil {
+				return fmt.Errorf("error in %s band defaults: %w", b.Name, err)
+			}
+			m.Mode = mode
+		}
+		if b.Tone > 0 && m.ShiftDirection != 0 && !given("tone") && !given("ctcss") && !given("dcs") {
+			tone, err := units.ToneIndex(b.Tone)
+			if err != nil {
+				return fmt.Errorf("error in %s band defaults: %w", b.Name, err)
+			}
+			m.ToneEnabled, m.ToneFrequency = 1, uint16(tone)
+		}
+		return nil
+	}
+	return nil
+}
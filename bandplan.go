@@ -0,0 +1,164 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// RepeaterRange is a range of repeater outputs sharing the standard shift,
+// in MHz. Offset is negative when repeater inputs are below the outputs.
+type RepeaterRange struct {
+	Low, High float64
+	Offset    float64
+}
+
+// BandSegment is an amateur allocation, in MHz.
+type BandSegment struct {
+	Name      string
+	Low, High float64
+	Repeaters []RepeaterRange `json:",omitempty"`
+}
+
+// BandPlan lists the amateur allocations of a region. Repeater shifts are
+// the common ones of the region, national plans differ in places and can
+// be written into the config instead.
+type BandPlan struct {
+	Region   string
+	Segments []BandSegment
+}
+
+// BandPlanConfig selects a built in band plan by Region, or gives the
+// segments of a custom one.
+type BandPlanConfig struct {
+	Region   string        `json:",omitempty"`
+	Segments []BandSegment `json:",omitempty"`
+}
+
+var bandPlans = map[string]BandPlan{
+	"R1": {Region: "IARU Region 1", Segments: []BandSegment{
+		{Name: "6m", Low: 50, High: 52, Repeaters: []RepeaterRange{{51.81, 51.99, -0.6}}},
+		{Name: "4m", Low: 70, High: 70.5},
+		{Name: "2m", Low: 144, High: 146, Repeaters: []RepeaterRange{{145.575, 145.7875, -0.6}}},
+		{Name: "70cm", Low: 430, High: 440, Repeaters: []RepeaterRange{{438.65, 439.425, -7.6}}},
+		{Name: "23cm", Low: 1240, High: 1300, Repeaters: []RepeaterRange{{1297, 1297.475, -6}}},
+	}},
+	"R2": {Region: "IARU Region 2", Segments: []BandSegment{
+		{Name: "6m", Low: 50, High: 54, Repeaters: []RepeaterRange{{53, 54, -1}}},
+		{Name: "2m", Low: 144, High: 148, Repeaters: []RepeaterRange{
+			{145.1, 145.5, -0.6},
+			{146.61, 146.99, -0.6},
+			{147, 147.39, 0.6},
+		}},
+		{Name: "1.25m", Low: 222, High: 225, Repeaters: []RepeaterRange{{223.85, 224.98, -1.6}}},
+		{Name: "70cm", Low: 420, High: 450, Repeaters: []RepeaterRange{
+			{442, 445, 5},
+			{447, 450, -5},
+		}},
+		{Name: "33cm", Low: 902, High: 928, Repeaters: []RepeaterRange{{927, 928, -25}}},
+		{Name: "23cm", Low: 1240, High: 1300, Repeaters: []RepeaterRange{{1282, 1288, -12}}},
+	}},
+	"R3": {Region: "IARU Region 3", Segments: []BandSegment{
+		{Name: "6m", Low: 50, High: 54, Repeaters: []RepeaterRange{{53, 54, -1}}},
+		{Name: "2m", Low: 144, High: 148, Repeaters: []RepeaterRange{
+			{146.6, 146.975, -0.6},
+			{147, 147.375, 0.6},
+		}},
+		{Name: "70cm", Low: 430, High: 440, Repeaters: []RepeaterRange{{438, 440, -5}}},
+		{Name: "23cm", Low: 1240, High: 1300, Repeaters: []RepeaterRange{{1273, 1274, 20}}},
+	}},
+}
+
+// Plan returns the configured band plan, if any.
+func (c BandPlanConfig) Plan() (*BandPlan, error) {
+	if len(c.Segments) > 0 {
+		for _, s := range c.Segments {
+			if !(s.Low >= 0 && s.Low < s.High) {
+				return nil, fmt.Errorf("error: band plan segment %q goes from %g to %g MHz", s.Name, s.Low, s.High)
+			}
+			for _, r := range s.Repeaters {
+				if !(r.Low <= r.High && r.Low >= s.Low && r.High <= s.High) {
+					return nil, fmt.Errorf("error: repeater range %g-%g MHz is not within band plan segment %q", r.Low, r.High, s.Name)
+				}
+			}
+		}
+		region := c.Region
+		if region == "" {
+			region = "custom"
+		}
+		return &BandPlan{Region: region, Segments: c.Segments}, nil
+	}
+	if c.Region == "" {
+		return nil, nil
+	}
+	return BandPlanFor(c.Region)
+}
+
+// BandPlanFor returns the built in plan of an IARU region, like R1 or
+// "IARU R2".
+func BandPlanFor(region string) (*BandPlan, error) {
+	key := strings.ToUpper(strings.TrimSpace(region))
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "IARU"), " ")
+	if !strings.HasPrefix(key, "R") {
+		key = "R" + key
+	}
+	p, ok := bandPlans[key]
+	if !ok {
+		var known []string
+		for k := range bandPlans {
+			known = append(known, k)
+		}
+		sort.Strings(known)
+		return nil, fmt.Errorf("error: no band plan for region %q, expected one of %s", region, strings.Join(known, ", "))
+	}
+	return &p, nil
+}
+
+// Segment returns the allocation hz falls in.
+func (p *BandPlan) Segment(hz uint32) (BandSegment, bool) {
+	mhz := float64(hz) / 1e6
+	for _, s := range p.Segments {
+		if mhz >= s.Low && mhz <= s.High {
+			return s, true
+		}
+	}
+	return BandSegment{}, false
+}
+
+// OutOfBand returns the problems of m with the plan: receive frequencies or
+// transmit frequencies outside amateur allocations. Receive only channels
+// show up too, it is up to the reader to tell them apart.
+func (p *BandPlan) OutOfBand(m MemoryEntry) (v []string) {
+	if _, ok := p.Segment(m.RXFrequency); !ok {
+		v = append(v, "receive frequency outside amateur bands")
+	}
+	if tx := m.ShiftedTX(); tx != 0 {
+		if _, ok := p.Segment(tx); !ok {
+			v = append(v, "transmit frequency outside amateur bands")
+		}
+	}
+	return v
+}
+
+// AutoOffset gives m the standard repeater shift of the plan when its
+// frequency is in a repeater output range, reporting whether it did.
+func (p *BandPlan) AutoOffset(m *MemoryEntry) bool {
+	s, ok := p.Segment(m.RXFrequency)
+	if !ok {
+		return false
+	}
+	mhz := float64(m.RXFrequency) / 1e6
+	for _, r := range s.Repeaters {
+		if mhz < r.Low || mhz > r.High {
+			continue
+		}
+		m.ShiftDirection = 1
+		if r.Offset < 0 {
+			m.ShiftDirection = 2
+		}
+		m.OffsetFrequency = uint32(math.Round(math.Abs(r.Offset) * 1e6))
+		return true
+	}
+	return false
+}
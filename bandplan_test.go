@@ -0,0 +1,111 @@
+package kenwoodutil
+
+import "testing"
+
+func TestBandPlanFor(t *testing.T) {
+	for in, want := range map[string]string{
+		"R1":       "IARU Region 1",
+		"r2":       "IARU Region 2",
+		"3":        "IARU Region 3",
+		"IARU R1":  "IARU Region 1",
+		" iaru r3": "IARU Region 3",
+		"IARUR2":   "IARU Region 2",
+		"R1 ":      "IARU Region 1",
+	} {
+		p, err := BandPlanFor(in)
+		if err != nil {
+			t.Errorf("BandPlanFor(%q): %v", in, err)
+			continue
+		}
+		if p.Region != want {
+			t.Errorf("BandPlanFor(%q) = %s, want %s", in, p.Region, want)
+		}
+	}
+	for _, in := range []string{"", "R", "R4", "0", "IARU", "Region 1", "R1,R2"} {
+		if p, err := BandPlanFor(in); err == nil {
+			t.Errorf("BandPlanFor(%q) succeeded, got %s", in, p.Region)
+		}
+	}
+}
+
+func TestBandPlanConfig(t *testing.T) {
+	if p, err := (BandPlanConfig{}).Plan(); p != nil || err != nil {
+		t.Errorf("empty config gave %v, %v, want no plan", p, err)
+	}
+	custom := BandPlanConfig{Segments: []BandSegment{{Name: "2m", Low: 144, High: 146}}}
+	if p, err := custom.Plan(); err != nil || p.Region != "custom" {
+		t.Errorf("custom config gave %v, %v", p, err)
+	}
+	for _, segments := range [][]BandSegment{
+		{{Name: "2m", Low: 146, High: 144}},
+		{{Name: "2m", Low: 144, High: 144}},
+		{{Name: "2m", Low: -1, High: 146}},
+		{{Name: "2m", Low: 144, High: 146, Repeaters: []RepeaterRange{{145.8, 145.6, -0.6}}}},
+		{{Name: "2m", Low: 144, High: 146, Repeaters: []RepeaterRange{{145.6, 146.5, -0.6}}}},
+	} {
+		if p, err := (BandPlanConfig{Segments: segments}).Plan(); err == nil {
+			t.Errorf("Plan of %+v succeeded, got %+v", segments, p)
+		}
+	}
+	if _, err := (BandPlanConfig{Region: "R9"}).Plan(); err == nil {
+		t.Error("Plan of region R9 succeeded")
+	}
+}
+
+func TestBandPlanEdges(t *testing.T) {
+	p, err := BandPlanFor("R1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		hz      uint32
+		segment string
+	}{
+		{144000000, "2m"},
+		{146000000, "2m"},
+		{143999999, ""},
+		{146000001, ""},
+		{0, ""},
+		{4294967295, ""},
+	} {
+		s, ok := p.Segment(tc.hz)
+		if ok != (tc.segment != "") || s.Name != tc.segment {
+			t.Errorf("Segment(%d) = %q, %v, want %q", tc.hz, s.Name, ok, tc.segment)
+		}
+	}
+
+	for _, tc := range []struct {
+		hz        uint32
+		direction uint8
+		offset    uint32
+	}{
+		{145575000, 2, 600000},
+		{145787500, 2, 600000},
+		{145562500, 0, 0},
+		{145800000, 0, 0},
+		{439425000, 2, 7600000},
+		{118100000, 0, 0},
+	} {
+		m := MemoryEntry{RXFrequency: tc.hz}
+		shifted := p.AutoOffset(&m)
+		if shifted != (tc.direction != 0) || m.ShiftDirection != tc.direction || m.OffsetFrequency != tc.offset {
+			t.Errorf("AutoOffset(%d) = %v, shift %d by %d, want shift %d by %d", tc.hz, shifted, m.ShiftDirection, m.OffsetFrequency, tc.direction, tc.offset)
+		}
+	}
+
+	for _, tc := range []struct {
+		m    MemoryEntry
+		want int
+	}{
+		{MemoryEntry{RXFrequency: 145600000, ShiftDirection: 2, OffsetFrequency: 600000}, 0},
+		{MemoryEntry{RXFrequency: 144200000, ShiftDirection: 2, OffsetFrequency: 600000}, 1},
+		{MemoryEntry{RXFrequency: 118100000}, 1},
+		{MemoryEntry{RXFrequency: 118100000, Split: true, TXFrequency: 145500000}, 1},
+		{MemoryEntry{RXFrequency: 145500000, Split: true, TXFrequency: 150000000}, 1},
+		{MemoryEntry{RXFrequency: 160000000, ShiftDirection: 1, OffsetFrequency: 600000}, 2},
+	} {
+		if got := p.OutOfBand(tc.m); len(got) != tc.want {
+			t.Errorf("OutOfBand(%+v) = %v, want %d problems", tc.m, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// pcPortSpeeds are the PC port speeds by their pcPortSpeedSetting value.
+var pcPortSpeeds = []int{9600, 19200, 38400, 57600}
+
+const (
+	pcPortSpeedSetting = "MU.pc_port_speed"
+	// speedSwitchDelay gives the radio time to switch the port over.
+	speedSwitchDelay = 300 * time.Millisecond
+)
+
+// SetPortSpeed switches the PC port of the radio to baud and reconnects at
+// that speed. It returns the speed used before, to switch back to once a
+// bulk transfer is done. When the radio does not answer at baud it is
+// switched back to the speed used before.
+func (r *Radio) SetPortSpeed(baud int) (previous int, err error) {
+	previous = r.BaudRate
+	if strings.HasPrefix(r.PortPath, tcpScheme) {
+		return previous, fmt.Errorf("error: port speed of %s is set on the remote end", r.PortPath)
+	}
+	index := -1
+	for i, b := range pcPortSpeeds {
+		if b == baud {
+			index = i
+		}
+	}
+	if index < 0 {
+		return previous, fmt.Errorf("error: unsupported port speed %d, expected one of %v", baud, pcPortSpeeds)
+	}
+	if baud == r.BaudRate {
+		return previous, nil
+	}
+
+	s, err := r.ReadSettings()
+	if err != nil {
+		return previous, err
+	}
+	if _, ok := s[pcPortSpeedSetting]; !ok {
+		return previous, fmt.Errorf("error: %s does not report its PC port speed", r.Model)
+	}
+	s[pcPortSpeedSetting] = strconv.Itoa(index)
+	if err := r.reconnectAt(baud, s.Lines()); err != nil {
+		return previous, r.rollbackPortSpeed(previous, s, err)
+	}
+	if err := r.Resync(); err != nil {
+		return previous, r.rollbackPortSpeed(previous, s, fmt.Errorf("error talking to radio at %d baud: %w", baud, err))
+	}
+	log.Info().Int("baud", baud).Msg("port speed switched")
+	return previous, nil
+}
+
+// rollbackPortSpeed puts the radio back to previous after switching it
+// with s failed with cause. The radio may or may not have switched, so it
+// is told to go back over the link at the new speed, if that is up, before
+// connecting at previous again. It returns cause, noting when going back
+// failed too.
+func (r *Radio) rollbackPortSpeed(previous int, s Settings, cause error) error {
+	for i, b := range pcPortSpeeds {
+		if b == previous {
+			s[pcPortSpeedSetting] = strconv.Itoa(i)
+		}
+	}
+	log.Warn().Err(cause).Int("baud", previous).Msg("port speed not switched, going back")
+	err := r.reconnectAt(previous, s.Lines())
+	if err != nil {
+		// the link at the new speed is gone, connect at the old one anyway
+		r.mu.Lock()
+		r.BaudRate = previous
+		err = r.Connect()
+		r.mu.Unlock()
+	}
+	if err == nil {
+		err = r.Resync()
+	}
+	if err != nil {
+		return fmt.Errorf("%w; going back to %d baud failed too, set it on the radio menu: %v", cause, previous, err)
+	}
+	return cause
+}
+
+// keepPortSpeed returns s with the PC port speed of the current connection,
+// so that writing settings does not cut the link.
+func (r *Radio) keepPortSpeed(s Settings) Settings {
+	if _, ok := s[pcPortSpeedSetting]; !ok || strings.HasPrefix(r.PortPath, tcpScheme) {
+		return s
+	}
+	for i, b := range pcPortSpeeds {
+		if b == r.BaudRate {
+			c := Settings{}
+			for k, v := range s {
+				c[k] = v
+			}
+			c[pcPortSpeedSetting] = strconv.Itoa(i)
+			return c
+		}
+	}
+	return s
+}
+
+// reconnectAt sends lines that switch the port speed without waiting for
+// replies, then connects again at baud. Other commands wait meanwhile.
+func (r *Radio) reconnectAt(baud int, lines []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range lines {
+		// the radio may answer at either speed, the reply is not
+		// worth waiting for
+		err := r.WriteString(l + "\r")
+		r.emit(EventCommand, l, "", time.Now(), 0, err)
+		if err != nil {
+			return fmt.Errorf("error switching port speed: %w", err)
+		}
+	}
+	time.Sleep(speedSwitchDelay)
+
+	r.Port.Close()
+	r.BaudRate = baud
+	if err := r.Connect(); err != nil {
+		return fmt.Errorf("error reconnecting at %d baud: %w", baud, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package kenwoodutil
+
+import "time"
+
+type Bookmark struct {
+	Time    time.Time
+	Note    string `json:",omitempty"`
+	Channel MemoryEntry
+}
+
+func (v VFO) MemoryEntry() MemoryEntry {
+	return MemoryEntry{
+		RXFrequency:     v.Frequency,
+		RXStepSize:      v.StepSize,
+		ShiftDirection:  v.ShiftDirection,
+		ReverseEnabled:  v.ReverseEnabled,
+		ToneEnabled:     v.ToneEnabled,
+		CTCSSEnabled:    v.CTCSSEnabled,
+		DCSEnabled:      v.DCSEnabled,
+		ToneFrequency:   v.ToneFrequency,
+		CTCSSFrequency:  v.CTCSSFrequency,
+		DCSFrequency:    v.DCSFrequency,
+		OffsetFrequency: v.OffsetFrequency,
+		Mode:            v.Mode,
+	}
+}
+
+// CurrentChannel returns what band is tuned to as a channel: the selected
+// memory in memory mode, the VFO otherwise.
+func (r *Radio) CurrentChannel(band int) (MemoryEntry, error) {
+	mode, err := r.GetVFOMode(band)
+	if err != nil {
+		return MemoryEntry{}, err
+	}
+	if mode == MemoryMode {
+		ch, err := r.GetMemoryChannel(band)
+		if err != nil {
+			return MemoryEntry{}, err
+		}
+		return r.ReadChannel(ch)
+	}
+	v, err := r.GetVFO(band)
+	if err != nil {
+		return MemoryEntry{}, err
+	}
+	return v.MemoryEntry(), nil
+}
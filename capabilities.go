@@ -0,0 +1,317 @@
+package kenwoodutil
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+type ChannelKind uint8
+
+const (
+	RegularChannel ChannelKind = iota
+	CallChannel
+	WeatherChannel
+	ScanEdgeChannel
+)
+
+var channelKinds = []ChannelKind{RegularChannel, CallChannel, WeatherChannel, ScanEdgeChannel}
+
+func ParseChannelKind(s string) (ChannelKind, error) {
+	for _, k := range channelKinds {
+		if k.String() == s {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("error: unknown channel kind %q", s)
+}
+
+func (k ChannelKind) String() string {
+	switch k {
+	case CallChannel:
+		return "call"
+	case WeatherChannel:
+		return "weather"
+	case ScanEdgeChannel:
+		return "scan edge"
+	default:
+		return "regular"
+	}
+}
+
+type FrequencyRange struct {
+	Low, High uint32
+}
+
+func (f FrequencyRange) Contains(hz uint32) bool {
+	return hz >= f.Low && hz <= f.High
+}
+
+// TXBand is a band a model transmits on, named like the amateur band it
+// covers.
+type TXBand struct {
+	Name string
+	FrequencyRange
+}
+
+// Capabilities describe a radio model. Family groups models sharing the
+// memory format, so that channels can be copied between them.
+type Capabilities struct {
+	Model    string
+	Family   string
+	HasTNC   bool
+	HasClock bool
+	// HasGPS models pass the data of an attached GPS receiver through.
+	HasGPS bool
+	// ChannelPower models keep a power level per memory channel.
+	ChannelPower bool
+	// CrossBandSetting is the setting switching cross-band repeat, for
+	// models whose PC protocol has one.
+	CrossBandSetting string
+	NameLength       int
+	// NameCharset is what the display renders in channel names,
+	// units.NameCharset if empty.
+	NameCharset string
+	// MemoryChannels is the number of regular memory channels, numbered
+	// from 0.
+	MemoryChannels   int
+	NamelessChannels []ChannelKind
+	RXRanges         []FrequencyRange
+	// TXBands are sorted by frequency.
+	TXBands []TXBand
+}
+
+// modelSpec is a model as described in models.json and user model files.
+type modelSpec struct {
+	Model            string
+	Family           string
+	HasTNC           bool
+	HasClock         bool
+	HasGPS           bool
+	ChannelPower     bool
+	CrossBandSetting string
+	NameLength       int
+	NameCharset      string
+	MemoryChannels   int
+	NamelessChannels []string
+	RXRanges         [][2]float64          // MHz
+	TXBands          map[string][2]float64 // MHz, by band name
+}
+
+func (s modelSpec) capabilities() (Capabilities, error) {
+	c := Capabilities{
+		Model:            s.Model,
+		Family:           s.Family,
+		HasTNC:           s.HasTNC,
+		HasClock:         s.HasClock,
+		HasGPS:           s.HasGPS,
+		ChannelPower:     s.ChannelPower,
+		CrossBandSetting: s.CrossBandSetting,
+		NameLength:       s.NameLength,
+		NameCharset:      s.NameCharset,
+		MemoryChannels:   s.MemoryChannels,
+	}
+	if c.Model == "" {
+		return c, errors.New("error: model without a name")
+	}
+	for _, name := range s.NamelessChannels {
+		k, err := ParseChannelKind(name)
+		if err != nil {
+			return c, fmt.Errorf("error in model %s: %w", c.Model, err)
+		}
+		c.NamelessChannels = append(c.NamelessChannels, k)
+	}
+	for _, r := range s.RXRanges {
+		if r[0] < 0 || r[1] < r[0] || r[1] > math.MaxUint32/1e6 {
+			return c, fmt.Errorf("error in model %s: bad RX range %g-%g MHz", c.Model, r[0], r[1])
+		}
+		c.RXRanges = append(c.RXRanges, FrequencyRange{uint32(math.Round(r[0] * 1e6)), uint32(math.Round(r[1] * 1e6))})
+	}
+	for name, r := range s.TXBands {
+		if r[0] < 0 || r[1] < r[0] || r[1] > math.MaxUint32/1e6 {
+			return c, fmt.Errorf("error in model %s: bad TX band %s %g-%g MHz", c.Model, name, r[0], r[1])
+		}
+		c.TXBands = append(c.TXBands, TXBand{name, FrequencyRange{uint32(math.Round(r[0] * 1e6)), uint32(math.Round(r[1] * 1e6))}})
+	}
+	sort.Slice(c.TXBands, func(i, j int) bool { return c.TXBands[i].Low < c.TXBands[j].Low })
+	return c, nil
+}
+
+func parseModels(j []byte) ([]Capabilities, error) {
+	var specs []modelSpec
+	if err := json.Unmarshal(j, &specs); err != nil {
+		return nil, err
+	}
+	var v []Capabilities
+	for _, s := range specs {
+		c, err := s.capabilities()
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, c)
+	}
+	return v, nil
+}
+
+//go:embed models.json
+var builtinModels []byte
+
+var capabilityTable []Capabilities
+
+// builtinCapabilities are the models of models.json, which the command
+// catalog is known to cover.
+var builtinCapabilities []Capabilities
+
+func init() {
+	var err error
+	if capabilityTable, err = parseModels(builtinModels); err != nil {
+		panic(fmt.Sprintf("built in models.json: %v", err))
+	}
+	builtinCapabilities = capabilityTable
+}
+
+// LoadModels adds the models of the user file at path to the built in ones,
+// replacing those of the same name. A missing file is not an error.
+func LoadModels(path string) error {
+	j, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading models: %w", err)
+	}
+	models, err := parseModels(j)
+	if err != nil {
+		return fmt.Errorf("error parsing models %s: %w", path, err)
+	}
+	// user models go first so that a variant like TM-D710G wins over the
+	// TM-D710 prefix
+	table := models
+	for _, c := range capabilityTable {
+		replaced := false
+		for _, m := range models {
+			replaced = replaced || m.Model == c.Model
+		}
+		if !replaced {
+			table = append(table, c)
+		}
+	}
+	capabilityTable = table
+	return nil
+}
+
+func CapabilitiesFor(model string) Capabilities {
+	for _, c := range capabilityTable {
+		if strings.HasPrefix(model, c.Model) {
+			return c
+		}
+	}
+	return Capabilities{Model: model}
+}
+
+// BuiltinModel reports whether model is one of models.json rather than of a
+// user models file or unknown.
+func BuiltinModel(model string) bool {
+	for _, c := range builtinCapabilities {
+		if strings.HasPrefix(model, c.Model) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultMemoryChannels is the memory size of models that do not give one.
+const DefaultMemoryChannels = 1000
+
+// MemorySize is the number of regular memory channels of the model.
+func (c Capabilities) MemorySize() int {
+	if c.MemoryChannels > 0 {
+		return c.MemoryChannels
+	}
+	return DefaultMemoryChannels
+}
+
+// Compatible reports whether memory of a c radio can be copied to an other
+// one. Unknown models are only compatible with themselves.
+func (c Capabilities) Compatible(other Capabilities) bool {
+	if c.Family == "" || other.Family == "" {
+		return c.Model == other.Model
+	}
+	return c.Family == other.Family
+}
+
+// MaxName returns how long channel names can be on the model.
+func (c Capabilities) MaxName() int {
+	if c.NameLength > 0 {
+		return c.NameLength
+	}
+	return units.MaxNameLength
+}
+
+// FitName returns name as the model stores it, see units.FitName.
+func (c Capabilities) FitName(name string) string {
+	charset := c.NameCharset
+	if charset == "" {
+		charset = units.NameCharset
+	}
+	return units.FitName(name, c.MaxName(), charset)
+}
+
+func (c Capabilities) SupportsName(kind ChannelKind) bool {
+	for _, k := range c.NamelessChannels {
+		if k == kind {
+			return false
+		}
+	}
+	return true
+}
+
+// CanReceive reports whether hz is within the receive coverage of the
+// model. Models without known coverage accept any frequency.
+func (c Capabilities) CanReceive(hz uint32) bool {
+	if len(c.RXRanges) == 0 {
+		return true
+	}
+	for _, r := range c.RXRanges {
+		if r.Contains(hz) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransmit reports whether hz is within one of the transmit bands of the
+// model. Models without known bands accept any frequency.
+func (c Capabilities) CanTransmit(hz uint32) bool {
+	if len(c.TXBands) == 0 {
+		return true
+	}
+	_, ok := c.BandOf(hz)
+	return ok
+}
+
+// BandOf returns the name of the transmit band hz falls in, like "2m".
+func (c Capabilities) BandOf(hz uint32) (string, bool) {
+	for _, b := range c.TXBands {
+		if b.Contains(hz) {
+			return b.Name, true
+		}
+	}
+	return "", false
+}
+
+// TXBandNames lists the transmit bands of the model, like "2m, 70cm".
+func (c Capabilities) TXBandNames() string {
+	names := make([]string, len(c.TXBands))
+	for i, b := range c.TXBands {
+		names[i] = b.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,182 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// ChannelOption sets up a channel built by NewFMChannel or NewAMChannel.
+type ChannelOption func(m *MemoryEntry) error
+
+// NewFMChannel builds an FM channel receiving on rxMHz, like
+//
+//	NewFMChannel(145.6, WithOffset(-0.6), WithTone(88.5), WithName("SR9A"))
+//
+// so that programs making channel lists need not know how the radio
+// encodes steps, shifts and tones. The channel is validated.
+func NewFMChannel(rxMHz float64, opts ...ChannelOption) (MemoryEntry, error) {
+	return newChannel(rxMHz, units.ModeFM, opts)
+}
+
+// NewAMChannel builds an AM channel receiving on rxMHz, see NewFMChannel.
+func NewAMChannel(rxMHz float64, opts ...ChannelOption) (MemoryEntry, error) {
+	return newChannel(rxMHz, units.ModeAM, opts)
+}
+
+func newChannel(rxMHz float64, mode uint8, opts []ChannelOption) (MemoryEntry, error) {
+	m := MemoryEntry{Mode: mode}
+	var err error
+	if m.RXFrequency, err = mhzToHz(rxMHz); err != nil {
+		return MemoryEntry{}, err
+	}
+	m.RXStepSize = units.CanonicalStep(m.RXFrequency, 0)
+	for _, opt := range opts {
+		if err := opt(&m); err != nil {
+			return MemoryEntry{}, err
+		}
+	}
+	if err := m.Validate(); err != nil {
+		return MemoryEntry{}, err
+	}
+	return m, nil
+}
+
+func mhzToHz(mhz float64) (uint32, error) {
+	if mhz < 0 || mhz > math.MaxUint32/1e6 {
+		return 0, fmt.Errorf("error: %g MHz is not a frequency", mhz)
+	}
+	return uint32(math.Round(mhz * 1e6)), nil
+}
+
+// WithNumber puts the channel at memory channel n.
+func WithNumber(n int) ChannelOption {
+	return func(m *MemoryEntry) error {
+		if n < 0 || n > math.MaxUint16 {
+			return fmt.Errorf("error: %d is not a channel number", n)
+		}
+		m.Number = uint16(n)
+		return nil
+	}
+}
+
+func WithName(name string) ChannelOption {
+	return func(m *MemoryEntry) error {
+		m.Name = name
+		return nil
+	}
+}
+
+// WithOffset shifts transmission by mhz, negative below the receive
+// frequency.
+func WithOffset(mhz float64) ChannelOption {
+	return func(m *MemoryEntry) error {
+		hz, err := mhzToHz(math.Abs(mhz))
+		if err != nil {
+			return err
+		}
+		m.Split, m.TXFrequency, m.TXStepSize = false, 0, 0
+		switch {
+		case hz == 0:
+			m.ShiftDirection, m.OffsetFrequency = 0, 0
+		case mhz > 0:
+			m.ShiftDirection, m.OffsetFrequency = 1, hz
+		default:
+			m.ShiftDirection, m.OffsetFrequency = 2, hz
+		}
+		return nil
+	}
+}
+
+// WithTX transmits on mhz: as a shift when the radio can shift that far,
+// as a split channel when it cannot.
+func WithTX(mhz float64) ChannelOption {
+	return func(m *MemoryEntry) error {
+		tx, err := mhzToHz(mhz)
+		if err != nil {
+			return err
+		}
+		m.setTX(tx, false)
+		return nil
+	}
+}
+
+// setTX makes m transmit on tx, with a shift unless split is set or tx is
+// further than a shift goes.
+func (m *MemoryEntry) setTX(tx uint32, split bool) {
+	m.Split, m.TXFrequency, m.TXStepSize = false, 0, 0
+	m.ShiftDirection, m.OffsetFrequency = 0, 0
+	switch {
+	case split || tx > m.RXFrequency+maxOffset || tx+maxOffset < m.RXFrequency:
+		m.Split, m.TXFrequency, m.TXStepSize = true, tx, units.CanonicalStep(tx, 0)
+	case tx > m.RXFrequency:
+		m.ShiftDirection, m.OffsetFrequency = 1, tx-m.RXFrequency
+	case tx < m.RXFrequency:
+		m.ShiftDirection, m.OffsetFrequency = 2, m.RXFrequency-tx
+	}
+}
+
+// WithTone sends a CTCSS tone of hz on transmit, to open repeaters.
+func WithTone(hz float64) ChannelOption {
+	return func(m *MemoryEntry) error {
+		idx, err := units.ToneIndex(hz)
+		if err != nil {
+			return err
+		}
+		m.ToneEnabled, m.ToneFrequency = 1, uint16(idx)
+		return nil
+	}
+}
+
+// WithCTCSS sends and squelches on a CTCSS tone of hz.
+func WithCTCSS(hz float64) ChannelOption {
+	return func(m *MemoryEntry) error {
+		idx, err := units.ToneIndex(hz)
+		if err != nil {
+			return err
+		}
+		m.CTCSSEnabled, m.CTCSSFrequency = 1, uint16(idx)
+		return nil
+	}
+}
+
+// WithDCS sends and squelches on DCS code, like 23 for D023.
+func WithDCS(code int) ChannelOption {
+	return func(m *MemoryEntry) error {
+		idx, err := units.DCSIndex(uint16(code))
+		if err != nil {
+			return err
+		}
+		m.DCSEnabled, m.DCSFrequency = 1, uint16(idx)
+		return nil
+	}
+}
+
+// WithStep tunes the channel in steps of khz rather than the step its
+// frequency falls on.
+func WithStep(khz float64) ChannelOption {
+	return func(m *MemoryEntry) (err error) {
+		m.RXStepSize, err = units.StepIndex(khz)
+		return err
+	}
+}
+
+// WithNarrow makes an FM channel narrow FM.
+func WithNarrow() ChannelOption {
+	return func(m *MemoryEntry) error {
+		if m.Mode != units.ModeFM {
+			return fmt.Errorf("error: only FM channels can be narrow")
+		}
+		m.Mode = units.ModeNFM
+		return nil
+	}
+}
+
+// WithLockout leaves the channel out of scans.
+func WithLockout() ChannelOption {
+	return func(m *MemoryEntry) error {
+		m.LockOut = 1
+		return nil
+	}
+}
@@ -0,0 +1,118 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+var channelListHeader = []string{"Ch", "Name", "Frequency", "Offset", "Tone", "Mode"}
+
+// ChannelList lays out channels for people rather than for import: MHz
+// with units, the tone kind spelled out and scan lockout marked.
+func ChannelList(channels []MemoryEntry) [][]string {
+	rows := [][]string{channelListHeader}
+	for _, m := range channels {
+		if m.RXFrequency == 0 {
+			continue
+		}
+		ch := m.Label()
+		if m.LockOut == 1 {
+			ch += "*"
+		}
+		rows = append(rows, []string{ch, m.Name, units.FormatMHz(m.RXFrequency) + " MHz", OffsetLabel(m), ToneLabel(m), units.ModeName(m.Mode)})
+	}
+	return rows
+}
+
+// OffsetLabel describes the repeater shift or split of m, like "-7.6000".
+func OffsetLabel(m MemoryEntry) string {
+	switch {
+	case m.Split:
+		return "split " + units.FormatMHz(m.TXFrequency)
+	case m.ShiftDirection == 1:
+		return "+" + units.FormatMHz(m.OffsetFrequency)
+	case m.ShiftDirection == 2:
+		return "-" + units.FormatMHz(m.OffsetFrequency)
+	}
+	return ""
+}
+
+// ToneLabel describes the tone, CTCSS or DCS setting of m, like "T 88.5".
+func ToneLabel(m MemoryEntry) string {
+	switch {
+	case m.ToneEnabled == 1:
+		return fmt.Sprintf("T %.1f", units.ToneHz(m.ToneFrequency))
+	case m.CTCSSEnabled == 1:
+		return fmt.Sprintf("CT %.1f", units.ToneHz(m.CTCSSFrequency))
+	case m.DCSEnabled == 1:
+		if code, ok := units.DCSCode(m.DCSFrequency); ok {
+			return fmt.Sprintf("D%03d", code)
+		}
+	}
+	return ""
+}
+
+// WriteTable writes rows as aligned plain text columns.
+func WriteTable(w io.Writer, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// WriteMarkdown writes rows as a Markdown table, the first row being the
+// header.
+func WriteMarkdown(w io.Writer, rows [][]string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+	line := func(row []string) string {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			cells[i] = cell.Replace(c)
+		}
+		return "| " + strings.Join(cells, " | ") + " |\n"
+	}
+	var b strings.Builder
+	b.WriteString(line(rows[0]))
+	b.WriteString(strings.Repeat("|---", len(rows[0])) + "|\n")
+	for _, row := range rows[1:] {
+		b.WriteString(line(row))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+const channelListStyle = `body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #888; padding: 2px 8px; }
+tr:nth-child(even) { background: #eee; }
+@media print { body { font-size: 10pt; } }`
+
+// WriteHTML writes rows as a standalone, printable HTML page.
+func WriteHTML(w io.Writer, title string, rows [][]string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n<h1>%s</h1>\n<table>\n",
+		html.EscapeString(title), channelListStyle, html.EscapeString(title))
+	for i, row := range rows {
+		tag := "td"
+		if i == 0 {
+			tag = "th"
+		}
+		b.WriteString("<tr>")
+		for _, c := range row {
+			fmt.Fprintf(&b, "<%s>%s</%s>", tag, html.EscapeString(c), tag)
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n</body>\n</html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,186 @@
+package kenwoodutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// checkpointEvery is how many channels go by between checkpoint saves, a
+// failed transfer saves its checkpoint right away.
+const checkpointEvery = 20
+
+// Checkpoint records how far a memory read or write got, so that an
+// interrupted transfer can go on where it stopped.
+type Checkpoint struct {
+	Operation string
+	// Radio identifies the radio like the radio cache does.
+	Radio string
+	Dump  string
+	// DumpSHA256 is the hash of the dump written, so that a changed dump is
+	// not written in two halves.
+	DumpSHA256 string `json:",omitempty"`
+	// Last is the last channel transferred, -1 before the first one.
+	Last int
+	// Memory holds the channels read so far.
+	Memory  []MemoryEntry `json:",omitempty"`
+	Updated time.Time
+
+	unsaved int
+}
+
+func checkpointPath(operation string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating cache directory: %w", err)
+	}
+	return filepath.Join(dir, "kenwoodutil", "checkpoint-"+operation+".json"), nil
+}
+
+// NewCheckpoint starts a checkpoint of operation between r and the dump at
+// path.
+func NewCheckpoint(operation string, r *Radio, path string) (*Checkpoint, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("error locating dump: %w", err)
+	}
+	c := &Checkpoint{Operation: operation, Radio: radioCacheKey(r), Dump: abs, Last: -1}
+	if operation == "write" {
+		if c.DumpSHA256, err = fileSHA256(path); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// LoadCheckpoint returns the checkpoint left by an interrupted operation
+// between r and the dump at path.
+func LoadCheckpoint(operation string, r *Radio, path string) (*Checkpoint, error) {
+	want, err := NewCheckpoint(operation, r, path)
+	if err != nil {
+		return nil, err
+	}
+	cpath, err := checkpointPath(operation)
+	if err != nil {
+		return nil, err
+	}
+	j, err := os.ReadFile(cpath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error: no interrupted %s to resume", operation)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+	c := &Checkpoint{}
+	if err := json.Unmarshal(j, c); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint: %w", err)
+	}
+	switch {
+	case c.Radio != want.Radio:
+		return nil, fmt.Errorf("error: the interrupted %s was with %s, not %s", operation, c.Radio, want.Radio)
+	case c.Dump != want.Dump:
+		return nil, fmt.Errorf("error: the interrupted %s was of %s, not %s", operation, c.Dump, want.Dump)
+	case c.DumpSHA256 != want.DumpSHA256:
+		return nil, fmt.Errorf("error: %s changed since the interrupted %s, start over", c.Dump, operation)
+	}
+	return c, nil
+}
+
+func (c *Checkpoint) Save() error {
+	path, err := checkpointPath(c.Operation)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating cache directory: %w", err)
+	}
+	c.Updated = time.Now()
+	j, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling checkpoint: %w", err)
+	}
+	if err := WriteFileAtomic(path, j, 0644, false); err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Remove drops the checkpoint once the operation is done.
+func (c *Checkpoint) Remove() error {
+	path, err := checkpointPath(c.Operation)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Done records channel as transferred, saving the checkpoint every few
+// channels.
+func (c *Checkpoint) Done(channel int, r *Radio) {
+	c.Last = channel
+	if c.Operation == "read" && channel < len(r.Memory) && r.Memory[channel].RXFrequency != 0 {
+		c.Memory = append(c.Memory, r.Memory[channel])
+	}
+	if c.unsaved++; c.unsaved >= checkpointEvery {
+		c.unsaved = 0
+		if err := c.Save(); err != nil {
+			log.Warn().Err(err).Msg("checkpoint not saved")
+		}
+	}
+}
+
+// ResumeRead puts the channels read before the interruption back into
+// r.Memory and returns the channel to go on from: the last one read, read
+// again in case it was cut short.
+func (c *Checkpoint) ResumeRead(r *Radio) int {
+	if c.Last < 0 {
+		return 0
+	}
+	var kept []MemoryEntry
+	for _, m := range c.Memory {
+		if int(m.Number) < c.Last && r.SetChannel(m) == nil {
+			kept = append(kept, m)
+		}
+	}
+	c.Memory = kept
+	return c.Last
+}
+
+// ResumeWrite returns the channel to go on writing from. The last channel
+// written is read back and written again unless it is intact.
+func (c *Checkpoint) ResumeWrite(r *Radio) (int, error) {
+	if c.Last < 0 {
+		return 0, nil
+	}
+	if err := r.CheckChannel(c.Last); err != nil {
+		return 0, err
+	}
+	got, err := r.readChannelRetrying(c.Last)
+	if err != nil {
+		return 0, fmt.Errorf("error verifying channel %03d: %w", c.Last, err)
+	}
+	if !got.Equal(r.Memory[c.Last]) {
+		log.Warn().Int("channel", c.Last).Msg("last channel written before the interruption does not read back right, writing it again")
+		return c.Last, nil
+	}
+	return c.Last + 1, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", path, err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
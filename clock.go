@@ -0,0 +1,47 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	CKFormat        = "CK %s"
+	CKCommandFormat = "CK\r"
+	ckLayout        = "060102150405"
+)
+
+func (r *Radio) checkClock() error {
+	if !r.Capabilities().HasClock {
+		return fmt.Errorf("error: %s has no clock", r.Model)
+	}
+	return nil
+}
+
+// GetClock reads the radio clock, which keeps local time.
+func (r *Radio) GetClock() (time.Time, error) {
+	if err := r.checkClock(); err != nil {
+		return time.Time{}, err
+	}
+	line, err := r.WriteReadString(CKCommandFormat)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error reading clock: %w", err)
+	}
+	payload := strings.TrimPrefix(strings.TrimSuffix(line, "\r"), "CK ")
+	t, err := time.ParseInLocation(ckLayout, payload, time.Local)
+	if err != nil {
+		return time.Time{}, parseError("clock line", line, "")
+	}
+	return t, nil
+}
+
+func (r *Radio) SetClock(t time.Time) error {
+	if err := r.checkClock(); err != nil {
+		return err
+	}
+	if _, err := r.WriteReadString(fmt.Sprintf(CKFormat, t.In(time.Local).Format(ckLayout)) + "\r"); err != nil {
+		return fmt.Errorf("error setting clock: %w", err)
+	}
+	return nil
+}
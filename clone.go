@@ -0,0 +1,81 @@
+package kenwoodutil
+
+import (
+	"fmt"
+)
+
+// CloneResult tells how a clone went. Mismatched are channels that did not
+// read back from the destination as they were written.
+type CloneResult struct {
+	Copied       int
+	Cleared      int
+	NamesSkipped []uint16
+	Mismatched   []uint16
+}
+
+// Clone copies memory from src to dst channel by channel, clearing channels
+// that are empty on src, and reads every written channel back from dst to
+// verify it. progress, if set, is called after every channel.
+func Clone(src, dst *Radio, progress func(channel int)) (res CloneResult, err error) {
+	if !src.Capabilities().Compatible(dst.Capabilities()) {
+		return res, fmt.Errorf("error cloning: %s memory can not be copied to %s", src.Model, dst.Model)
+	}
+	span := dst.startSpan("clone", "source", src.Model, "destination", dst.Model)
+	defer func() { dst.endSpan(span, err) }()
+
+	src.sizeMemory()
+	dst.sizeMemory()
+	defer src.reindex()
+	defer dst.reindex()
+	if dst.BackupDir != "" {
+		all := make([]int, len(dst.Memory))
+		for i := range all {
+			all[i] = i
+		}
+		if err := dst.Backup(all); err != nil {
+			return res, err
+		}
+	}
+	for i := 0; i < len(src.Memory) && i < len(dst.Memory); i++ {
+		m, err := src.readChannelRetrying(i)
+		if err != nil {
+			return res, fmt.Errorf("error reading channel %d from source: %w", i, err)
+		}
+		src.Memory[i] = m
+		old, err := dst.readChannelRetrying(i)
+		if err != nil {
+			return res, fmt.Errorf("error reading channel %d from destination: %w", i, err)
+		}
+
+		switch {
+		case m.RXFrequency == 0 && old.RXFrequency != 0:
+			if err := dst.ClearChannel(i); err != nil {
+				return res, err
+			}
+			dst.Memory[i] = MemoryEntry{}
+			res.Cleared++
+		case m.RXFrequency != 0 && !m.Equal(old):
+			dst.Memory[i] = m
+			nameSkipped, err := dst.WriteChannel(i)
+			if err != nil {
+				return res, err
+			}
+			if nameSkipped {
+				m.Name = ""
+				res.NamesSkipped = append(res.NamesSkipped, m.Number)
+			}
+			got, err := dst.readChannelRetrying(i)
+			if err != nil {
+				return res, fmt.Errorf("error verifying channel %d: %w", i, err)
+			}
+			if !got.Equal(m) {
+				res.Mismatched = append(res.Mismatched, m.Number)
+			}
+			res.Copied++
+		}
+		if progress != nil {
+			progress(i)
+		}
+	}
+	return res, nil
+}
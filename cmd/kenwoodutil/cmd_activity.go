@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runActivity(args []string) error {
+	fs := flag.NewFlagSet("activity", flag.ExitOnError)
+	by := fs.String("by", "hour", "bucket activity by hour of the day or by day")
+	format := fs.String("format", "chart", "output format, chart or csv")
+	fromMHz := fs.String("from", "", "only frequencies from this one, in MHz")
+	toMHz := fs.String("to", "", "only frequencies up to this one, in MHz")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("usage: activity [-by hour|day] [-format chart|csv] [-from MHz] [-to MHz] log.csv...")
+	}
+	if *by != "hour" && *by != "day" {
+		return fmt.Errorf("error: unknown bucket %q, expected hour or day", *by)
+	}
+	if *format != "chart" && *format != "csv" {
+		return fmt.Errorf("error: unknown format %q, expected chart or csv", *format)
+	}
+	var from, to uint32 = 0, ^uint32(0)
+	var err error
+	if *fromMHz != "" {
+		if from, err = units.ParseMHz(*fromMHz); err != nil {
+			return err
+		}
+	}
+	if *toMHz != "" {
+		if to, err = units.ParseMHz(*toMHz); err != nil {
+			return err
+		}
+	}
+
+	var hits []kenwoodutil.Hit
+	for _, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("error opening reception log: %w", err)
+		}
+		v, err := kenwoodutil.ReadHitLog(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, h := range v {
+			if h.Frequency >= from && h.Frequency <= to {
+				hits = append(hits, h)
+			}
+		}
+	}
+
+	a := kenwoodutil.NewActivityHistogram(hits, *by == "day")
+	if *format == "csv" {
+		cw := csv.NewWriter(os.Stdout)
+		cw.WriteAll(a.Rows())
+		return cw.Error()
+	}
+	a.Chart(os.Stdout)
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runAudit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	fromRadio := fs.Bool("radio", false, "audit the radio memory instead of a dump")
+	fs.Parse(args)
+
+	var memory []kenwoodutil.MemoryEntry
+	c := kenwoodutil.Capabilities{}
+	if *fromRadio {
+		r, err := openRadio()
+		if err != nil {
+			return err
+		}
+		log.Info().Msg("Reading memory...")
+		if err := r.ReadMemory(); err != nil {
+			return err
+		}
+		memory, c = r.Memory, r.Capabilities()
+	} else {
+		path := defaultDumpPath
+		if fs.NArg() > 0 {
+			path = fs.Arg(0)
+		}
+		d, err := kenwoodutil.LoadDump(path)
+		if err != nil {
+			return err
+		}
+		memory = d.Memory
+	}
+
+	findings := kenwoodutil.Audit(memory, c.MaxName())
+	if len(findings) == 0 {
+		log.Info().Msg("No problems found.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CHANNELS\tPROBLEM\tDETAIL")
+	for _, f := range findings {
+		var chs []string
+		for _, n := range f.Channels {
+			chs = append(chs, fmt.Sprintf("%03d", n))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", strings.Join(chs, ","), f.Problem, f.Detail)
+	}
+	return w.Flush()
+}
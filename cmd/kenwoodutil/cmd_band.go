@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runBandControl(args []string) error {
+	fs := flag.NewFlagSet("band", flag.ExitOnError)
+	fs.Parse(args)
+	args = fs.Args()
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	arg := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+
+	switch arg(0) {
+	case "", "status":
+	case "control", "ptt", "select":
+		band, err := units.ParseBand(arg(1))
+		if err != nil {
+			return err
+		}
+		control, ptt, err := r.GetBand()
+		if err != nil {
+			return err
+		}
+		switch arg(0) {
+		case "control":
+			control = band
+		case "ptt":
+			ptt = band
+		default:
+			control, ptt = band, band
+		}
+		if err := r.SetBands(control, ptt); err != nil {
+			return err
+		}
+	case "mode":
+		band, err := units.ParseBand(arg(1))
+		if err != nil {
+			return err
+		}
+		mode, err := kenwoodutil.ParseVFOMode(arg(2))
+		if err != nil {
+			return err
+		}
+		if err := r.SetVFOMode(band, mode); err != nil {
+			return err
+		}
+	case "crossband":
+		switch arg(1) {
+		case "on", "off":
+			if err := r.SetCrossBandRepeat(arg(1) == "on"); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("usage: band crossband on|off")
+		}
+	default:
+		return fmt.Errorf("unknown band action %q", arg(0))
+	}
+
+	control, ptt, err := r.GetBand()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "BAND\tMODE\tROLE")
+	for band := 0; band < 2; band++ {
+		mode, err := r.GetVFOMode(band)
+		if err != nil {
+			return err
+		}
+		role := ""
+		switch {
+		case band == control && band == ptt:
+			role = "control, PTT"
+		case band == control:
+			role = "control"
+		case band == ptt:
+			role = "PTT"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", units.BandName(band), kenwoodutil.VFOModeName(mode), role)
+	}
+	w.Flush()
+	if r.Capabilities().CrossBandSetting != "" {
+		on, err := r.CrossBandRepeat()
+		if err != nil {
+			return err
+		}
+		state := "off"
+		if on {
+			state = "on"
+		}
+		fmt.Printf("cross-band repeat: %s\n", state)
+	}
+	return nil
+}
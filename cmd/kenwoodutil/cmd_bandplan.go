@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// configuredBandPlan returns the plan of region, or the one in the config
+// when region is empty.
+func configuredBandPlan(c *kenwoodutil.Config, region string) (*kenwoodutil.BandPlan, error) {
+	if region != "" {
+		return kenwoodutil.BandPlanFor(region)
+	}
+	plan, err := c.BandPlan.Plan()
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, errors.New("error: no band plan, use -region or set BandPlan in the config")
+	}
+	return plan, nil
+}
+
+func runBandPlan(args []string) error {
+	fs := flag.NewFlagSet("bandplan", flag.ExitOnError)
+	region := fs.String("region", "", "IARU region, R1, R2 or R3, instead of the configured band plan")
+	fs.Parse(args)
+
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	plan, err := configuredBandPlan(c, *region)
+	if err != nil {
+		return err
+	}
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CH\tNAME\tFREQUENCY\tPROBLEM")
+	flagged := 0
+	for _, m := range d.Memory {
+		if m.RXFrequency == 0 {
+			continue
+		}
+		if problems := plan.OutOfBand(m); len(problems) > 0 {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.Label(), m.Name, units.FormatMHz(m.RXFrequency), strings.Join(problems, "; "))
+			flagged++
+		}
+	}
+	if flagged == 0 {
+		log.Info().Str("band plan", plan.Region).Msg("All channels are within amateur bands.")
+		return nil
+	}
+	return w.Flush()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runBookmark(args []string) error {
+	fs := flag.NewFlagSet("bookmark", flag.ExitOnError)
+	bandName := fs.String("band", "", "band to bookmark (A or B), defaults to the control band")
+	note := fs.String("note", "", "note stored with the bookmark")
+	fs.Parse(args)
+
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	d, err := loadDumpOrNew(path)
+	if err != nil {
+		return err
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	var band int
+	if *bandName == "" {
+		band, _, err = r.GetBand()
+	} else {
+		band, err = units.ParseBand(*bandName)
+	}
+	if err != nil {
+		return err
+	}
+	ch, err := r.CurrentChannel(band)
+	if err != nil {
+		return err
+	}
+
+	d.Inbox = append(d.Inbox, kenwoodutil.Bookmark{Time: time.Now(), Note: *note, Channel: ch})
+	if err := d.Save(path); err != nil {
+		return err
+	}
+	fmt.Printf("bookmarked %s MHz %s\n", units.FormatMHz(ch.RXFrequency), ch.Name)
+	log.Info().Int("inbox", len(d.Inbox)).Msg("Bookmark saved.")
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runCalibrate(args []string) error {
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	samples := fs.Int("samples", 20, "number of round trips to measure at each pacing")
+	apply := fs.Bool("apply", false, "store the recommended settings in the radio cache so they are used from now on")
+	fs.Parse(args)
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Calibrating...")
+	t, err := r.Calibrate(*samples)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("reply latency:  %s\n", t.Latency)
+	fmt.Printf("error rate:     %.1f%%\n", t.ErrorRate*100)
+	fmt.Printf("pacing:         %s\n", t.Pacing)
+	fmt.Printf("pipeline depth: %d\n", t.PipelineDepth)
+	if !*apply {
+		log.Info().Msg("Run with -apply to use these settings.")
+		return nil
+	}
+	cache, _ := kenwoodutil.LoadRadioCache(r)
+	cache.Tuning = t
+	if err := kenwoodutil.SaveRadioCache(r, cache); err != nil {
+		return err
+	}
+	log.Info().Msg("Settings stored in radio cache.")
+	return nil
+}
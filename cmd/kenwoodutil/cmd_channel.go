@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runChannel(args []string) error {
+	if len(args) < 2 || args[0] != "set" {
+		return errors.New("usage: ch set <channel> -freq MHz [-tx MHz | -offset [+-]MHz] [-tone Hz | -ctcss Hz | -dcs code] [-mode FM|AM|NFM] [-step kHz] [-name name] [-lockout]")
+	}
+	// the radio checks the number against its memory
+	n, err := strconv.ParseUint(args[1], 10, 16)
+	if err != nil {
+		return fmt.Errorf("error: %q is not a channel number", args[1])
+	}
+	fs := flag.NewFlagSet("ch set", flag.ExitOnError)
+	fields := map[string]*string{
+		"freq":   fs.String("freq", "", "receive frequency in MHz"),
+		"tx":     fs.String("tx", "", "transmit frequency in MHz, instead of -offset"),
+		"offset": fs.String("offset", "", "repeater offset in MHz, like -7.6 or +0.6"),
+		"tone":   fs.String("tone", "", "access tone in Hz"),
+		"ctcss":  fs.String("ctcss", "", "CTCSS tone in Hz"),
+		"dcs":    fs.String("dcs", "", "DCS code, like 023"),
+		"mode":   fs.String("mode", "", "FM, AM or NFM"),
+		"step":   fs.String("step", "", "step in kHz, picked from the frequency by default"),
+		"name":   fs.String("name", "", "channel name"),
+	}
+	lockout := fs.Bool("lockout", false, "lock the channel out of scans")
+	fs.Parse(args[2:])
+	if *fields["freq"] == "" {
+		if !stdinIsTerminal() {
+			return errors.New("-freq is required")
+		}
+		given := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+		if err := promptChannel(bufio.NewScanner(os.Stdin), fields, given); err != nil {
+			return err
+		}
+	}
+
+	ch, err := kenwoodutil.CSVChannel(func(field string) string {
+		if v, ok := fields[field]; ok {
+			return *v
+		}
+		return ""
+	})
+	if err != nil {
+		return err
+	}
+	ch.Number, ch.Name = uint16(n), *fields["name"]
+	if *lockout {
+		ch.LockOut = 1
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	if err := ch.ValidateFor(r.Capabilities()); err != nil {
+		return err
+	}
+	kenwoodutil.WarnNameChanges(r.Capabilities().NameChanges([]kenwoodutil.MemoryEntry{ch}))
+	if err := r.SetChannel(ch); err != nil {
+		return err
+	}
+	if _, err := r.WriteChannel(int(n)); err != nil {
+		return err
+	}
+	log.Info().Str("channel", fmt.Sprintf("%03d", n)).Str("name", ch.Name).Msgf("Channel written, %s MHz", units.FormatMHz(ch.RXFrequency))
+	return nil
+}
+
+func stdinIsTerminal() bool {
+	st, err := os.Stdin.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
+
+// promptChannel asks for the frequency and the fields not given, offering
+// defaults for the frequency: the band plan repeater shift, the step that
+// fits and AM on the airband.
+func promptChannel(in *bufio.Scanner, fields map[string]*string, given map[string]bool) error {
+	ask := func(label, def string) (string, error) {
+		if def != "" {
+			fmt.Printf("%s [%s]: ", label, def)
+		} else {
+			fmt.Printf("%s: ", label)
+		}
+		if !in.Scan() {
+			if err := in.Err(); err != nil {
+				return "", fmt.Errorf("error reading input: %w", err)
+			}
+			return "", errors.New("error: input ended")
+		}
+		if v := strings.TrimSpace(in.Text()); v != "" {
+			return v, nil
+		}
+		return def, nil
+	}
+
+	var hz uint32
+	for hz == 0 {
+		v, err := ask("frequency MHz", "")
+		if err != nil {
+			return err
+		}
+		if hz, err = units.ParseMHz(v); err != nil {
+			fmt.Println(err)
+		}
+	}
+	*fields["freq"] = kenwoodutil.SheetMHz(hz)
+
+	m := kenwoodutil.MemoryEntry{RXFrequency: hz, RXStepSize: units.CanonicalStep(hz, 0)}
+	if c, err := loadConfig(); err == nil {
+		if plan, err := configuredBandPlan(c, ""); err == nil {
+			plan.AutoOffset(&m)
+		}
+	}
+	mode := "FM"
+	if hz >= 108000000 && hz < 137000000 {
+		mode = "AM"
+	}
+	prompts := []struct {
+		field, label, def string
+		skip              bool
+	}{
+		{"offset", "offset MHz, like -7.6", kenwoodutil.SheetColumns["offset"].Value(m), given["offset"] || given["tx"]},
+		{"tone", "access tone Hz", "", given["tone"] || given["ctcss"] || given["dcs"]},
+		{"step", "step kHz", kenwoodutil.SheetColumns["step"].Value(m), given["step"]},
+		{"mode", "mode", mode, given["mode"]},
+		{"name", "name", "", given["name"]},
+	}
+	for _, p := range prompts {
+		if p.skip {
+			continue
+		}
+		v, err := ask(p.label, p.def)
+		if err != nil {
+			return err
+		}
+		*fields[p.field] = v
+	}
+	return nil
+}
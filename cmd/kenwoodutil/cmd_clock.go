@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+func runClock(args []string) error {
+	fs := flag.NewFlagSet("clock", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() > 1 || (fs.NArg() == 1 && fs.Arg(0) != "sync") {
+		return fmt.Errorf("usage: clock [sync]")
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	radio, err := r.GetClock()
+	if err != nil {
+		return err
+	}
+	host := time.Now()
+	fmt.Printf("radio %s\nhost  %s\noffset %s\n", radio.Format(time.RFC3339), host.Format(time.RFC3339), radio.Sub(host).Round(time.Second))
+
+	if fs.Arg(0) == "sync" {
+		// the radio clock has one second resolution, set it on the next
+		// second boundary
+		now := time.Now()
+		next := now.Truncate(time.Second).Add(time.Second)
+		time.Sleep(next.Sub(now))
+		if err := r.SetClock(next); err != nil {
+			return err
+		}
+		log.Info().Time("time", next).Msg("Radio clock set.")
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runClone(args []string) error {
+	fs := flag.NewFlagSet("clone", flag.ExitOnError)
+	to := fs.String("to", "", "serial port (or tcp://host:port) of the destination radio")
+	toBaud := fs.Int("to-baud", 0, "baud rate of the destination radio, defaults to -baud")
+	fs.Parse(args)
+	if *to == "" {
+		return fmt.Errorf("error: destination radio not given, use -to")
+	}
+
+	src, err := openRadio()
+	if err != nil {
+		return fmt.Errorf("error opening source radio: %w", err)
+	}
+	if *to == src.PortPath {
+		return fmt.Errorf("error: source and destination are the same port")
+	}
+	if *toBaud == 0 {
+		*toBaud = *baudRate
+	}
+	dst, err := openRadioAt(*to, *toBaud)
+	if err != nil {
+		return fmt.Errorf("error opening destination radio: %w", err)
+	}
+	log.Info().Str("from", src.Model).Str("to", dst.Model).Msg("Cloning memory...")
+	res, err := kenwoodutil.Clone(src, dst, func(channel int) {
+		if channel%100 == 99 {
+			log.Info().Int("channel", channel).Msg("progress")
+		}
+	})
+	if err != nil {
+		return err
+	}
+	log.Info().Int("copied", res.Copied).Int("cleared", res.Cleared).Msg("Cloning done.")
+	if len(res.NamesSkipped) > 0 {
+		log.Warn().Interface("channels", res.NamesSkipped).Msg("Names were not written for some channels, the destination does not support them there.")
+	}
+	if len(res.Mismatched) > 0 {
+		return fmt.Errorf("error: verification failed for channels %v", res.Mismatched)
+	}
+	log.Info().Msg("Destination memory verified.")
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runCommandCatalog(args []string) error {
+	fs := flag.NewFlagSet("commands", flag.ExitOnError)
+	model := fs.String("model", "", "only list the commands known on `model`, e.g. TM-V71")
+	asJSON := fs.Bool("json", false, "print the catalog as JSON")
+	fs.Parse(args)
+
+	prefix := ""
+	if fs.NArg() > 0 {
+		prefix = fs.Arg(0)
+	}
+	catalog := kenwoodutil.ProtocolCommands(*model, prefix)
+	if *asJSON {
+		j, err := json.MarshalIndent(catalog, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(j))
+		return nil
+	}
+	for _, c := range catalog {
+		printProtocolCommand(os.Stdout, c)
+	}
+	return nil
+}
+
+// printProtocolCommand describes c with its query, set and reply forms.
+func printProtocolCommand(w io.Writer, c kenwoodutil.ProtocolCommand) {
+	desc := c.Description
+	if len(c.Models) > 0 {
+		desc += " (" + strings.Join(c.Models, ", ") + ")"
+	}
+	fmt.Fprintf(w, "%s  %s\n", c.Mnemonic, desc)
+	usage := c.Usage()
+	fmt.Fprintf(w, "    query  %s\n", usage[0])
+	if len(usage) > 1 {
+		fmt.Fprintf(w, "    set    %s\n", usage[1])
+	}
+	fmt.Fprintf(w, "    reply  %s\n", strings.TrimSpace(c.Mnemonic+" "+strings.Join(c.Reply, ",")))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+// watchLoop enforces rules every interval until interrupted, calling after
+// (if set) at the end of every round.
+func watchLoop(r *kenwoodutil.Radio, rules []kenwoodutil.WatchdogRule, interval time.Duration, health *kenwoodutil.Health, after func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	health.Started = time.Now()
+	for {
+		for _, rule := range rules {
+			restored, err := r.Enforce(rule)
+			health.Record(restored, err)
+			if err != nil {
+				log.Error().Err(err).Str("rule", rule.Name).Msg("watchdog check failed")
+				continue
+			}
+			if len(restored) > 0 {
+				log.Warn().Str("rule", rule.Name).Strs("restored", restored).Msg("radio configuration was changed, restored")
+			}
+		}
+		if after != nil {
+			after()
+		}
+		if err := r.Tracer.Flush(); err != nil {
+			log.Warn().Err(err).Msg("traces were not exported")
+		}
+		select {
+		case <-sig:
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	fs.Parse(args)
+
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(c.Daemon.Watchdog) == 0 && c.Daemon.OperatingLog == "" && len(c.Daemon.Schedule) == 0 {
+		return errors.New("no watchdog rules, operating log or schedule in config, nothing to do")
+	}
+	schedule := &kenwoodutil.Scheduler{Profiles: c.Daemon.Schedule}
+	if err := schedule.Check(); err != nil {
+		return err
+	}
+	closeLogs, err := remoteLogging(c.Log)
+	if err != nil {
+		return err
+	}
+	defer closeLogs()
+	interval := time.Duration(c.Daemon.Interval)
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	var oplog *kenwoodutil.OperatingLog
+	if c.Daemon.OperatingLog != "" {
+		oplog = &kenwoodutil.OperatingLog{Path: c.Daemon.OperatingLog}
+	}
+	after := func() {
+		applied, err := schedule.Apply(r, time.Now())
+		if err != nil {
+			log.Error().Err(err).Msg("scheduled profile not applied")
+		}
+		if len(applied) > 0 {
+			log.Info().Strs("profiles", applied).Msg("scheduled profiles applied")
+		}
+		if oplog != nil {
+			if err := oplog.Poll(r, []int{0, 1}); err != nil {
+				log.Error().Err(err).Msg("operating log not updated")
+			}
+		}
+	}
+	log.Info().Dur("interval", interval).Int("rules", len(c.Daemon.Watchdog)).Str("operating log", c.Daemon.OperatingLog).Msg("Daemon started.")
+	watchLoop(r, c.Daemon.Watchdog, interval, &kenwoodutil.Health{}, after)
+	log.Info().Msg("Daemon stopped.")
+	return nil
+}
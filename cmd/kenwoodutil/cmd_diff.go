@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func printDiff(diffs []kenwoodutil.ChannelDiff, oldName, newName string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "CH\tCHANGE\tFIELD\t%s\t%s\n", oldName, newName)
+	for _, d := range diffs {
+		if d.Kind != kenwoodutil.ChannelChanged {
+			fmt.Fprintf(w, "%03d\t%s\t\t\t\n", d.Number, d.Kind)
+			continue
+		}
+		for _, c := range d.Changes {
+			fmt.Fprintf(w, "%03d\t%s\t%s\t%s\t%s\n", d.Number, d.Kind, c.Field, c.Old, c.New)
+		}
+	}
+	return w.Flush()
+}
+
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.Parse(args)
+
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Reading memory...")
+	if err := r.ReadMemory(); err != nil {
+		return fmt.Errorf("error reading memory: %w", err)
+	}
+
+	diffs := kenwoodutil.DiffMemory(r.OccupedChannels(), d.Memory)
+	if len(diffs) == 0 {
+		log.Info().Msg("Radio memory matches the file.")
+		return nil
+	}
+	return printDiff(diffs, "RADIO", "FILE")
+}
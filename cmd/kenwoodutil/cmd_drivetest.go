@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// driveTestMaxFixAge is how old the last GPS fix may be to place a sample.
+const driveTestMaxFixAge = 5 * time.Second
+
+func runDriveTest(args []string) error {
+	fs := flag.NewFlagSet("drivetest", flag.ExitOnError)
+	channel := fs.Int("channel", -1, "memory channel to lock the band to")
+	bandName := fs.String("band", "", "band to sample, A or B, defaults to the control band")
+	interval := fs.Duration("interval", time.Second, "sampling interval")
+	gpsd := fs.String("gpsd", kenwoodutil.DefaultGPSD, "gpsd to take positions from, host:port")
+	out := fs.String("o", "", "CSV file the samples are appended to as they are taken")
+	geojson := fs.String("geojson", "", "also write the samples with a position to this GeoJSON file when stopped")
+	fs.Parse(args)
+	if *channel < 0 || *out == "" {
+		return errors.New("usage: drivetest -channel n -o file.csv [-band A|B] [-interval 1s] [-gpsd host:port] [-geojson file]")
+	}
+
+	f, err := os.OpenFile(*out, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening survey file: %w", err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("error opening survey file: %w", err)
+	}
+	cw := csv.NewWriter(f)
+	if fi.Size() == 0 {
+		cw.Write(kenwoodutil.CoverageHeader)
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	band := -1
+	if *bandName != "" {
+		if band, err = units.ParseBand(*bandName); err != nil {
+			return err
+		}
+	} else if band, _, err = r.GetBand(); err != nil {
+		return err
+	}
+	if err := r.SelectMemoryChannel(band, *channel); err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	var fix kenwoodutil.GPSFix
+	gpsErr := make(chan error, 1)
+	go func() {
+		gpsErr <- kenwoodutil.ReadGPSD(*gpsd, func(f kenwoodutil.GPSFix) bool {
+			if f.Valid {
+				mu.Lock()
+				fix = f
+				mu.Unlock()
+			}
+			return true
+		})
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	var samples []kenwoodutil.CoverageSample
+	noFix := 0
+	log.Info().Int("channel", *channel).Str("band", units.BandName(band)).Str("file", *out).Msg("Drive test started, interrupt to stop.")
+	for {
+		s, err := r.Sample(band)
+		if err != nil {
+			return err
+		}
+		if s.Mode != kenwoodutil.MemoryMode || s.Channel != *channel {
+			return fmt.Errorf("error: band %s left channel %03d, stopping the drive test", units.BandName(band), *channel)
+		}
+		cs := kenwoodutil.CoverageSample{Time: s.Time, Frequency: s.Frequency, SMeter: s.SMeter, Busy: s.Busy}
+		mu.Lock()
+		if s.Time.Sub(fix.Time) <= driveTestMaxFixAge {
+			cs.Fix, cs.Latitude, cs.Longitude = true, fix.Latitude, fix.Longitude
+		}
+		mu.Unlock()
+		if !cs.Fix {
+			if noFix++; noFix == 1 {
+				log.Warn().Msg("No GPS fix, samples are logged without a position.")
+			}
+		} else {
+			noFix = 0
+		}
+		samples = append(samples, cs)
+		cw.Write(cs.CSV())
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("error writing survey file: %w", err)
+		}
+
+		select {
+		case <-sig:
+			log.Info().Int("samples", len(samples)).Msg("Drive test stopped.")
+			if *geojson == "" {
+				return nil
+			}
+			var b bytes.Buffer
+			if err := kenwoodutil.WriteCoverageGeoJSON(&b, samples); err != nil {
+				return err
+			}
+			return kenwoodutil.WriteFileAtomic(*geojson, b.Bytes(), 0644, false)
+		case err := <-gpsErr:
+			return err
+		case <-ticker.C:
+		}
+	}
+}
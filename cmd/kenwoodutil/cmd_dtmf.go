@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runDTMF(args []string) error {
+	fs := flag.NewFlagSet("dtmf", flag.ExitOnError)
+	path := fs.String("f", defaultDumpPath, "dump file to edit")
+	fs.Parse(args)
+
+	d, err := loadDumpOrNew(*path)
+	if err != nil {
+		return err
+	}
+
+	cmd := fs.Arg(0)
+	if cmd == "" {
+		cmd = "list"
+	}
+	switch {
+	case cmd == "list" && fs.NArg() <= 1:
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(w, "NO\tNAME\tCODE\n")
+		for _, e := range d.DTMF {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", e.Number, e.Name, e.Code)
+		}
+		return w.Flush()
+
+	case cmd == "set" && (fs.NArg() == 3 || fs.NArg() == 4):
+		n, err := strconv.Atoi(fs.Arg(1))
+		if err != nil || n < 0 || n >= kenwoodutil.DTMFMemories {
+			return fmt.Errorf("error: bad DTMF memory number %q", fs.Arg(1))
+		}
+		e := kenwoodutil.DTMFEntry{Number: uint8(n), Code: strings.ToUpper(fs.Arg(2)), Name: fs.Arg(3)}
+		if err := e.Validate(); err != nil {
+			return err
+		}
+		d.DTMF = setDTMF(d.DTMF, e)
+
+	case cmd == "clear" && fs.NArg() == 2:
+		n, err := strconv.Atoi(fs.Arg(1))
+		if err != nil || n < 0 || n >= kenwoodutil.DTMFMemories {
+			return fmt.Errorf("error: bad DTMF memory number %q", fs.Arg(1))
+		}
+		d.DTMF = setDTMF(d.DTMF, kenwoodutil.DTMFEntry{Number: uint8(n)})
+
+	default:
+		return fmt.Errorf("usage: dtmf [-f file] list | set n code [name] | clear n")
+	}
+	return d.Save(*path)
+}
+
+// setDTMF replaces memory e.Number in entries, dropping it if e is empty,
+// and keeps entries sorted by number.
+func setDTMF(entries []kenwoodutil.DTMFEntry, e kenwoodutil.DTMFEntry) []kenwoodutil.DTMFEntry {
+	var v []kenwoodutil.DTMFEntry
+	for _, old := range entries {
+		if old.Number < e.Number {
+			v = append(v, old)
+		}
+	}
+	if e.Code != "" || e.Name != "" {
+		v = append(v, e)
+	}
+	for _, old := range entries {
+		if old.Number > e.Number {
+			v = append(v, old)
+		}
+	}
+	return v
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+const editHelp = `list [from-to]          show channels, M marks changed ones and D deleted ones
+show N                  show all fields of a channel
+set N field=value ...   change fields, of ` + "%s" + `
+del N / undel N         mark a channel for deletion or take it back
+diff                    show what commit would write
+commit                  write changed channels
+quit                    leave, quit! drops uncommitted changes`
+
+func runEdit(args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	file := fs.String("f", "", "edit a dump file instead of the radio, commit saves it")
+	force := fs.Bool("force", false, "allow changing pinned channels of the dump file")
+	lines := fs.Bool("lines", false, "read editor commands line by line even on a terminal")
+	fs.Parse(args)
+	// standard input has the commands
+	if *file == kenwoodutil.StdioPath {
+		return fmt.Errorf("error: -f needs a dump file, not %s", kenwoodutil.StdioPath)
+	}
+
+	var e *kenwoodutil.MemoryEditor
+	var commit func() error
+	if *file != "" {
+		d, err := loadDumpOrNew(*file)
+		if err != nil {
+			return err
+		}
+		e = kenwoodutil.NewMemoryEditor(d.Memory, kenwoodutil.Capabilities{})
+		if !*force {
+			e.Pinned = d.Pins()
+		}
+		commit = func() error {
+			if n := d.ReplaceMemory(e.Memory(), "edit"); n > 0 {
+				log.Info().Int("channels", n).Msg("Deleted channels moved to the trash.")
+			}
+			if err := d.Save(*file); err != nil {
+				return err
+			}
+			e.Reset(d.Memory)
+			return nil
+		}
+	} else {
+		r, err := openRadio()
+		if err != nil {
+			return err
+		}
+		log.Info().Msg("Reading memory...")
+		if err := r.ReadMemory(); err != nil {
+			return err
+		}
+		e = kenwoodutil.NewMemoryEditor(r.OccupedChannels(), r.Capabilities())
+		commit = func() error { return e.Commit(r) }
+	}
+
+	if !*lines {
+		if t, err := openTerminal(); err == nil {
+			defer t.Close()
+			return runEditView(t, e, commit)
+		}
+	}
+
+	in := bufio.NewScanner(os.Stdin)
+	fmt.Println(`Type "help" for commands.`)
+	for {
+		fmt.Print("> ")
+		if !in.Scan() {
+			break
+		}
+		words := strings.Fields(in.Text())
+		if len(words) == 0 {
+			continue
+		}
+		if words[0] == "quit" || words[0] == "quit!" || words[0] == "exit" {
+			if n := len(e.Changes()); n > 0 && words[0] != "quit!" {
+				fmt.Printf("%d channels have uncommitted changes, commit or use quit!\n", n)
+				continue
+			}
+			return nil
+		}
+		if words[0] == "commit" {
+			n := len(e.Changes())
+			if err := commit(); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Printf("%d channels written.\n", n)
+			continue
+		}
+		if err := editCommand(os.Stdout, e, words); err != nil {
+			fmt.Println(err)
+		}
+	}
+	if err := in.Err(); err != nil {
+		return fmt.Errorf("error reading input: %w", err)
+	}
+	if n := len(e.Changes()); n > 0 {
+		log.Warn().Int("channels", n).Msg("input ended, uncommitted changes dropped")
+	}
+	return nil
+}
+
+func parseEditChannel(e *kenwoodutil.MemoryEditor, s string) (uint16, error) {
+	n, err := strconv.ParseUint(s, 10, 16)
+	if err != nil || int(n) >= e.Capabilities.MemorySize() {
+		return 0, fmt.Errorf("error: %q is not a channel number", s)
+	}
+	return uint16(n), nil
+}
+
+// editCommand runs one editor command but commit and quit.
+func editCommand(w io.Writer, e *kenwoodutil.MemoryEditor, words []string) error {
+	switch words[0] {
+	case "help":
+		fmt.Fprintf(w, editHelp+"\n", strings.Join(kenwoodutil.EditFields, ", "))
+	case "list", "ls":
+		from, to := 0, e.Capabilities.MemorySize()-1
+		if len(words) > 1 {
+			list, err := units.ParseChannelList(words[1])
+			if err != nil {
+				return err
+			}
+			from, to = list[0], list[len(list)-1]
+		}
+		var channels []kenwoodutil.MemoryEntry
+		for _, m := range e.Channels() {
+			if int(m.Number) >= from && int(m.Number) <= to {
+				channels = append(channels, m)
+			}
+		}
+		rows := kenwoodutil.ChannelList(channels)
+		rows[0] = append([]string{""}, rows[0]...)
+		for i, m := range channels {
+			rows[i+1] = append([]string{e.Status(m.Number)}, rows[i+1]...)
+		}
+		return kenwoodutil.WriteTable(w, rows)
+	case "show":
+		if len(words) != 2 {
+			return errors.New("usage: show N")
+		}
+		n, err := parseEditChannel(e, words[1])
+		if err != nil {
+			return err
+		}
+		m, ok := e.Channel(n)
+		if !ok {
+			return fmt.Errorf("error: channel %03d is empty", n)
+		}
+		var rows [][]string
+		for _, c := range []string{"ch", "name", "rx", "tx", "offset", "tone", "ctcss", "dcs", "mode", "step", "lockout"} {
+			rows = append(rows, []string{kenwoodutil.SheetColumns[c].Header, kenwoodutil.SheetColumns[c].Value(m)})
+		}
+		return kenwoodutil.WriteTable(w, rows)
+	case "set":
+		if len(words) < 3 {
+			return errors.New("usage: set N field=value ...")
+		}
+		n, err := parseEditChannel(e, words[1])
+		if err != nil {
+			return err
+		}
+		// names may have spaces, so take the rest of the line apart at
+		// field names
+		return e.SetFields(n, splitAssignments(strings.Join(words[2:], " ")))
+	case "del", "undel":
+		if len(words) != 2 {
+			return fmt.Errorf("usage: %s N", words[0])
+		}
+		n, err := parseEditChannel(e, words[1])
+		if err != nil {
+			return err
+		}
+		if words[0] == "del" {
+			return e.Delete(n)
+		}
+		return e.Undelete(n)
+	case "diff":
+		changes := e.Changes()
+		if len(changes) == 0 {
+			fmt.Fprintln(w, "No changes.")
+			return nil
+		}
+		return printDiff(changes, "BEFORE", "AFTER")
+	default:
+		return fmt.Errorf("unknown command %q, try help", words[0])
+	}
+	return nil
+}
+
+// splitAssignments splits "name=Club Net freq=145.5" into field, value
+// pairs.
+func splitAssignments(s string) (v [][2]string) {
+	for _, word := range strings.Fields(s) {
+		kv := strings.SplitN(word, "=", 2)
+		if len(kv) == 2 {
+			v = append(v, [2]string{kv[0], kv[1]})
+		} else if len(v) > 0 {
+			v[len(v)-1][1] += " " + word
+		} else {
+			v = append(v, [2]string{word, ""})
+		}
+	}
+	return v
+}
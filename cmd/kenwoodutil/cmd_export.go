@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "", "csv or xlsx, by default taken from the -o extension, csv for standard output")
+	out := fs.String("o", "", "spreadsheet file to write")
+	columnList := fs.String("columns", strings.Join(kenwoodutil.DefaultSheetColumns, ","), "columns to write, of ch, name, rx, tx, split, offset, tone, ctcss, dcs, mode, step, lockout")
+	only := fs.String("only", "", "export only the channels of these banks of 100, groups or tags, like bank:3,group:club,tag:SOTA")
+	share := fs.Bool("share", false, "replace the frequencies and names of channels tagged "+kenwoodutil.PrivateTag+" with placeholders")
+	fs.Parse(args)
+	if *out == "" {
+		return errors.New("usage: export [-format csv|xlsx] [-columns ch,name,rx,...] [-only bank:3,group:name,tag:SOTA] [-share] -o sheet [file]")
+	}
+	columns, err := kenwoodutil.ParseSheetColumns(*columnList)
+	if err != nil {
+		return err
+	}
+	if *format == "" && *out == kenwoodutil.StdioPath {
+		*format = "csv"
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*out)), ".")
+	}
+
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+	memory := d.Memory
+	if *only != "" {
+		f, err := kenwoodutil.ParseChannelFilter(*only)
+		if err != nil {
+			return err
+		}
+		if memory, err = f.Select(d); err != nil {
+			return err
+		}
+		if len(memory) == 0 {
+			return fmt.Errorf("error: no channels match %s", *only)
+		}
+	}
+	var private map[uint16]bool
+	if *share {
+		private = d.Tagged(kenwoodutil.PrivateTag)
+	}
+	rows := kenwoodutil.SharedSheetRows(memory, columns, private)
+
+	var b bytes.Buffer
+	switch *format {
+	case "csv":
+		w := csv.NewWriter(&b)
+		w.WriteAll(rows)
+		if err := w.Error(); err != nil {
+			return fmt.Errorf("error writing spreadsheet: %w", err)
+		}
+	case "xlsx":
+		if err := kenwoodutil.WriteXLSX(&b, rows); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown spreadsheet format %q, use csv or xlsx", *format)
+	}
+	if err := kenwoodutil.WriteFileAtomic(*out, b.Bytes(), 0644, false); err != nil {
+		return fmt.Errorf("error writing spreadsheet: %w", err)
+	}
+	log.Info().Int("channels", len(rows)-1).Str("file", *out).Msg("Export done.")
+	return nil
+}
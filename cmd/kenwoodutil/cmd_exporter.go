@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runExporter(args []string) error {
+	fs := flag.NewFlagSet("exporter", flag.ExitOnError)
+	listen := fs.String("listen", ":9188", "address to serve metrics on")
+	interval := fs.Duration("interval", 5*time.Second, "how often to poll the radio")
+	fs.Parse(args)
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	e := &kenwoodutil.MetricsExporter{Radio: r}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	srv := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	stop := make(chan struct{})
+	go func() {
+		for {
+			if err := e.Poll(); err != nil {
+				log.Warn().Err(err).Msg("poll failed")
+			}
+			select {
+			case <-stop:
+				return
+			case <-time.After(*interval):
+			}
+		}
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		close(stop)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	log.Info().Str("listen", *listen).Msg("Serving metrics on /metrics.")
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Info().Msg("Exporter stopped.")
+	return nil
+}
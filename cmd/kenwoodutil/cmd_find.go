@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runFind(args []string) error {
+	fs := flag.NewFlagSet("find", flag.ExitOnError)
+	freq := fs.String("freq", "", "receive or transmit frequency in MHz")
+	name := fs.String("name", "", "channel name pattern, like \"*club*\"")
+	band := fs.String("band", "", "amateur band, like 2m or 70cm")
+	tone := fs.Float64("tone", 0, "tone or CTCSS frequency in Hz")
+	fromRadio := fs.Bool("radio", false, "search the channels in the radio instead of a dump")
+	fs.Parse(args)
+
+	q := kenwoodutil.ChannelQuery{Name: *name, Band: *band, Tone: *tone}
+	if *freq != "" {
+		hz, err := units.ParseMHz(*freq)
+		if err != nil {
+			return err
+		}
+		q.Frequency = hz
+	}
+	if q == (kenwoodutil.ChannelQuery{}) {
+		return errors.New("usage: find [-freq MHz] [-name pattern] [-band 2m|70cm|...] [-tone Hz] [-radio] [file]")
+	}
+	if err := q.Validate(); err != nil {
+		return err
+	}
+
+	var channels []kenwoodutil.MemoryEntry
+	if *fromRadio {
+		r, err := openRadio()
+		if err != nil {
+			return err
+		}
+		if err := r.ReadMemory(); err != nil {
+			return err
+		}
+		special, err := r.ReadSpecialChannels()
+		if err != nil {
+			return err
+		}
+		channels = append(r.OccupedChannels(), special...)
+	} else {
+		path := defaultDumpPath
+		if fs.NArg() > 0 {
+			path = fs.Arg(0)
+		}
+		d, err := kenwoodutil.LoadDump(path)
+		if err != nil {
+			return err
+		}
+		channels = append(append([]kenwoodutil.MemoryEntry(nil), d.Memory...), d.Special...)
+	}
+
+	found := kenwoodutil.FindChannels(channels, q)
+	if len(found) == 0 {
+		return errors.New("error: no channels match")
+	}
+	return kenwoodutil.WriteTable(os.Stdout, kenwoodutil.ChannelList(found))
+}
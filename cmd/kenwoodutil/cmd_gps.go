@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runGPS(args []string) error {
+	fs := flag.NewFlagSet("gps", flag.ExitOnError)
+	format := fs.String("format", "nmea", "output format, nmea sentences as received or json fixes")
+	valid := fs.Bool("valid", false, "only print fixes the receiver marks valid, with -format json")
+	gpsd := fs.String("gpsd", "", "read fixes from gpsd at host:port, like "+kenwoodutil.DefaultGPSD+", instead of the radio; only with -format json")
+	fs.Parse(args)
+	if *format != "nmea" && *format != "json" {
+		return fmt.Errorf("error: unknown format %q, expected nmea or json", *format)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	var werr error
+	if *gpsd != "" {
+		if *format != "json" {
+			return fmt.Errorf("error: gpsd fixes are only printed with -format json")
+		}
+		err := kenwoodutil.ReadGPSD(*gpsd, func(fix kenwoodutil.GPSFix) bool {
+			if fix.Valid || !*valid {
+				werr = enc.Encode(fix)
+			}
+			return werr == nil
+		})
+		if err != nil {
+			return err
+		}
+		return werr
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	err = r.ReadGPS(func(sentence string, fix kenwoodutil.GPSFix, complete bool) bool {
+		switch {
+		case *format == "nmea":
+			_, werr = fmt.Println(sentence)
+		case complete && (fix.Valid || !*valid):
+			werr = enc.Encode(fix)
+		}
+		return werr == nil
+	})
+	if err != nil {
+		return err
+	}
+	return werr
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+const groupUsage = "usage: group [-channels 100-199] [-band A|B] [-force] list|add|remove|read|write|select [name] [file]"
+
+func runGroup(args []string) error {
+	fs := flag.NewFlagSet("group", flag.ExitOnError)
+	channels := fs.String("channels", "", "channel range of a group to add, like 100-199")
+	bandName := fs.String("band", "", "band to tune for select, A or B, defaults to the control band")
+	force := fs.Bool("force", false, "let read replace pinned channels of the dump")
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		return errors.New(groupUsage)
+	}
+	cmd := fs.Arg(0)
+	var name string
+	rest := fs.Args()[1:]
+	if cmd != "list" {
+		if len(rest) < 1 {
+			return errors.New(groupUsage)
+		}
+		name, rest = rest[0], rest[1:]
+	}
+	if len(rest) > 1 {
+		return errors.New(groupUsage)
+	}
+	path := defaultDumpPath
+	if len(rest) > 0 {
+		path = rest[0]
+	}
+	d, err := kenwoodutil.LoadDump(path)
+	if errors.Is(err, os.ErrNotExist) && cmd == "add" {
+		d, err = &kenwoodutil.Dump{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "list":
+		if len(d.Groups) == 0 {
+			fmt.Println("no groups")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "GROUP\tCHANNELS\tUSED")
+		for _, g := range d.Groups {
+			fmt.Fprintf(w, "%s\t%03d-%03d\t%d\n", g.Name, g.First, g.Last, len(d.GroupChannels(g)))
+		}
+		return w.Flush()
+	case "add":
+		list, err := units.ParseChannelList(*channels)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 || list[len(list)-1]-list[0] != len(list)-1 {
+			return fmt.Errorf("error: -channels must be one range of channels, like 100-199")
+		}
+		if err := d.AddGroup(kenwoodutil.MemoryGroup{Name: name, First: uint16(list[0]), Last: uint16(list[len(list)-1])}); err != nil {
+			return err
+		}
+		return d.Save(path)
+	case "remove":
+		if err := d.RemoveGroup(name); err != nil {
+			return err
+		}
+		return d.Save(path)
+	}
+
+	g, err := d.Group(name)
+	if err != nil {
+		return err
+	}
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "read":
+		log.Info().Str("group", g.Name).Msg("Reading group...")
+		channels, err := r.ReadGroup(g)
+		if err != nil {
+			return err
+		}
+		trashed, err := d.ReplaceGroup(g, channels, "removed on radio "+r.Model, *force)
+		if err != nil {
+			return fmt.Errorf("%w, use -force to replace it", err)
+		}
+		for _, m := range channels {
+			d.RecordImport(m.Number, "radio "+r.Model)
+		}
+		if err := d.Save(path); err != nil {
+			return err
+		}
+		log.Info().Int("channels", len(channels)).Int("trashed", trashed).Msg("Reading group done.")
+	case "write":
+		log.Info().Str("group", g.Name).Msg("Writing group...")
+		summary, err := r.WriteGroup(g, d.GroupChannels(g))
+		if err != nil {
+			return err
+		}
+		log.Info().Int("channels", summary.Written).Msg("Writing group done.")
+		if len(summary.NamesSkipped) > 0 {
+			log.Warn().Interface("channels", summary.NamesSkipped).Msg("Names were not written for some channels, the radio does not support them there.")
+		}
+	case "select":
+		band := -1
+		if *bandName != "" {
+			band, err = units.ParseBand(*bandName)
+		} else {
+			band, _, err = r.GetBand()
+		}
+		if err != nil {
+			return err
+		}
+		channels := d.GroupChannels(g)
+		if len(channels) == 0 {
+			return fmt.Errorf("error: group %s has no channels", g)
+		}
+		if err := r.SelectMemoryChannel(band, int(channels[0].Number)); err != nil {
+			return err
+		}
+		fmt.Printf("band %s on %03d %s\n", units.BandName(band), channels[0].Number, channels[0].Name)
+	default:
+		return fmt.Errorf("unknown group command %q", cmd)
+	}
+	return nil
+}
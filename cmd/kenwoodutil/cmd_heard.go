@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runHeard(args []string) error {
+	fs := flag.NewFlagSet("heard", flag.ExitOnError)
+	trackPath := fs.String("track", "", "track of JSON fixes, as written by gps -format json")
+	format := fs.String("format", "", "output format, kml or geojson, by default from the -o extension")
+	out := fs.String("o", "-", "file to write, - for standard output")
+	freq := fs.String("freq", "", "only receptions on this frequency, in MHz")
+	channel := fs.String("channel", "", "only receptions on this channel number or name")
+	maxGap := fs.Duration("max-gap", 30*time.Second, "leave out receptions with no fix this close in time")
+	fs.Parse(args)
+	if *trackPath == "" || fs.NArg() == 0 {
+		return errors.New("usage: heard -track file [-format kml|geojson] [-o file] [-freq MHz] [-channel ch] [-max-gap 30s] log.csv...")
+	}
+	if *format == "" {
+		*format = "kml"
+		if strings.EqualFold(filepath.Ext(*out), ".geojson") || strings.EqualFold(filepath.Ext(*out), ".json") {
+			*format = "geojson"
+		}
+	}
+	if *format != "kml" && *format != "geojson" {
+		return fmt.Errorf("error: unknown format %q, expected kml or geojson", *format)
+	}
+	var hz uint32
+	if *freq != "" {
+		var err error
+		if hz, err = units.ParseMHz(*freq); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(*trackPath)
+	if err != nil {
+		return fmt.Errorf("error opening track: %w", err)
+	}
+	track, err := kenwoodutil.ReadTrack(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	var hits []kenwoodutil.Hit
+	for _, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("error opening reception log: %w", err)
+		}
+		v, err := kenwoodutil.ReadHitLog(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, h := range v {
+			if hz != 0 && h.Frequency != hz {
+				continue
+			}
+			if *channel != "" && h.Channel != *channel && !strings.EqualFold(h.Name, *channel) {
+				continue
+			}
+			hits = append(hits, h)
+		}
+	}
+
+	points := kenwoodutil.LocateHits(hits, track, *maxGap)
+	if len(points) < len(hits) {
+		log.Warn().Int("receptions", len(hits)-len(points)).Msg("Receptions without a fix close enough were left out.")
+	}
+	var b bytes.Buffer
+	if *format == "kml" {
+		err = kenwoodutil.WriteHeardKML(&b, points)
+	} else {
+		err = kenwoodutil.WriteHeardGeoJSON(&b, points)
+	}
+	if err != nil {
+		return err
+	}
+	if err := kenwoodutil.WriteFileAtomic(*out, b.Bytes(), 0644, false); err != nil {
+		return fmt.Errorf("error writing map: %w", err)
+	}
+	log.Info().Int("receptions", len(points)).Msg("Map written.")
+	return nil
+}
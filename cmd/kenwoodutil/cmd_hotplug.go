@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+// hotplugAttempts is how many times a port that appeared is tried before
+// giving up until it comes back, radios take a while to boot.
+const hotplugAttempts = 5
+
+func portPresent(path string) (bool, error) {
+	ports, err := kenwoodutil.ListPorts()
+	if err != nil {
+		return false, err
+	}
+	for _, p := range ports {
+		if p.Name == path {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hotplugConnect runs the connect macro on the radio that appeared on the
+// port.
+func hotplugConnect(c *kenwoodutil.Config, interval time.Duration) error {
+	m, ok := c.Macros[c.Hotplug.OnConnect]
+	if !ok {
+		return fmt.Errorf("no macro named %q in %s", c.Hotplug.OnConnect, *configPath)
+	}
+	var r *kenwoodutil.Radio
+	var err error
+	for attempt := 1; attempt <= hotplugAttempts; attempt++ {
+		time.Sleep(interval)
+		if r, err = openRadioAt(*portPath, *baudRate); err == nil {
+			break
+		}
+		log.Debug().Err(err).Int("attempt", attempt).Msg("radio not answering yet")
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return r.RunMacro(m, nil)
+}
+
+// runHookPrograms runs the programs of a hook one after another, with env
+// added to their environment. Failures are logged and do not stop the rest.
+func runHookPrograms(hook string, programs [][]string, env ...string) {
+	for _, argv := range programs {
+		if len(argv) == 0 {
+			continue
+		}
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Env = append(os.Environ(), env...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			log.Error().Err(err).Str("command", strings.Join(argv, " ")).Str("output", string(out)).Msgf("%s command failed", hook)
+		}
+	}
+}
+
+// hotplugDisconnect flushes what is buffered for remote collectors and runs
+// the disconnect programs.
+func hotplugDisconnect(c *kenwoodutil.Config) {
+	if err := tracer.Flush(); err != nil {
+		log.Warn().Err(err).Msg("traces were not exported")
+	}
+	runHookPrograms("disconnect", c.Hotplug.OnDisconnect)
+}
+
+func runHotplug(args []string) error {
+	fs := flag.NewFlagSet("hotplug", flag.ExitOnError)
+	fs.Parse(args)
+
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if c.Hotplug.OnConnect == "" && len(c.Hotplug.OnDisconnect) == 0 {
+		return errors.New("no hotplug actions in config, nothing to do")
+	}
+	if strings.Contains(*portPath, "://") {
+		return fmt.Errorf("error: hotplug needs a serial port, not %s", *portPath)
+	}
+	interval := time.Duration(c.Hotplug.Interval)
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	closeLogs, err := remoteLogging(c.Log)
+	if err != nil {
+		return err
+	}
+	defer func() { closeLogs() }()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	log.Info().Str("port", *portPath).Dur("interval", interval).Msg("Waiting for the radio port.")
+	was := false
+	for {
+		present, err := portPresent(*portPath)
+		if err != nil {
+			log.Error().Err(err).Msg("listing ports")
+		}
+		switch {
+		case err == nil && present && !was:
+			log.Info().Str("port", *portPath).Msg("Port appeared.")
+			if c.Hotplug.OnConnect != "" {
+				if err := hotplugConnect(c, interval); err != nil {
+					log.Error().Err(err).Msg("connect hook failed")
+				} else {
+					log.Info().Str("macro", c.Hotplug.OnConnect).Msg("Connect hook done.")
+				}
+			}
+		case err == nil && !present && was:
+			log.Info().Str("port", *portPath).Msg("Port disappeared.")
+			// closing the remote logs sends what they hold, then they are
+			// opened again for the next drive
+			closeLogs()
+			hotplugDisconnect(c)
+			if closeLogs, err = remoteLogging(c.Log); err != nil {
+				return err
+			}
+		}
+		if err == nil {
+			was = present
+		}
+		select {
+		case <-sig:
+			return nil
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runIGate(args []string) error {
+	fs := flag.NewFlagSet("igate", flag.ExitOnError)
+	fs.Parse(args)
+
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	closeLogs, err := remoteLogging(c.Log)
+	if err != nil {
+		return err
+	}
+	defer closeLogs()
+	rules, err := c.IGate.Rules()
+	if err != nil {
+		return err
+	}
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+
+	if c.IGate.APRS != nil {
+		if err := r.WriteAPRSConfig(c.IGate.APRS); err != nil {
+			return err
+		}
+	}
+
+	health := &kenwoodutil.Health{}
+	report := func() {
+		if band, err := units.ParseBand(rules[0].Band); err == nil {
+			if s, err := r.GetSMeter(band); err == nil {
+				health.SMeter = s
+			}
+		}
+		log.Info().Int("checks", health.Checks).Int("restores", health.Restores).Int("errors", health.Errors).Int("smeter", health.SMeter).Msg("igate health")
+		if c.IGate.HealthFile == "" {
+			return
+		}
+		j, err := json.MarshalIndent(health, "", "  ")
+		if err == nil {
+			err = kenwoodutil.WriteFileAtomic(c.IGate.HealthFile, j, 0644, false)
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("error writing health file")
+		}
+	}
+	log.Info().Str("band", rules[0].Band).Str("frequency", rules[0].Frequency).Msg("igate mode started")
+	watchLoop(r, rules, c.IGate.CheckInterval(), health, report)
+	log.Info().Msg("igate mode stopped")
+	return nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runImport(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: import repeaterbook|csv|xlsx|mcp [flags] [file]")
+	}
+	switch args[0] {
+	case "repeaterbook":
+		return runImportRepeaterBook(args[1:])
+	case "csv", "xlsx":
+		return runImportSheet(args[0], args[1:])
+	case "mcp":
+		return runImportMCP(args[1:])
+	}
+	return fmt.Errorf("unknown import source %q", args[0])
+}
+
+// mergeImported adds channels not already present in d into free slots out
+// of candidates, returning how many were added.
+func mergeImported(d *kenwoodutil.Dump, channels []kenwoodutil.MemoryEntry, candidates []int, source string) int {
+	free := d.FreeChannels(candidates)
+	added := 0
+	for _, m := range channels {
+		duplicate := false
+		for _, existing := range d.Memory {
+			if kenwoodutil.SameRepeater(existing, m) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			log.Debug().Str("name", m.Name).Msg("already in memory, skipping")
+			continue
+		}
+		if added == len(free) {
+			log.Warn().Int("left", len(channels)-added).Msg("channel range is full, not all channels were imported")
+			break
+		}
+		m.Number = uint16(free[added])
+		d.Memory = append(d.Memory, m)
+		d.RecordImport(m.Number, source)
+		added++
+	}
+	sort.SliceStable(d.Memory, func(i, j int) bool { return d.Memory[i].Number < d.Memory[j].Number })
+	return added
+}
+
+// transmittable drops the repeater and split channels that the model of
+// the -radio profile cannot transmit on.
+func transmittable(channels []kenwoodutil.MemoryEntry) []kenwoodutil.MemoryEntry {
+	return kenwoodutil.Transmittable(channels, expectedModel)
+}
+
+func runImportRepeaterBook(args []string) error {
+	fs := flag.NewFlagSet("import repeaterbook", flag.ExitOnError)
+	country := fs.String("country", "", "country to query, like \"Poland\" or \"United States\"")
+	lat := fs.Float64("lat", 0, "latitude of the search center")
+	lon := fs.Float64("lon", 0, "longitude of the search center")
+	radius := fs.Float64("radius", 50, "search radius in kilometres")
+	channels := fs.String("channels", "500-599", "channel range to fill, like 500-599")
+	fs.Parse(args)
+
+	if *country == "" {
+		return errors.New("-country is required")
+	}
+	candidates, err := units.ParseChannelList(*channels)
+	if err != nil {
+		return err
+	}
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	d, err := loadDumpOrNew(path)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("country", *country).Msg("Querying RepeaterBook...")
+	repeaters, err := kenwoodutil.FetchRepeaterBook(*country)
+	if err != nil {
+		return err
+	}
+	var near []kenwoodutil.Repeater
+	for _, rp := range repeaters {
+		if rp.Distance(*lat, *lon) <= *radius {
+			near = append(near, rp)
+		}
+	}
+	sort.Slice(near, func(i, j int) bool {
+		return near[i].Distance(*lat, *lon) < near[j].Distance(*lat, *lon)
+	})
+	var entries []kenwoodutil.MemoryEntry
+	for _, rp := range near {
+		entries = append(entries, rp.MemoryEntry())
+	}
+
+	added := mergeImported(d, transmittable(entries), candidates, "repeaterbook")
+	if err := d.Save(path); err != nil {
+		return err
+	}
+	log.Info().Int("found", len(near)).Int("added", added).Msg("Import done.")
+	return nil
+}
+
+func runImportSheet(format string, args []string) error {
+	fs := flag.NewFlagSet("import "+format, flag.ExitOnError)
+	mapping := fs.String("map", "", "column mapping, like \"freq=Frequency MHz,name=Label\"; fields: "+strings.Join(kenwoodutil.CSVFields, ", "))
+	mapFile := fs.String("mapfile", "", "JSON file with the column mapping")
+	channels := fs.String("channels", "500-599", "channel range to fill when the spreadsheet has no channel numbers")
+	offline := fs.Bool("offline", false, "use the cached copy of a sheet URL instead of downloading it")
+	autoOffset := fs.Bool("auto-offset", false, "give channels without tx or offset the standard repeater shift of the band plan")
+	region := fs.String("region", "", "band plan region for -auto-offset, R1, R2 or R3, instead of the configured one")
+	force := fs.Bool("force", false, "replace pinned channels too")
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: import %s [-map ...] [-mapfile file] [-channels 500-599] [-offline] [-auto-offset [-region R1|R2|R3]] [-force] sheet.%s|url [file]", format, format)
+	}
+
+	var m kenwoodutil.CSVMapping
+	var err error
+	if *mapFile != "" {
+		m, err = kenwoodutil.LoadCSVMapping(*mapFile)
+	} else {
+		m, err = kenwoodutil.ParseCSVMapping(*mapping)
+	}
+	if err != nil {
+		return err
+	}
+	candidates, err := units.ParseChannelList(*channels)
+	if err != nil {
+		return err
+	}
+	path := defaultDumpPath
+	if fs.NArg() > 1 {
+		path = fs.Arg(1)
+	}
+	d, err := loadDumpOrNew(path)
+	if err != nil {
+		return err
+	}
+
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	var plan *kenwoodutil.BandPlan
+	if *autoOffset {
+		if plan, err = configuredBandPlan(c, *region); err != nil {
+			return err
+		}
+	}
+	fill := func(m *kenwoodutil.MemoryEntry, given func(field string) bool) error {
+		// the shift goes first, band default tones are for repeaters
+		if plan != nil && !given("tx") && !given("offset") {
+			plan.AutoOffset(m)
+		}
+		return kenwoodutil.ApplyBandDefaults(m, c.BandDefaults, given)
+	}
+	entries, hasNumbers, err := kenwoodutil.ReadSheetChannels(format, fs.Arg(0), m, fill, *offline)
+	if err != nil {
+		return err
+	}
+	entries = transmittable(entries)
+	source := format + " " + filepath.Base(fs.Arg(0))
+	if u, err := url.Parse(fs.Arg(0)); kenwoodutil.IsSheetURL(fs.Arg(0)) && err == nil {
+		source = format + " " + u.Host
+	}
+	added := len(entries)
+	if hasNumbers {
+		if err := checkImportNumbers(d, entries); err != nil {
+			return err
+		}
+		if !*force {
+			var numbers []uint16
+			for _, m := range entries {
+				numbers = append(numbers, m.Number)
+			}
+			if err := d.CheckPinned(numbers); err != nil {
+				return fmt.Errorf("%w, use -force to replace it", err)
+			}
+		}
+		kenwoodutil.PlaceImported(d, entries, source)
+	} else {
+		added = mergeImported(d, entries, candidates, source)
+	}
+	if err := d.Save(path); err != nil {
+		return err
+	}
+	log.Info().Int("found", len(entries)).Int("added", added).Msg("Import done.")
+	return nil
+}
+
+func runImportMCP(args []string) error {
+	fs := flag.NewFlagSet("import mcp", flag.ExitOnError)
+	force := fs.Bool("force", false, "replace pinned channels too")
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		return errors.New("usage: import mcp [-force] export.hmk [file]")
+	}
+	if strings.EqualFold(filepath.Ext(fs.Arg(0)), ".mc") {
+		return errors.New("error: .mc files are MCP's own binary format, export the channels to .hmk from MCP and import that")
+	}
+	path := defaultDumpPath
+	if fs.NArg() > 1 {
+		path = fs.Arg(1)
+	}
+	d, err := loadDumpOrNew(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := kenwoodutil.ReadFileOrStdin(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error reading MCP file: %w", err)
+	}
+	entries, err := kenwoodutil.ReadHMKChannels(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	entries = transmittable(entries)
+	if err := checkImportNumbers(d, entries); err != nil {
+		return err
+	}
+	if !*force {
+		var numbers []uint16
+		for _, m := range entries {
+			numbers = append(numbers, m.Number)
+		}
+		if err := d.CheckPinned(numbers); err != nil {
+			return fmt.Errorf("%w, use -force to replace it", err)
+		}
+	}
+	kenwoodutil.PlaceImported(d, entries, "mcp "+filepath.Base(fs.Arg(0)))
+	if err := d.Save(path); err != nil {
+		return err
+	}
+	log.Info().Int("added", len(entries)).Msg("Import done.")
+	return nil
+}
+
+// checkImportNumbers fails on channels numbered past the memory of the
+// radio d is for.
+func checkImportNumbers(d *kenwoodutil.Dump, channels []kenwoodutil.MemoryEntry) error {
+	size := d.MemorySize()
+	for _, m := range channels {
+		if int(m.Number) >= size {
+			return fmt.Errorf("error: channel %d is not in the %03d-%03d memory", m.Number, 0, size-1)
+		}
+	}
+	return nil
+}
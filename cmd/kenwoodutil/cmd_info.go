@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runInfo(args []string) error {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the radio info and capabilities as JSON")
+	fs.Parse(args)
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	info := r.Info()
+	c := r.Capabilities()
+	if *asJSON {
+		j, err := json.MarshalIndent(struct {
+			Radio        *kenwoodutil.RadioInfo
+			Capabilities kenwoodutil.Capabilities
+		}{info, c}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding info: %w", err)
+		}
+		fmt.Println(string(j))
+		return nil
+	}
+
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	unknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	tnc := yesNo(info.TNC)
+	if info.TNC {
+		tnc += ", data band " + info.DataBand
+	}
+	var rx, tx []string
+	for _, f := range c.RXRanges {
+		rx = append(rx, units.FormatMHz(f.Low)+"-"+units.FormatMHz(f.High))
+	}
+	for _, b := range c.TXBands {
+		tx = append(tx, fmt.Sprintf("%s %s-%s", b.Name, units.FormatMHz(b.Low), units.FormatMHz(b.High)))
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Model\t%s\n", info.Model)
+	fmt.Fprintf(w, "Family\t%s\n", unknown(c.Family))
+	fmt.Fprintf(w, "Serial\t%s\n", unknown(info.Serial))
+	fmt.Fprintf(w, "Firmware\t%s\n", unknown(info.Firmware))
+	fmt.Fprintf(w, "Memory channels\t%03d-%03d\n", 0, c.MemorySize()-1)
+	fmt.Fprintf(w, "Name length\t%d\n", c.MaxName())
+	fmt.Fprintf(w, "Power per channel\t%s\n", yesNo(c.ChannelPower))
+	fmt.Fprintf(w, "TNC\t%s\n", tnc)
+	fmt.Fprintf(w, "GPS pass-through\t%s\n", yesNo(info.GPS))
+	fmt.Fprintf(w, "Clock\t%s\n", yesNo(c.HasClock))
+	fmt.Fprintf(w, "Cross-band repeat\t%s\n", yesNo(c.CrossBandSetting != ""))
+	fmt.Fprintf(w, "Receive\t%s MHz\n", unknown(strings.Join(rx, ", ")))
+	fmt.Fprintf(w, "Transmit\t%s MHz\n", unknown(strings.Join(tx, ", ")))
+	return w.Flush()
+}
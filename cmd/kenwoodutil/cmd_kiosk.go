@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+// kioskRadio finds the radio on port, programs it and runs the result hook.
+// It returns the baud rate the radio answered at, 0 without a radio.
+func kioskRadio(c *kenwoodutil.Config, plan *kenwoodutil.Dump, port string, mode kenwoodutil.SerialMode) int {
+	var model string
+	var baud int
+	var err error
+	for _, b := range kenwoodutil.ProbeBaudRates {
+		if model, err = kenwoodutil.ProbePort(port, b, mode); err == nil {
+			baud = b
+			break
+		}
+	}
+	if err != nil {
+		log.Debug().Err(err).Str("port", port).Msg("no radio")
+		return 0
+	}
+	log.Info().Str("port", port).Int("baud", baud).Str("radio model", model).Msg("Radio plugged in, programming.")
+	env := []string{"KENWOOD_PORT=" + port, "KENWOOD_MODEL=" + model}
+
+	r, err := openRadioAt(port, baud)
+	if err == nil {
+		// KioskProgram backs up the whole memory in its own directory
+		r.BackupDir = ""
+		var res kenwoodutil.KioskResult
+		res, err = kenwoodutil.KioskProgram(r, plan, c.Kiosk.BackupDir)
+		r.Close()
+		if err == nil {
+			log.Info().Str("port", port).Int("written", res.Written).Str("checksum", res.Checksum).Msg("Radio programmed, unplug it.")
+			runHookPrograms("success", c.Kiosk.OnSuccess, env...)
+			return baud
+		}
+	}
+	log.Error().Err(err).Str("port", port).Msg("Programming failed, unplug the radio to try again.")
+	runHookPrograms("failure", c.Kiosk.OnFailure, append(env, "KENWOOD_ERROR="+err.Error())...)
+	return baud
+}
+
+func runKiosk(args []string) error {
+	fs := flag.NewFlagSet("kiosk", flag.ExitOnError)
+	planPath := fs.String("plan", "", "dump to write to every radio, instead of the configured one")
+	fs.Parse(args)
+
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if *planPath != "" {
+		c.Kiosk.Plan = *planPath
+	}
+	if c.Kiosk.Plan == "" {
+		return errors.New("error: no plan to program, set Kiosk.Plan in the config or use -plan")
+	}
+	plan, err := kenwoodutil.LoadDump(c.Kiosk.Plan)
+	if err != nil {
+		return err
+	}
+	if err := plan.ResolveDuplicates(kenwoodutil.DuplicatesError); err != nil {
+		return err
+	}
+	if c.Kiosk.BackupDir == "" {
+		c.Kiosk.BackupDir = "kiosk-backups"
+	}
+	interval := time.Duration(c.Kiosk.Interval)
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	mode, err := serialMode()
+	if err != nil {
+		return err
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	// done holds the baud rate of the radios already programmed, by port.
+	// They are left alone until they stop answering, cables often stay
+	// plugged in while radios come and go.
+	done := map[string]int{}
+	log.Info().Str("plan", c.Kiosk.Plan).Int("channels", len(plan.Memory)).Msg("Kiosk ready, plug in a radio.")
+	for {
+		ports, err := kenwoodutil.ListPorts()
+		if err != nil {
+			log.Error().Err(err).Msg("listing ports")
+		}
+		present := map[string]bool{}
+		for _, p := range ports {
+			present[p.Name] = true
+			if baud, ok := done[p.Name]; ok {
+				if _, err := kenwoodutil.ProbePort(p.Name, baud, mode); err == nil {
+					continue
+				}
+				log.Info().Str("port", p.Name).Msg("Radio unplugged.")
+				delete(done, p.Name)
+			}
+			if baud := kioskRadio(c, plan, p.Name, mode); baud != 0 {
+				done[p.Name] = baud
+			}
+		}
+		for name := range done {
+			if err == nil && !present[name] {
+				delete(done, name)
+			}
+		}
+		select {
+		case <-sig:
+			return nil
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// configuredLicence returns the licence at path, or the one in the config
+// when path is empty, nil when there is neither.
+func configuredLicence(c *kenwoodutil.Config, path string) (*kenwoodutil.Licence, error) {
+	if path == "" {
+		path = c.Licence
+	}
+	if path == "" {
+		return nil, nil
+	}
+	return kenwoodutil.LoadLicence(path)
+}
+
+func runLicence(args []string) error {
+	fs := flag.NewFlagSet("licence", flag.ExitOnError)
+	licencePath := fs.String("licence", "", "licence file, instead of the one in the config")
+	fs.Parse(args)
+
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	l, err := configuredLicence(c, *licencePath)
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		return fmt.Errorf("error: no licence, use -licence or set Licence in the config")
+	}
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+	model := expectedModel
+	if d.Radio != nil {
+		model = d.Radio.Model
+	}
+
+	unlicensed := l.Unlicensed(d.Memory, kenwoodutil.CapabilitiesFor(model))
+	if len(unlicensed) == 0 {
+		log.Info().Int("allocations", len(l.Allocations)).Msg("All channels transmit within the licence.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CH\tNAME\tRX\tTX")
+	for _, m := range unlicensed {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.Label(), m.Name, units.FormatMHz(m.RXFrequency), units.FormatMHz(m.TransmitFrequency()))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return fmt.Errorf("error: %d channels transmit outside the licence, writes refuse them", len(unlicensed))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	format := fs.String("format", "table", "output format, table, markdown or html")
+	fromRadio := fs.Bool("radio", false, "list the channels in the radio instead of a dump")
+	title := fs.String("title", "", "heading of the HTML page, defaults to the dump or radio name")
+	fs.Parse(args)
+
+	var channels []kenwoodutil.MemoryEntry
+	name := ""
+	if *fromRadio {
+		r, err := openRadio()
+		if err != nil {
+			return err
+		}
+		if err := r.ReadMemory(); err != nil {
+			return err
+		}
+		channels, name = r.OccupedChannels(), r.Model
+	} else {
+		path := defaultDumpPath
+		if fs.NArg() > 0 {
+			path = fs.Arg(0)
+		}
+		d, err := kenwoodutil.LoadDump(path)
+		if err != nil {
+			return err
+		}
+		channels, name = (&kenwoodutil.Radio{Memory: d.Memory}).OccupedChannels(), filepath.Base(path)
+	}
+	if *title == "" {
+		*title = name + " channels"
+	}
+
+	rows := kenwoodutil.ChannelList(channels)
+	switch *format {
+	case "table":
+		return kenwoodutil.WriteTable(os.Stdout, rows)
+	case "markdown", "md":
+		return kenwoodutil.WriteMarkdown(os.Stdout, rows)
+	case "html":
+		return kenwoodutil.WriteHTML(os.Stdout, *title, rows)
+	}
+	return fmt.Errorf("error: unknown list format %q, expected table, markdown or html", *format)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runLock(args []string) error {
+	fs := flag.NewFlagSet("lock", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		return errors.New("usage: lock [status|on|off]")
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	switch fs.Arg(0) {
+	case "", "status":
+		on, err := r.GetLock()
+		if err != nil {
+			return err
+		}
+		if on {
+			fmt.Println("keys locked")
+		} else {
+			fmt.Println("keys unlocked")
+		}
+	case "on", "off":
+		if err := r.SetLock(fs.Arg(0) == "on"); err != nil {
+			return err
+		}
+		log.Info().Str("lock", fs.Arg(0)).Msg("Key lock set.")
+	default:
+		return errors.New("usage: lock [status|on|off]")
+	}
+	return nil
+}
+
+func runDisplay(args []string) error {
+	fs := flag.NewFlagSet("display", flag.ExitOnError)
+	fs.Parse(args)
+	usage := fmt.Errorf("usage: display [status] | brightness 0-%d | auto on|off", kenwoodutil.MaxBrightness)
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	d, err := r.GetDisplay()
+	if err != nil {
+		return err
+	}
+	switch fs.Arg(0) {
+	case "", "status":
+		fmt.Printf("brightness %d\n", d.Brightness)
+		if d.AutoBrightness {
+			fmt.Println("auto brightness on")
+		} else {
+			fmt.Println("auto brightness off")
+		}
+		return nil
+	case "brightness":
+		if fs.NArg() != 2 {
+			return usage
+		}
+		if d.Brightness, err = strconv.Atoi(fs.Arg(1)); err != nil {
+			return usage
+		}
+	case "auto":
+		if fs.NArg() != 2 || fs.Arg(1) != "on" && fs.Arg(1) != "off" {
+			return usage
+		}
+		d.AutoBrightness = fs.Arg(1) == "on"
+	default:
+		return usage
+	}
+	if err := r.SetDisplay(d); err != nil {
+		return err
+	}
+	log.Info().Int("brightness", d.Brightness).Bool("auto", d.AutoBrightness).Msg("Display set.")
+	return nil
+}
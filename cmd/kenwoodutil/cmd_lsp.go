@@ -0,0 +1,14 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runLSP(args []string) error {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	fs.Parse(args)
+	return kenwoodutil.ServeLSP(os.Stdin, os.Stdout)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	out := fs.String("o", "", "file to write the upgraded dump to, defaults to the input file")
+	force := fs.Bool("force", false, "write the dump even if it does not validate")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("error: expected one dump file to migrate")
+	}
+	path := fs.Arg(0)
+	if *out == "" {
+		*out = path
+	}
+
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+	changed := d.InferMissing()
+	log.Info().Int("channels", len(d.Memory)).Int("changed", changed).Msg("Dump upgraded.")
+
+	if err := d.Validate(); err != nil {
+		if !*force {
+			return fmt.Errorf("error: upgraded dump does not validate, fix it or use -force: %w", err)
+		}
+		log.Warn().Msg(err.Error())
+	}
+	if err := d.Save(*out); err != nil {
+		return err
+	}
+	log.Info().Str("file", *out).Int("version", kenwoodutil.DumpVersion).Msg("Dump written.")
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runMonitor(args []string) error {
+	fs := flag.NewFlagSet("monitor", flag.ExitOnError)
+	bandName := fs.String("band", "", "band to monitor, A or B, defaults to the control band")
+	interval := fs.Duration("interval", 500*time.Millisecond, "polling interval")
+	asJSON := fs.Bool("json", false, "print a JSON line per sample instead of a live display")
+	changes := fs.Bool("changes", false, "only print when squelch opens or closes")
+	logPath := fs.String("log", "", "append each reception to this file")
+	logFormat := fs.String("log-format", "adif", "reception log format, adif or csv")
+	fs.Parse(args)
+	if *logFormat != "adif" && *logFormat != "csv" {
+		return fmt.Errorf("error: unknown log format %q, expected adif or csv", *logFormat)
+	}
+	logHit, closeLog, err := openReceptionLog(*logPath, *logFormat)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	band := -1
+	if *bandName != "" {
+		if band, err = units.ParseBand(*bandName); err != nil {
+			return err
+		}
+	} else if band, _, err = r.GetBand(); err != nil {
+		return err
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	enc := json.NewEncoder(os.Stdout)
+	var last *kenwoodutil.SignalSample
+	var hits kenwoodutil.HitTracker
+	for {
+		s, err := r.Sample(band)
+		if err != nil {
+			return err
+		}
+		if err := logHit(hits.Add(s)); err != nil {
+			return fmt.Errorf("error writing log: %w", err)
+		}
+		if !*changes || last == nil || last.Busy != s.Busy {
+			switch {
+			case *asJSON:
+				enc.Encode(s)
+			case *changes:
+				fmt.Println(s)
+			default:
+				fmt.Printf("\r%s\033[K", s)
+			}
+		}
+		last = &s
+
+		select {
+		case <-sig:
+			if !*asJSON && !*changes {
+				fmt.Println()
+			}
+			if err := logHit(hits.Close(time.Now())); err != nil {
+				return fmt.Errorf("error writing log: %w", err)
+			}
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// openReceptionLog opens the log of hits at path for appending, in adif or
+// csv format. Without a path hits are dropped.
+func openReceptionLog(path, format string) (logHit func(h *kenwoodutil.Hit) error, close func() error, err error) {
+	if path == "" {
+		return func(h *kenwoodutil.Hit) error { return nil }, func() error { return nil }, nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening log file: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("error opening log file: %w", err)
+	}
+	cw := csv.NewWriter(f)
+	if fi.Size() == 0 {
+		if format == "adif" {
+			io.WriteString(f, kenwoodutil.ADIFHeader)
+		} else {
+			cw.Write(kenwoodutil.HitHeader)
+		}
+	}
+	logHit = func(h *kenwoodutil.Hit) error {
+		if h == nil {
+			return nil
+		}
+		if format == "adif" {
+			_, err := io.WriteString(f, h.ADIF())
+			return err
+		}
+		cw.Write(h.CSV())
+		cw.Flush()
+		return cw.Error()
+	}
+	return logHit, f.Close, nil
+}
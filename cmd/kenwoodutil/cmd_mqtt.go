@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runMQTT(args []string) error {
+	fs := flag.NewFlagSet("mqtt", flag.ExitOnError)
+	broker := fs.String("broker", "", "host:port of the MQTT broker, instead of the configured one")
+	topic := fs.String("topic", "", "topic prefix, instead of the configured one")
+	discovery := fs.String("discovery", "", "Home Assistant discovery prefix, instead of the configured one")
+	fs.Parse(args)
+
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if *broker != "" {
+		c.MQTT.Broker = *broker
+	}
+	if *topic != "" {
+		c.MQTT.Topic = *topic
+	}
+	if *discovery != "" {
+		c.MQTT.Discovery = *discovery
+	}
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	b := kenwoodutil.NewMQTTBridge(r, c.MQTT)
+
+	stop := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		close(stop)
+	}()
+	defer func() {
+		if r.Transmitting() {
+			r.SetPTT(false)
+		}
+	}()
+
+	for {
+		err := b.Connect()
+		if err == nil {
+			log.Info().Str("broker", b.Config.Broker).Str("topic", b.Config.Topic).Msg("Bridging radio to MQTT.")
+			err = b.Run(stop)
+		}
+		select {
+		case <-stop:
+			log.Info().Msg("MQTT bridge stopped.")
+			return nil
+		default:
+		}
+		log.Warn().Err(err).Msg("Lost the MQTT broker, reconnecting in 10s.")
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
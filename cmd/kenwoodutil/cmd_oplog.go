@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/skrzyp/kenwoodutil"
+)
+
+// logTimeLayouts are the forms -from and -to take, without a zone meaning
+// local time.
+var logTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+func parseLogTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range logTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("error: can not parse time %q, expected like 2024-06-01 or \"2024-06-01 14:30\"", s)
+}
+
+func runOpLog(args []string) error {
+	fs := flag.NewFlagSet("oplog", flag.ExitOnError)
+	fromFlag := fs.String("from", "", "start of the range, like 2024-06-01 or \"2024-06-01 14:30\"")
+	toFlag := fs.String("to", "", "end of the range, defaults to the end of the log")
+	format := fs.String("format", "csv", "output format, csv or jsonl")
+	fs.Parse(args)
+	if *format != "csv" && *format != "jsonl" {
+		return fmt.Errorf("error: unknown format %q, expected csv or jsonl", *format)
+	}
+	from, err := parseLogTime(*fromFlag)
+	if err != nil {
+		return err
+	}
+	to, err := parseLogTime(*toFlag)
+	if err != nil {
+		return err
+	}
+
+	path := fs.Arg(0)
+	if path == "" {
+		c, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if path = c.Daemon.OperatingLog; path == "" {
+			return errors.New("error: no operating log given and none set in the config")
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening operating log: %w", err)
+	}
+	defer f.Close()
+	records, err := kenwoodutil.ReadTuneLog(f)
+	if err != nil {
+		return err
+	}
+
+	records = kenwoodutil.TuneRecordsBetween(records, from, to)
+	if *format == "jsonl" {
+		enc := json.NewEncoder(os.Stdout)
+		for _, rec := range records {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	w := csv.NewWriter(os.Stdout)
+	w.Write(kenwoodutil.TuneRecordHeader)
+	for _, rec := range records {
+		w.Write(rec.CSV())
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runPin(args []string) error {
+	fs := flag.NewFlagSet("pin", flag.ExitOnError)
+	set := fs.String("set", "", "channels to pin, like 0-9,500")
+	clear := fs.String("clear", "", "channels to unpin")
+	fs.Parse(args)
+
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+
+	if *set == "" && *clear == "" {
+		var pinned []int
+		for n := range d.Pins() {
+			pinned = append(pinned, int(n))
+		}
+		if len(pinned) == 0 {
+			fmt.Println("no channels are pinned")
+			return nil
+		}
+		sort.Ints(pinned)
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "CH\tFREQ\tNAME")
+		for _, n := range pinned {
+			m, _ := d.Channel(uint16(n))
+			fmt.Fprintf(w, "%03d\t%s\t%s\n", n, units.FormatMHz(m.RXFrequency), m.Name)
+		}
+		return w.Flush()
+	}
+
+	setList, err := units.ParseChannelList(*set)
+	if err != nil {
+		return err
+	}
+	clearList, err := units.ParseChannelList(*clear)
+	if err != nil {
+		return err
+	}
+	for _, n := range setList {
+		if err := d.SetPinned(uint16(n), true); err != nil {
+			return err
+		}
+	}
+	for _, n := range clearList {
+		if err := d.SetPinned(uint16(n), false); err != nil {
+			return err
+		}
+	}
+	if err := d.Save(path); err != nil {
+		return err
+	}
+	log.Info().Int("pinned", len(d.Pins())).Msg("Pins saved.")
+	return nil
+}
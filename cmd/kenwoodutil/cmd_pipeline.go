@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runPipeline(args []string) error {
+	if len(args) == 0 || args[0] != "run" {
+		return errors.New("usage: pipeline run [-dry-run] file")
+	}
+	fs := flag.NewFlagSet("pipeline run", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print the channels the pipeline would put into its sinks")
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		return errors.New("usage: pipeline run [-dry-run] file")
+	}
+
+	spec, err := kenwoodutil.LoadPipelineSpec(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	for i := range spec.Sources {
+		if spec.Sources[i].Type == "repeaterbook" && spec.Sources[i].Model == "" {
+			spec.Sources[i].Model = expectedModel
+		}
+	}
+	p, err := spec.Pipeline(openRadio)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		channels, err := p.Collect()
+		if err != nil {
+			return err
+		}
+		return kenwoodutil.WriteTable(os.Stdout, kenwoodutil.ChannelList(channels))
+	}
+	channels, err := p.Run()
+	if err != nil {
+		return err
+	}
+	log.Info().Int("channels", len(channels)).Int("sinks", len(p.Sinks)).Msg("Pipeline done.")
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runPM(args []string) error {
+	fs := flag.NewFlagSet("pm", flag.ExitOnError)
+	fs.Parse(args)
+
+	path := defaultDumpPath
+	if fs.NArg() > 1 {
+		path = fs.Arg(1)
+	}
+
+	switch fs.Arg(0) {
+	case "backup":
+		d, err := loadDumpOrNew(path)
+		if err != nil {
+			return err
+		}
+		r, err := openRadio()
+		if err != nil {
+			return err
+		}
+		if d.Profiles, err = r.ReadPMProfiles(); err != nil {
+			return err
+		}
+		if err := d.Save(path); err != nil {
+			return err
+		}
+		log.Info().Int("profiles", len(d.Profiles)).Msg("Programmable memories saved.")
+	case "restore":
+		d, err := kenwoodutil.LoadDump(path)
+		if err != nil {
+			return err
+		}
+		if len(d.Profiles) == 0 {
+			return fmt.Errorf("%s has no programmable memories", path)
+		}
+		r, err := openRadio()
+		if err != nil {
+			return err
+		}
+		if err := r.WritePMProfiles(d.Profiles); err != nil {
+			return err
+		}
+		log.Info().Int("profiles", len(d.Profiles)).Msg("Programmable memories restored.")
+	default:
+		return fmt.Errorf("usage: pm backup|restore [file]")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runPorts(args []string) error {
+	fs := flag.NewFlagSet("ports", flag.ExitOnError)
+	fs.Parse(args)
+
+	ports, err := kenwoodutil.ListPorts()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "PORT\tUSB ID\tPRODUCT\tSERIAL")
+	for _, p := range ports {
+		id := "-"
+		if p.IsUSB {
+			id = p.VID + ":" + p.PID
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, id, p.Product, p.SerialNumber)
+	}
+	return w.Flush()
+}
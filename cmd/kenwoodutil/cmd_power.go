@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runPower(args []string) error {
+	fs := flag.NewFlagSet("power", flag.ExitOnError)
+	bandName := fs.String("band", "", "band to set the power level of, A or B, defaults to the control band")
+	fs.Parse(args)
+	usage := errors.New("usage: power [-band A|B] status|on|off|set high|mid|low")
+	if fs.NArg() < 1 {
+		return usage
+	}
+
+	if fs.Arg(0) == "on" {
+		// a radio that is off does not identify itself
+		mode, err := serialMode()
+		if err != nil {
+			return err
+		}
+		r, err := kenwoodutil.NewRadio(*portPath, *baudRate, mode)
+		if err != nil {
+			return err
+		}
+		if err := attachHooks(r); err != nil {
+			return err
+		}
+		err = r.SetPowerOn(true)
+		if errors.Is(err, kenwoodutil.ErrTimeout) {
+			log.Warn().Msg("radio did not answer, it may still be starting up")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		log.Info().Msg("Radio switched on.")
+		return nil
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	switch fs.Arg(0) {
+	case "status":
+		on, err := r.PowerOn()
+		if errors.Is(err, kenwoodutil.ErrRadioNAK) {
+			log.Warn().Msg("radio does not report its power state")
+		} else if err != nil {
+			return err
+		} else if on {
+			fmt.Println("power on")
+		} else {
+			fmt.Println("power off")
+		}
+		for band := 0; band <= 1; band++ {
+			p, err := r.GetPower(band)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("band %s %s\n", units.BandName(band), p)
+		}
+	case "off":
+		if err := r.SetPowerOn(false); err != nil {
+			return err
+		}
+		log.Info().Msg("Radio switched off.")
+	case "set":
+		if fs.NArg() != 2 {
+			return usage
+		}
+		p, err := kenwoodutil.ParsePowerLevel(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		band := -1
+		if *bandName != "" {
+			band, err = units.ParseBand(*bandName)
+		} else {
+			band, _, err = r.GetBand()
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.SetPower(band, p); err != nil {
+			return err
+		}
+		log.Info().Str("band", units.BandName(band)).Str("power", p.String()).Msg("Power level set.")
+	default:
+		return usage
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func runPTT(args []string) error {
+	fs := flag.NewFlagSet("ptt", flag.ExitOnError)
+	maxTX := fs.Duration("max", 30*time.Second, "return to receive after this long")
+	iKnow := fs.Bool("i-know-what-im-doing", false, "required to key the transmitter")
+	fs.Parse(args)
+
+	switch fs.Arg(0) {
+	case "on":
+		if !*iKnow {
+			return errors.New("refusing to transmit without -i-know-what-im-doing")
+		}
+	case "off":
+	default:
+		return fmt.Errorf("usage: ptt on|off")
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	if fs.Arg(0) == "off" {
+		return r.SetPTT(false)
+	}
+
+	r.MaxTX = *maxTX
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	if err := r.SetPTT(true); err != nil {
+		return err
+	}
+	select {
+	case <-sig:
+	case <-time.After(*maxTX):
+	}
+	return r.SetPTT(false)
+}
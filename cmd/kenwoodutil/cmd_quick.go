@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func runQuick(args []string) error {
+	fs := flag.NewFlagSet("quick", flag.ExitOnError)
+	tone := fs.Float64("tone", 0, "repeater access tone in Hz")
+	channel := fs.Int("channel", 999, "scratch memory channel to overwrite")
+	name := fs.String("name", "QUICK", "channel name")
+	bandName := fs.String("band", "", "band to tune (A or B), defaults to the control band")
+
+	// frequency and offset come first, and the offset usually looks like a flag
+	var pos []string
+	for len(args) > 0 && len(pos) < 2 && isNumber(args[0]) {
+		pos, args = append(pos, args[0]), args[1:]
+	}
+	fs.Parse(args)
+	pos = append(pos, fs.Args()...)
+	if len(pos) < 1 || len(pos) > 2 {
+		return fmt.Errorf("usage: quick <MHz> [offset MHz] [-tone Hz]")
+	}
+
+	ch := kenwoodutil.MemoryEntry{Number: uint16(*channel), Name: *name}
+	var err error
+	if ch.RXFrequency, err = units.ParseMHz(pos[0]); err != nil {
+		return err
+	}
+	ch.RXStepSize = units.CanonicalStep(ch.RXFrequency, 0)
+	if len(pos) == 2 {
+		offset, err := strconv.ParseFloat(pos[1], 64)
+		if err != nil {
+			return fmt.Errorf("error parsing offset %q", pos[1])
+		}
+		switch {
+		case offset > 0:
+			ch.ShiftDirection = 1
+		case offset < 0:
+			ch.ShiftDirection, offset = 2, -offset
+		}
+		if ch.OffsetFrequency, err = units.ParseMHz(strconv.FormatFloat(offset, 'f', -1, 64)); err != nil {
+			return err
+		}
+	}
+	if *tone != 0 {
+		idx, err := units.ToneIndex(*tone)
+		if err != nil {
+			return err
+		}
+		ch.ToneEnabled, ch.ToneFrequency = 1, uint16(idx)
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	if err := r.CheckChannel(*channel); err != nil {
+		return err
+	}
+	if err := ch.ValidateFor(r.Capabilities()); err != nil {
+		return err
+	}
+	kenwoodutil.WarnNameChanges(r.Capabilities().NameChanges([]kenwoodutil.MemoryEntry{ch}))
+	if err := r.SetChannel(ch); err != nil {
+		return err
+	}
+	if _, err := r.WriteChannel(*channel); err != nil {
+		return err
+	}
+
+	var band int
+	if *bandName == "" {
+		band, _, err = r.GetBand()
+	} else {
+		band, err = units.ParseBand(*bandName)
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.SelectMemoryChannel(band, *channel); err != nil {
+		return err
+	}
+	log.Info().Int("channel", *channel).Str("band", units.BandName(band)).Msgf("Tuned to %s MHz", units.FormatMHz(ch.RXFrequency))
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runRaw(args []string) error {
+	fs := flag.NewFlagSet("raw", flag.ExitOnError)
+	fs.Parse(args)
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		reply, err := r.Raw(strings.Join(fs.Args(), " "))
+		if err != nil {
+			return err
+		}
+		fmt.Println(reply)
+		return nil
+	}
+
+	// on a terminal lines are edited, recalled with up and down and the
+	// mnemonic completed with Tab
+	if t, err := openTerminal(); err == nil {
+		defer t.Close()
+		line := newLineEditor(t)
+		line.out = os.Stderr
+		line.complete = func(s string) []string {
+			if strings.Contains(s, " ") {
+				return nil
+			}
+			var v []string
+			for _, c := range kenwoodutil.ProtocolCommands(r.Model, s) {
+				v = append(v, c.Mnemonic)
+			}
+			return v
+		}
+		for {
+			command, err := line.readLine("> ", "")
+			if errors.Is(err, errInterrupted) {
+				continue
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if rawCommand(r, command) {
+				return nil
+			}
+		}
+	}
+
+	in := bufio.NewScanner(os.Stdin)
+	fmt.Fprint(os.Stderr, "> ")
+	for in.Scan() {
+		if rawCommand(r, in.Text()) {
+			return nil
+		}
+		fmt.Fprint(os.Stderr, "> ")
+	}
+	return in.Err()
+}
+
+// rawCommand runs a line of the interactive session, quit is set when it
+// ends the session.
+func rawCommand(r *kenwoodutil.Radio, line string) (quit bool) {
+	command := strings.TrimSpace(line)
+	switch command {
+	case "":
+	case "quit", "exit":
+		return true
+	case "help", "?":
+		completeCommand(r.Model, "")
+	default:
+		if strings.HasSuffix(command, "?") {
+			completeCommand(r.Model, strings.TrimSuffix(command, "?"))
+			break
+		}
+		if c, ok := kenwoodutil.LookupCommand(strings.ToUpper(kenwoodutil.CommandMnemonic(command))); !ok || !c.Supports(r.Model) {
+			fmt.Fprintf(os.Stderr, "%s is not in the command catalog of %s, sending anyway\n", kenwoodutil.CommandMnemonic(command), r.Model)
+		}
+		reply, err := r.Raw(command)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		} else {
+			fmt.Println(reply)
+		}
+	}
+	return false
+}
+
+// completeCommand lists the catalog commands of model starting with prefix,
+// for "M?" in an interactive session.
+func completeCommand(model, prefix string) {
+	catalog := kenwoodutil.ProtocolCommands(model, prefix)
+	if len(catalog) == 0 {
+		fmt.Fprintf(os.Stderr, "no %s command starts with %q\n", model, prefix)
+		return
+	}
+	for _, c := range catalog {
+		printProtocolCommand(os.Stderr, c)
+	}
+}
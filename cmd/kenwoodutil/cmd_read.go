@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runRead(args []string) error {
+	fs := flag.NewFlagSet("read", flag.ExitOnError)
+	out := fs.String("o", defaultDumpPath, "file to dump memory to")
+	withSettings := fs.Bool("settings", true, "also read menu settings")
+	withSpecial := fs.Bool("special", true, "also read call channels and program scan edges")
+	withDTMF := fs.Bool("dtmf", true, "also read DTMF memories")
+	withAPRS := fs.Bool("aprs", true, "also read APRS config of radios with a TNC")
+	fast := fs.Int("fast", 0, "switch the PC port to this baud rate (up to 57600) for the transfer")
+	resume := fs.Bool("resume", false, "go on with an interrupted read from where it stopped")
+	format := fs.String("format", "json", "dump format, json, or jsonl for memory channels only, one per line as soon as it is read")
+	fs.Parse(args)
+	if *format != "json" && *format != "jsonl" {
+		return fmt.Errorf("error: unknown format %q, expected json or jsonl", *format)
+	}
+	// a dump to standard output has no previous version nor checkpoint
+	stdout := *out == kenwoodutil.StdioPath
+	if stdout && *resume {
+		return fmt.Errorf("error: -resume needs a dump file, not %s", kenwoodutil.StdioPath)
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	restore, err := fastTransfer(r, *fast)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	var cp *kenwoodutil.Checkpoint
+	first := 0
+	if *resume {
+		if cp, err = kenwoodutil.LoadCheckpoint("read", r, *out); err != nil {
+			return err
+		}
+		first = cp.ResumeRead(r)
+		log.Info().Int("channel", first).Msg("Resuming read...")
+	} else if !stdout {
+		if cp, err = kenwoodutil.NewCheckpoint("read", r, *out); err != nil {
+			return err
+		}
+	}
+	done := func(ch int) {
+		if cp != nil {
+			cp.Done(ch, r)
+		}
+	}
+	var stream *channelStream
+	if *format == "jsonl" {
+		if stream, err = newChannelStream(*out, *resume); err != nil {
+			return err
+		}
+		defer stream.Close()
+		checkpoint := done
+		done = func(ch int) {
+			checkpoint(ch)
+			stream.Put(r.Memory[ch])
+		}
+	}
+	log.Info().Msg("Reading memory...")
+	if err := r.ReadMemoryFrom(first, done); err != nil {
+		if cp == nil {
+			return fmt.Errorf("error reading memory: %w", err)
+		}
+		if serr := cp.Save(); serr != nil {
+			log.Warn().Err(serr).Msg("checkpoint not saved, the read can not be resumed")
+		} else {
+			log.Warn().Int("channel", cp.Last).Msg("Read interrupted, run read -resume to go on.")
+		}
+		return fmt.Errorf("error reading memory: %w", err)
+	}
+	log.Info().Msg("Reading done.")
+	if stream != nil {
+		if stream.err != nil {
+			return fmt.Errorf("error writing stream: %w", stream.err)
+		}
+		if cp != nil {
+			if err := cp.Remove(); err != nil {
+				log.Warn().Err(err).Msg("stale checkpoint left behind")
+			}
+		}
+		return nil
+	}
+
+	// channels gone from the radio since the last read go to the trash of
+	// the new dump rather than vanish
+	d := &kenwoodutil.Dump{Radio: r.Info()}
+	if !stdout {
+		if old, err := kenwoodutil.LoadDump(*out); err == nil {
+			d.Memory, d.Trash = old.Memory, old.Trash
+		} else if !errors.Is(err, os.ErrNotExist) {
+			log.Warn().Err(err).Msg("previous dump not readable, its channels are not kept in the trash")
+		}
+	}
+	if n := d.ReplaceMemory(r.OccupedChannels(), "removed on radio "+r.Model); n > 0 {
+		log.Warn().Int("channels", n).Msg("Channels no longer on the radio were moved to the trash.")
+	}
+	for _, m := range d.Memory {
+		d.RecordImport(m.Number, "radio "+r.Model)
+	}
+	if *withSpecial {
+		log.Info().Msg("Reading special channels...")
+		d.Special, err = r.ReadSpecialChannels()
+		if err != nil {
+			return err
+		}
+	}
+	if *withDTMF {
+		log.Info().Msg("Reading DTMF memories...")
+		d.DTMF, err = r.ReadDTMFMemories()
+		if err != nil {
+			return err
+		}
+	}
+	if *withAPRS {
+		log.Info().Msg("Reading APRS config...")
+		d.APRS, err = r.ReadAPRSConfig()
+		if err != nil {
+			return err
+		}
+	}
+	if *withSettings {
+		log.Info().Msg("Reading settings...")
+		d.Settings, err = r.ReadSettings()
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Info().Msg("Dumping memory to file...")
+	if err := d.Save(*out); err != nil {
+		return err
+	}
+	if cp != nil {
+		if err := cp.Remove(); err != nil {
+			log.Warn().Err(err).Msg("stale checkpoint left behind")
+		}
+	}
+	log.Info().Msg("Dumping memory to file done")
+	return nil
+}
+
+// channelStream writes channels to a JSON Lines file as they are read, one
+// MemoryEntry per line.
+type channelStream struct {
+	f    *os.File
+	enc  *json.Encoder
+	last int
+	err  error
+}
+
+// newChannelStream creates path, or standard output for "-". When resuming
+// it appends instead, skipping the channels the file has already.
+func newChannelStream(path string, resume bool) (*channelStream, error) {
+	s := &channelStream{f: os.Stdout, last: -1}
+	if path != kenwoodutil.StdioPath {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if resume {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			old, err := os.ReadFile(path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("error reading stream: %w", err)
+			}
+			for _, line := range bytes.Split(old, []byte("\n")) {
+				var m kenwoodutil.MemoryEntry
+				if json.Unmarshal(line, &m) == nil && int(m.Number) > s.last {
+					s.last = int(m.Number)
+				}
+			}
+		}
+		f, err := os.OpenFile(path, flags, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("error creating stream: %w", err)
+		}
+		s.f = f
+	}
+	s.enc = json.NewEncoder(s.f)
+	return s, nil
+}
+
+// Put writes m unless it is empty or already written. The first error is
+// logged, the read goes on.
+func (s *channelStream) Put(m kenwoodutil.MemoryEntry) {
+	if m.RXFrequency == 0 || int(m.Number) <= s.last || s.err != nil {
+		return
+	}
+	if s.err = s.enc.Encode(m); s.err != nil {
+		log.Error().Err(s.err).Msg("error writing stream, channels are no longer written")
+		return
+	}
+	s.last = int(m.Number)
+}
+
+func (s *channelStream) Close() error {
+	if s.f == os.Stdout {
+		return nil
+	}
+	return s.f.Close()
+}
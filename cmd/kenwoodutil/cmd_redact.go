@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runRedact(args []string) error {
+	fs := flag.NewFlagSet("redact", flag.ExitOnError)
+	out := fs.String("o", "", "file to write the redacted dump to, defaults to file.redacted.json")
+	jitterFreq := fs.Bool("jitter", false, "also move every frequency by a few channel steps")
+	seed := fs.Int64("seed", 0, "random seed for -jitter, defaults to the current time")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("error: expected one dump file to redact")
+	}
+	path := fs.Arg(0)
+	if *out == "" {
+		*out = strings.TrimSuffix(path, ".json") + ".redacted.json"
+	}
+	if *out == path {
+		return fmt.Errorf("error: refusing to overwrite the original dump")
+	}
+
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+	var rng *rand.Rand
+	if *jitterFreq {
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		rng = rand.New(rand.NewSource(*seed))
+	}
+	d.Redact(rng)
+	if err := d.Save(*out); err != nil {
+		return err
+	}
+	log.Info().Str("file", *out).Msg("Redacted dump written, check it before sharing.")
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+// confirm asks question on the terminal and reports whether it was
+// answered yes.
+func confirm(question string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return false, fmt.Errorf("error reading answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+func runRefresh(args []string) error {
+	fs := flag.NewFlagSet("refresh", flag.ExitOnError)
+	country := fs.String("country", "", "country to fetch from RepeaterBook, like Poland")
+	fromRadio := fs.Bool("radio", false, "check the channels in the radio instead of a dump")
+	yes := fs.Bool("yes", false, "apply the corrections without asking")
+	dryRun := fs.Bool("dry-run", false, "only show the corrections")
+	force := fs.Bool("force", false, "correct pinned channels of the dump too")
+	fs.Parse(args)
+	if *country == "" {
+		return errors.New("usage: refresh -country name [-radio] [-yes] [-dry-run] [-force] [file]")
+	}
+
+	var r *kenwoodutil.Radio
+	var d *kenwoodutil.Dump
+	var err error
+	var memory []kenwoodutil.MemoryEntry
+	pins := map[uint16]bool{}
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	if *fromRadio {
+		if r, err = openRadio(); err != nil {
+			return err
+		}
+		log.Info().Msg("Reading memory...")
+		if err := r.ReadMemory(); err != nil {
+			return err
+		}
+		memory = r.OccupedChannels()
+	} else {
+		if d, err = kenwoodutil.LoadDump(path); err != nil {
+			return err
+		}
+		memory = d.Memory
+		if !*force {
+			pins = d.Pins()
+		}
+	}
+
+	log.Info().Str("country", *country).Msg("Fetching repeaters from RepeaterBook...")
+	repeaters, err := kenwoodutil.FetchRepeaterBook(*country)
+	if err != nil {
+		return err
+	}
+	var corrections []kenwoodutil.RepeaterCorrection
+	for _, c := range kenwoodutil.RepeaterCorrections(memory, repeaters) {
+		if pins[c.Channel.Number] {
+			log.Warn().Str("channel", c.Channel.Label()).Msg("pinned channel differs from RepeaterBook, use -force to correct it")
+			continue
+		}
+		corrections = append(corrections, c)
+	}
+	if len(corrections) == 0 {
+		log.Info().Int("repeaters", len(repeaters)).Msg("All repeater channels match RepeaterBook.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CH\tNAME\tREPEATER\tTONE\tOFFSET")
+	change := func(old, nu string) string {
+		if old == nu {
+			return old
+		}
+		return fmt.Sprintf("%s -> %s", old, nu)
+	}
+	for _, c := range corrections {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Channel.Label(), c.Channel.Name, c.Repeater.Callsign,
+			change(kenwoodutil.ToneLabel(c.Channel), kenwoodutil.ToneLabel(c.Fixed)), change(kenwoodutil.OffsetLabel(c.Channel), kenwoodutil.OffsetLabel(c.Fixed)))
+	}
+	w.Flush()
+	if *dryRun {
+		log.Info().Int("channels", len(corrections)).Msg("Dry run, nothing was corrected.")
+		return nil
+	}
+	if !*yes {
+		ok, err := confirm(fmt.Sprintf("Correct %d channels?", len(corrections)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			log.Info().Msg("Nothing was corrected.")
+			return nil
+		}
+	}
+
+	if r != nil {
+		for _, c := range corrections {
+			if err := r.SetChannel(c.Fixed); err != nil {
+				return err
+			}
+			if _, err := r.WriteChannel(int(c.Fixed.Number)); err != nil {
+				return err
+			}
+		}
+	} else {
+		fixed := map[uint16]kenwoodutil.MemoryEntry{}
+		for _, c := range corrections {
+			fixed[c.Fixed.Number] = c.Fixed
+		}
+		for i, m := range d.Memory {
+			if f, ok := fixed[m.Number]; ok {
+				d.Memory[i] = f
+				d.RecordImport(m.Number, "repeaterbook refresh")
+			}
+		}
+		if err := d.Save(path); err != nil {
+			return err
+		}
+	}
+	log.Info().Int("channels", len(corrections)).Msg("Refresh done.")
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runRename(args []string) error {
+	fs := flag.NewFlagSet("rename", flag.ExitOnError)
+	match := fs.String("match", "", "regular expression the whole channel name has to match, like 'SR9(.*)'")
+	replace := fs.String("replace", "", "new name, \\1 or ${1} standing for groups of -match")
+	fromRadio := fs.Bool("radio", false, "rename channels in the radio instead of a dump")
+	dryRun := fs.Bool("dry-run", false, "only show the new names")
+	force := fs.Bool("force", false, "rename pinned channels of the dump too")
+	fs.Parse(args)
+	if *match == "" {
+		return errors.New("usage: rename -match regexp -replace name [-radio] [-dry-run] [-force] [file]")
+	}
+	re, err := regexp.Compile(*match)
+	if err != nil {
+		return fmt.Errorf("error parsing -match: %w", err)
+	}
+	repl := kenwoodutil.ExpandReplacement(*replace)
+
+	var r *kenwoodutil.Radio
+	var d *kenwoodutil.Dump
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	var e *kenwoodutil.MemoryEditor
+	if *fromRadio {
+		if r, err = openRadio(); err != nil {
+			return err
+		}
+		log.Info().Msg("Reading memory...")
+		if err := r.ReadMemory(); err != nil {
+			return err
+		}
+		e = kenwoodutil.NewMemoryEditor(r.OccupedChannels(), r.Capabilities())
+	} else {
+		if d, err = kenwoodutil.LoadDump(path); err != nil {
+			return err
+		}
+		e = kenwoodutil.NewMemoryEditor(d.Memory, kenwoodutil.Capabilities{})
+		if !*force {
+			e.Pinned = d.Pins()
+		}
+	}
+
+	renames := kenwoodutil.RenameChannels(e.Memory(), re, repl)
+	if len(renames) == 0 {
+		log.Info().Msg("No channel names match.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CH\tOLD\tNEW")
+	for _, rn := range renames {
+		fmt.Fprintf(w, "%03d\t%s\t%s\n", rn.Number, rn.Old, rn.New)
+		// check every name before writing any
+		if err := e.Set(rn.Number, "name", rn.New); err != nil {
+			w.Flush()
+			if errors.Is(err, kenwoodutil.ErrPinned) {
+				return fmt.Errorf("%w, use -force to rename it", err)
+			}
+			return fmt.Errorf("error renaming channel %03d: %w", rn.Number, err)
+		}
+	}
+	w.Flush()
+	if *dryRun {
+		log.Info().Int("channels", len(renames)).Msg("Dry run, nothing was renamed.")
+		return nil
+	}
+
+	if r != nil {
+		if err := e.Commit(r); err != nil {
+			return err
+		}
+	} else {
+		d.Memory = e.Memory()
+		if err := d.Save(path); err != nil {
+			return err
+		}
+	}
+	log.Info().Int("channels", len(renames)).Msg("Rename done.")
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runReorganize(args []string) error {
+	fs := flag.NewFlagSet("reorganize", flag.ExitOnError)
+	compact := fs.Bool("compact", false, "move occupied channels to the start of memory")
+	sortBy := fs.String("sort", "", "sort channels by freq, name or number")
+	mapFile := fs.String("map", "", "JSON file mapping old channel numbers to new ones, like {\"12\": 5}")
+	start := fs.Int("start", 0, "first channel number for -compact and -sort")
+	dryRun := fs.Bool("dry-run", false, "show the new layout without writing it")
+	out := fs.String("o", "", "also save the new layout to this dump file")
+	fs.Parse(args)
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Reading memory...")
+	if err := r.ReadMemory(); err != nil {
+		return fmt.Errorf("error reading memory: %w", err)
+	}
+
+	var mapping map[uint16]uint16
+	switch {
+	case *mapFile != "":
+		j, err := os.ReadFile(*mapFile)
+		if err != nil {
+			return fmt.Errorf("error reading mapping file: %w", err)
+		}
+		if err := json.Unmarshal(j, &mapping); err != nil {
+			return fmt.Errorf("error parsing mapping file: %w", err)
+		}
+	case *sortBy != "":
+		mapping, err = kenwoodutil.SortMapping(r.Memory, *sortBy, *start)
+		if err != nil {
+			return err
+		}
+	case *compact:
+		mapping = kenwoodutil.CompactMapping(r.Memory, *start)
+	default:
+		return errors.New("one of -compact, -sort or -map is required")
+	}
+	layout, err := kenwoodutil.Renumber(r.Memory, mapping)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "OLD\tNEW\tFREQ\tNAME")
+	for _, m := range r.OccupedChannels() {
+		if n, ok := mapping[m.Number]; ok && n != m.Number {
+			fmt.Fprintf(w, "%03d\t%03d\t%s\t%s\n", m.Number, n, units.FormatMHz(m.RXFrequency), m.Name)
+		}
+	}
+	w.Flush()
+
+	if *out != "" {
+		if err := (&kenwoodutil.Dump{Memory: layout}).Save(*out); err != nil {
+			return err
+		}
+	}
+	if *dryRun {
+		return nil
+	}
+
+	log.Info().Msg("Writing new layout...")
+	summary, err := r.ApplyLayout(layout)
+	if err != nil {
+		return err
+	}
+	log.Info().Int("channels", summary.Written).Msg("Writing new layout done.")
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	out := fs.String("o", "kenwoodutil-report-"+time.Now().Format("20060102-150405")+".zip", "archive to write")
+	fs.Parse(args)
+
+	files := map[string]string{}
+
+	var info strings.Builder
+	fmt.Fprintf(&info, "kenwoodutil %s\n", kenwoodutil.ToolVersion)
+	fmt.Fprintf(&info, "go %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&info, "args %q\n", os.Args[1:])
+	fmt.Fprintf(&info, "port %s baud %d\n", *portPath, *baudRate)
+	files["info.txt"] = info.String()
+
+	var probe strings.Builder
+	if ports, err := kenwoodutil.ListPorts(); err != nil {
+		fmt.Fprintf(&probe, "listing ports: %v\n", err)
+	} else {
+		for _, p := range ports {
+			fmt.Fprintf(&probe, "%s usb=%v vid=%s pid=%s product=%q\n", p.Name, p.IsUSB, p.VID, p.PID, p.Product)
+		}
+	}
+	mode, err := serialMode()
+	if err != nil {
+		return err
+	}
+	if model, err := kenwoodutil.ProbePort(*portPath, *baudRate, mode); err != nil {
+		fmt.Fprintf(&probe, "probing %s: %v\n", *portPath, err)
+	} else {
+		fmt.Fprintf(&probe, "probing %s: %s\n", *portPath, model)
+	}
+	files["probe.txt"] = probe.String()
+
+	if fs.NArg() > 0 {
+		files["transcript.log"], files["result.txt"] = reportTranscript(fs.Arg(0), fs.Args()[1:])
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"info.txt", "probe.txt", "transcript.log", "result.txt"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			return fmt.Errorf("error writing report: %w", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			return fmt.Errorf("error writing report: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("error writing report: %w", err)
+	}
+	if err := kenwoodutil.WriteFileAtomic(*out, buf.Bytes(), 0644, false); err != nil {
+		return fmt.Errorf("error writing report: %w", err)
+	}
+	log.Info().Str("file", *out).Msg("Report written, have a look at it before attaching it to an issue.")
+	return nil
+}
+
+// reportTranscript runs command with debug logging captured and returns the
+// redacted log and how the command ended.
+func reportTranscript(name string, args []string) (transcript, result string) {
+	var run func([]string) error
+	for _, c := range commands {
+		if c.Name == name && c.Name != "report" {
+			run = c.Run
+		}
+	}
+	if run == nil {
+		return "", fmt.Sprintf("unknown command %q\n", name)
+	}
+
+	var buf bytes.Buffer
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	log.Logger = log.Output(zerolog.MultiLevelWriter(consoleLog, &buf))
+	err := run(args)
+	log.Logger = log.Output(consoleLog)
+	zerolog.SetGlobalLevel(level)
+
+	result = "ok\n"
+	if err != nil {
+		result = fmt.Sprintf("error: %v\nhint: %s\n", err, errorHint(err))
+	}
+	return kenwoodutil.RedactTranscript(buf.String()), kenwoodutil.RedactTranscript(result)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	fromBackup := fs.Bool("from-backup", false, "restore the channels of a safety backup, the latest one in -backup-dir without a file")
+	fs.Parse(args)
+
+	if !*fromBackup {
+		return errors.New("usage: restore -from-backup [file]")
+	}
+	path := fs.Arg(0)
+	if path == "" {
+		var err error
+		if path, err = kenwoodutil.LatestBackup(*backupDir); err != nil {
+			return err
+		}
+	}
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+	if d.Backup == nil {
+		return fmt.Errorf("error: %s is not a safety backup", path)
+	}
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	log.Info().Str("file", path).Int("channels", len(d.Backup.Channels)).Msg("Restoring backup...")
+	summary, err := r.RestoreBackup(d)
+	if err != nil {
+		return err
+	}
+	log.Info().Int("channels", summary.Written).Msg("Backup restored.")
+	if len(summary.NamesSkipped) > 0 {
+		log.Warn().Interface("channels", summary.NamesSkipped).Msg("Names were not written for some channels, the radio does not support them there.")
+	}
+	return nil
+}
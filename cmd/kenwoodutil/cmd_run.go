@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+)
+
+func runMacro(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.Parse(args)
+
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		var names []string
+		for name := range c.Macros {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t%s\n", name, c.Macros[name].Description)
+		}
+		return w.Flush()
+	}
+
+	m, ok := c.Macros[fs.Arg(0)]
+	if !ok {
+		return fmt.Errorf("no macro named %q in %s", fs.Arg(0), *configPath)
+	}
+	params := map[string]string{}
+	for _, a := range fs.Args()[1:] {
+		kv := strings.SplitN(a, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("macro parameters must look like name=value, not %q", a)
+		}
+		params[kv[0]] = kv[1]
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	if err := r.RunMacro(m, params); err != nil {
+		return err
+	}
+	log.Info().Str("macro", fs.Arg(0)).Msg("Macro done.")
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runScan(args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	bandName := fs.String("band", "", "band to scan, A or B, defaults to the control band")
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		return errors.New("usage: scan [-band A|B] start|stop | resume [time|carrier|seek]")
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	switch fs.Arg(0) {
+	case "start", "stop":
+		band := -1
+		if *bandName != "" {
+			if band, err = units.ParseBand(*bandName); err != nil {
+				return err
+			}
+		} else if band, _, err = r.GetBand(); err != nil {
+			return err
+		}
+		on := fs.Arg(0) == "start"
+		if err := r.SetScan(band, on); err != nil {
+			return err
+		}
+		log.Info().Str("band", units.BandName(band)).Bool("scanning", on).Msg("Scan switched.")
+	case "resume":
+		if fs.NArg() > 1 {
+			return r.SetScanResume(fs.Arg(1))
+		}
+		mode, err := r.ScanResume()
+		if err != nil {
+			return err
+		}
+		fmt.Println(mode)
+	default:
+		return fmt.Errorf("unknown scan command %q", fs.Arg(0))
+	}
+	return nil
+}
+
+func runLockout(args []string) error {
+	fs := flag.NewFlagSet("lockout", flag.ExitOnError)
+	set := fs.String("set", "", "channels to lock out of scanning, like 10-20,25")
+	clear := fs.String("clear", "", "channels to scan again")
+	fromRadio := fs.Bool("radio", false, "change the radio instead of a dump")
+	force := fs.Bool("force", false, "change pinned channels of the dump too")
+	fs.Parse(args)
+	if *set == "" && *clear == "" {
+		return errors.New("usage: lockout [-set channels] [-clear channels] [-radio] [-force] [file]")
+	}
+	setList, err := units.ParseChannelList(*set)
+	if err != nil {
+		return err
+	}
+	clearList, err := units.ParseChannelList(*clear)
+	if err != nil {
+		return err
+	}
+
+	var locked, unlocked []int
+	if *fromRadio {
+		r, err := openRadio()
+		if err != nil {
+			return err
+		}
+		if locked, err = r.SetLockout(setList, true); err != nil {
+			return err
+		}
+		if unlocked, err = r.SetLockout(clearList, false); err != nil {
+			return err
+		}
+	} else {
+		path := defaultDumpPath
+		if fs.NArg() > 0 {
+			path = fs.Arg(0)
+		}
+		d, err := kenwoodutil.LoadDump(path)
+		if err != nil {
+			return err
+		}
+		if !*force {
+			var numbers []uint16
+			for _, n := range append(setList, clearList...) {
+				numbers = append(numbers, uint16(n))
+			}
+			if err := d.CheckPinned(numbers); err != nil {
+				return fmt.Errorf("%w, use -force to change it", err)
+			}
+		}
+		locked, unlocked = d.SetLockout(setList, true), d.SetLockout(clearList, false)
+		if err := d.Save(path); err != nil {
+			return err
+		}
+	}
+	log.Info().Ints("locked out", locked).Ints("cleared", unlocked).Msg("Lockout done.")
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runSnapshot(args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	out := fs.String("o", "", "file to write the snapshot to, instead of standard output")
+	memory := fs.Bool("memory", true, "read memory and settings to checksum the codeplug, which takes a while")
+	fs.Parse(args)
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	s, err := r.Snapshot(*memory)
+	if err != nil {
+		return err
+	}
+	j, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding snapshot: %w", err)
+	}
+	j = append(j, '\n')
+	if *out == "" {
+		_, err = os.Stdout.Write(j)
+		return err
+	}
+	if err := kenwoodutil.WriteFileAtomic(*out, j, 0644, false); err != nil {
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+	return nil
+}
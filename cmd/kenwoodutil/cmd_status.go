@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+func runStatus(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	follow := fs.Bool("follow", false, "keep updating the status line")
+	interval := fs.Duration("interval", time.Second, "update interval in follow mode")
+	fs.Parse(args)
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	for {
+		control, _, err := r.GetBand()
+		if err != nil {
+			return err
+		}
+		s, err := r.BandStatus(control)
+		if err != nil {
+			return err
+		}
+		if !*follow {
+			fmt.Println(s)
+			return nil
+		}
+		fmt.Printf("\r%s\033[K", s)
+		time.Sleep(*interval)
+	}
+}
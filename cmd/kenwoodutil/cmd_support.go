@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runSupport(args []string) error {
+	fs := flag.NewFlagSet("support", flag.ExitOnError)
+	probe := fs.Bool("probe", false, "probe the commands the radio answers again, as after a firmware update")
+	fs.Parse(args)
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	if *probe {
+		if r.Support, err = r.ProbeSupport(); err != nil {
+			return err
+		}
+		cache, _ := kenwoodutil.LoadRadioCache(r)
+		cache.Support = r.Support
+		if err := kenwoodutil.SaveRadioCache(r, cache); err != nil {
+			return err
+		}
+		log.Info().Msg("Probed commands stored in radio cache.")
+	}
+	source := "command catalog"
+	if r.Support != nil {
+		source = "probe"
+	}
+	fmt.Printf("%s, as of the %s\n\n", r.Model, source)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "COMMAND\tSUPPORTED\tMISSING")
+	for _, op := range kenwoodutil.Operations {
+		missing := r.Missing(op)
+		supported := "yes"
+		if len(missing) > 0 {
+			supported = "no"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", op.Name, supported, strings.Join(missing, " "))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runSurvey(args []string) error {
+	fs := flag.NewFlagSet("survey", flag.ExitOnError)
+	fromMHz := fs.String("from", "", "start of the range in MHz")
+	toMHz := fs.String("to", "", "end of the range in MHz")
+	stepKHz := fs.Float64("step", 12.5, "step in kHz")
+	bandName := fs.String("band", "", "band to survey with (A or B), defaults to the control band")
+	dwell := fs.Duration("dwell", 150*time.Millisecond, "time on each frequency before the squelch is read")
+	channels := fs.String("channels", "900-999", "scratch channels to store active frequencies in")
+	passes := fs.Int("passes", 0, "number of sweeps, 0 to sweep until interrupted")
+	logPath := fs.String("log", "", "append each active frequency found to this CSV reception log")
+	fs.Parse(args)
+
+	if *fromMHz == "" || *toMHz == "" {
+		return errors.New("usage: survey -from MHz -to MHz [-step kHz] [-band A|B] [-dwell 150ms] [-channels 900-999] [-passes n] [-log file.csv] [file]")
+	}
+	from, err := units.ParseMHz(*fromMHz)
+	if err != nil {
+		return err
+	}
+	to, err := units.ParseMHz(*toMHz)
+	if err != nil {
+		return err
+	}
+	if to < from {
+		return fmt.Errorf("error: %s MHz is below %s MHz", *toMHz, *fromMHz)
+	}
+	if _, err := units.StepIndex(*stepKHz); err != nil {
+		return err
+	}
+	step := uint32(*stepKHz * 1e3)
+	candidates, err := units.ParseChannelList(*channels)
+	if err != nil {
+		return err
+	}
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	d, err := loadDumpOrNew(path)
+	if err != nil {
+		return err
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	var band int
+	if *bandName == "" {
+		band, _, err = r.GetBand()
+	} else {
+		band, err = units.ParseBand(*bandName)
+	}
+	if err != nil {
+		return err
+	}
+	log.Info().Str("channels", *channels).Msg("Reading scratch channels...")
+	bank, err := kenwoodutil.NewScratchBank(r, d, candidates)
+	if err != nil {
+		return err
+	}
+	logHit, closeLog, err := openReceptionLog(*logPath, "csv")
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	stopped, full := false, false
+	for pass := 1; !stopped && !full && (*passes == 0 || pass <= *passes); pass++ {
+		log.Info().Int("pass", pass).Msgf("Sweeping %s-%s MHz...", units.FormatMHz(from), units.FormatMHz(to))
+		var serr error
+		err := r.SurveyRange(band, from, to, step, *dwell, func(s kenwoodutil.SignalSample) bool {
+			select {
+			case <-sig:
+				stopped = true
+				return false
+			default:
+			}
+			if !s.Busy {
+				return true
+			}
+			// the frequency was listened to for the dwell time
+			hit := &kenwoodutil.Hit{Start: s.Time.Add(-*dwell), End: s.Time, Frequency: s.Frequency, Modulation: s.Modulation, PeakSMeter: s.SMeter}
+			if serr = logHit(hit); serr != nil {
+				serr = fmt.Errorf("error writing log: %w", serr)
+				return false
+			}
+			var ch uint16
+			var added bool
+			ch, added, full, serr = bank.Store(s)
+			switch {
+			case serr != nil:
+				return false
+			case full:
+				log.Warn().Str("frequency", units.FormatMHz(s.Frequency)).Msg("Scratch channels are full, stopping.")
+				return false
+			case added:
+				log.Info().Str("frequency", units.FormatMHz(s.Frequency)).Int("smeter", s.SMeter).Int("channel", int(ch)).Msg("New activity stored.")
+			default:
+				log.Debug().Str("frequency", units.FormatMHz(s.Frequency)).Int("smeter", s.SMeter).Msg("activity")
+			}
+			return true
+		})
+		if err == nil {
+			err = serr
+		}
+		if serr := d.Save(path); serr != nil && err == nil {
+			err = serr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	log.Info().Str("file", path).Msg("Survey done.")
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runTag(args []string) error {
+	fs := flag.NewFlagSet("tag", flag.ExitOnError)
+	set := fs.String("set", "", "channels to tag, like 0-9,500")
+	clear := fs.String("clear", "", "channels to untag")
+	path := fs.String("f", defaultDumpPath, "dump file")
+	fs.Parse(args)
+
+	d, err := kenwoodutil.LoadDump(*path)
+	if err != nil {
+		return err
+	}
+
+	tag := fs.Arg(0)
+	if tag == "" {
+		tags := d.Tags()
+		if len(tags) == 0 {
+			fmt.Println("no channels are tagged")
+			return nil
+		}
+		var names []string
+		for t := range tags {
+			names = append(names, t)
+		}
+		sort.Strings(names)
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "TAG\tCHANNELS")
+		for _, t := range names {
+			fmt.Fprintf(w, "%s\t%d\n", t, tags[t])
+		}
+		return w.Flush()
+	}
+
+	if *set == "" && *clear == "" {
+		var tagged []int
+		for n := range d.Tagged(tag) {
+			tagged = append(tagged, int(n))
+		}
+		if len(tagged) == 0 {
+			fmt.Printf("no channels are tagged %s\n", tag)
+			return nil
+		}
+		sort.Ints(tagged)
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "CH\tFREQ\tNAME")
+		for _, n := range tagged {
+			m, _ := d.Channel(uint16(n))
+			fmt.Fprintf(w, "%03d\t%s\t%s\n", n, units.FormatMHz(m.RXFrequency), m.Name)
+		}
+		return w.Flush()
+	}
+
+	setList, err := units.ParseChannelList(*set)
+	if err != nil {
+		return err
+	}
+	clearList, err := units.ParseChannelList(*clear)
+	if err != nil {
+		return err
+	}
+	for _, n := range setList {
+		if err := d.SetTag(uint16(n), tag, true); err != nil {
+			return err
+		}
+	}
+	for _, n := range clearList {
+		if err := d.SetTag(uint16(n), tag, false); err != nil {
+			return err
+		}
+	}
+	if err := d.Save(*path); err != nil {
+		return err
+	}
+	log.Info().Str("tag", tag).Int("tagged", len(d.Tagged(tag))).Msg("Tags saved.")
+	return nil
+}
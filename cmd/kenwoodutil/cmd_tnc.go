@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runTNC(args []string) error {
+	fs := flag.NewFlagSet("tnc", flag.ExitOnError)
+	bandName := fs.String("band", "", "data band, A or B, defaults to the current one")
+	fs.Parse(args)
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	mode, band, err := r.GetTNC()
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 && *bandName == "" {
+		fmt.Printf("%s on band %s\n", kenwoodutil.TNCModeName(mode), units.BandName(band))
+		return nil
+	}
+
+	if fs.NArg() > 0 {
+		if mode, err = kenwoodutil.ParseTNCMode(fs.Arg(0)); err != nil {
+			return err
+		}
+	}
+	if *bandName != "" {
+		if band, err = units.ParseBand(*bandName); err != nil {
+			return err
+		}
+	}
+	if err := r.SetTNC(mode, band); err != nil {
+		return err
+	}
+	log.Info().Str("mode", kenwoodutil.TNCModeName(mode)).Str("band", units.BandName(band)).Msg("TNC set.")
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runTrash(args []string) error {
+	fs := flag.NewFlagSet("trash", flag.ExitOnError)
+	channels := fs.String("channels", "", "channels to restore or empty, like 10-20,25, defaults to all")
+	fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return errors.New("usage: trash [-channels list] list|restore|empty [file]")
+	}
+	path := defaultDumpPath
+	if fs.NArg() > 1 {
+		path = fs.Arg(1)
+	}
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+	list, err := units.ParseChannelList(*channels)
+	if err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "list":
+		if len(d.Trash) == 0 {
+			fmt.Println("trash is empty")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "CH\tFREQ\tNAME\tREMOVED\tREASON")
+		for _, t := range d.Trash {
+			fmt.Fprintf(w, "%03d\t%s\t%s\t%s\t%s\n", t.Channel.Number, units.FormatMHz(t.Channel.RXFrequency), t.Channel.Name, t.Removed.Format("2006-01-02 15:04"), t.Reason)
+		}
+		return w.Flush()
+	case "restore":
+		restored, err := d.Restore(list)
+		if err != nil {
+			return err
+		}
+		if len(restored) == 0 {
+			return errors.New("error: nothing to restore")
+		}
+		if err := d.Save(path); err != nil {
+			return err
+		}
+		log.Info().Interface("channels", restored).Msg("Channels restored.")
+	case "empty":
+		want := map[uint16]bool{}
+		for _, n := range list {
+			want[uint16(n)] = true
+		}
+		var trash []kenwoodutil.TrashedChannel
+		for _, t := range d.Trash {
+			if len(want) > 0 && !want[t.Channel.Number] {
+				trash = append(trash, t)
+			}
+		}
+		log.Info().Int("channels", len(d.Trash)-len(trash)).Msg("Trash emptied.")
+		d.Trash = trash
+		return d.Save(path)
+	default:
+		return fmt.Errorf("unknown trash command %q", fs.Arg(0))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runVFO(args []string) error {
+	fs := flag.NewFlagSet("vfo", flag.ExitOnError)
+	bandName := fs.String("band", "A", "band to operate on (A or B)")
+	fs.Parse(args)
+
+	band, err := units.ParseBand(*bandName)
+	if err != nil {
+		return err
+	}
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "":
+	case "freq":
+		hz, err := units.ParseMHz(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		if err := r.SetFrequency(band, hz); err != nil {
+			return err
+		}
+	case "mode":
+		mode, err := units.ParseMode(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		if err := r.SetMode(band, mode); err != nil {
+			return err
+		}
+	case "select":
+		if err := r.SelectBand(band); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown vfo action %q", fs.Arg(0))
+	}
+
+	v, err := r.GetVFO(band)
+	if err != nil {
+		return err
+	}
+	control, ptt, err := r.GetBand()
+	if err != nil {
+		return err
+	}
+	// the amateur band, or whether the radio cannot transmit there
+	var tx string
+	if name, ok := r.Capabilities().BandOf(v.Frequency); ok {
+		tx = " " + name
+	} else if len(r.Capabilities().TXBands) > 0 {
+		tx = " RX only"
+	}
+	fmt.Printf("band %s: %s MHz%s %s (control band %s, PTT band %s)\n",
+		units.BandName(band), units.FormatMHz(v.Frequency), tx, units.ModeName(v.Mode), units.BandName(control), units.BandName(ptt))
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+const (
+	defaultCoverageBins  = 60
+	defaultCoverageSplit = 10 // MHz
+	svgBarHeight         = 60
+	svgBinWidth          = 10
+	svgMargin            = 20
+)
+
+func runViz(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: viz coverage|banks [flags] [file]")
+	}
+	switch args[0] {
+	case "coverage":
+		return runVizCoverage(args[1:])
+	case "banks":
+		return runVizBanks(args[1:])
+	}
+	return fmt.Errorf("error: unknown chart %q", args[0])
+}
+
+func runVizCoverage(args []string) error {
+	fs := flag.NewFlagSet("viz coverage", flag.ExitOnError)
+	bins := fs.Int("bins", defaultCoverageBins, "bins per segment")
+	split := fs.Float64("split", defaultCoverageSplit, "start a new segment at gaps wider than this many MHz")
+	svg := fs.String("svg", "", "write an SVG chart to this file instead of printing one")
+	fs.Parse(args)
+	if *bins < 1 {
+		return fmt.Errorf("error: -bins must be at least 1")
+	}
+
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+	segments := kenwoodutil.Coverage(d.Memory, *bins, uint32(*split*1e6))
+	if len(segments) == 0 {
+		return fmt.Errorf("error: %s has no channels", path)
+	}
+	if *svg != "" {
+		f, err := os.Create(*svg)
+		if err != nil {
+			return fmt.Errorf("error writing chart: %w", err)
+		}
+		defer f.Close()
+		return writeCoverageSVG(f, segments)
+	}
+	printCoverage(os.Stdout, segments)
+	return nil
+}
+
+var coverageShades = []rune(" ▁▂▃▄▅▆▇█")
+
+func printCoverage(w io.Writer, segments []kenwoodutil.CoverageSegment) {
+	for _, s := range segments {
+		peak := s.Peak()
+		var bar strings.Builder
+		for _, b := range s.Bins {
+			if b.Channels == 0 {
+				bar.WriteRune('·')
+				continue
+			}
+			bar.WriteRune(coverageShades[(b.Channels*(len(coverageShades)-1)+peak-1)/peak])
+		}
+		fmt.Fprintf(w, "%s - %s MHz, %d channels, at most %d per bin\n", units.FormatMHz(s.Low), units.FormatMHz(s.High), s.Channels, peak)
+		fmt.Fprintf(w, "  %s\n", bar.String())
+		for _, g := range s.Gaps {
+			fmt.Fprintf(w, "  gap %s - %s MHz\n", units.FormatMHz(g.Low), units.FormatMHz(g.High))
+		}
+	}
+}
+
+func writeCoverageSVG(w io.Writer, segments []kenwoodutil.CoverageSegment) error {
+	width := 2 * svgMargin
+	for _, s := range segments {
+		if n := len(s.Bins)*svgBinWidth + 2*svgMargin; n > width {
+			width = n
+		}
+	}
+	rowHeight := svgBarHeight + 2*svgMargin
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", width, rowHeight*len(segments))
+	for i, s := range segments {
+		top := i * rowHeight
+		peak := s.Peak()
+		fmt.Fprintf(w, `<text x="%d" y="%d">%s - %s MHz, %d channels</text>`+"\n", svgMargin, top+svgMargin-5, units.FormatMHz(s.Low), units.FormatMHz(s.High), s.Channels)
+		for j, b := range s.Bins {
+			x := svgMargin + j*svgBinWidth
+			if b.Channels == 0 {
+				fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="#f4c7c3"><title>gap %s MHz</title></rect>`+"\n",
+					x, top+svgMargin, svgBinWidth, svgBarHeight, units.FormatMHz(b.Low))
+				continue
+			}
+			h := b.Channels * svgBarHeight / peak
+			fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="#3b78e7"><title>%s MHz: %d</title></rect>`+"\n",
+				x, top+svgMargin+svgBarHeight-h, svgBinWidth-1, h, units.FormatMHz(b.Low), b.Channels)
+		}
+	}
+	_, err := fmt.Fprintln(w, "</svg>")
+	return err
+}
+
+var slotMarks = []rune{'·', '█', '+'}
+
+func runVizBanks(args []string) error {
+	fs := flag.NewFlagSet("viz banks", flag.ExitOnError)
+	channels := fs.String("channels", "", "channel range an import would fill, like 500-599")
+	count := fs.Int("count", 0, "how many channels the import brings, defaults to filling the range")
+	fs.Parse(args)
+
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+	var pending []int
+	if *channels != "" {
+		candidates, err := units.ParseChannelList(*channels)
+		if err != nil {
+			return err
+		}
+		pending = d.FreeChannels(candidates)
+		if *count > len(pending) {
+			fmt.Printf("only %d of %d channels fit into %s\n", len(pending), *count, *channels)
+		} else if *count > 0 {
+			pending = pending[:*count]
+		}
+	}
+
+	slots := kenwoodutil.Occupancy(d.Memory, pending, d.MemorySize())
+	fmt.Printf("%s used  %s empty  %s pending import\n", string(slotMarks[kenwoodutil.SlotUsed]), string(slotMarks[kenwoodutil.SlotEmpty]), string(slotMarks[kenwoodutil.SlotPending]))
+	for bank := 0; bank < len(slots)/kenwoodutil.BankSize; bank++ {
+		var row strings.Builder
+		used, added := 0, 0
+		for _, st := range slots[bank*kenwoodutil.BankSize : (bank+1)*kenwoodutil.BankSize] {
+			row.WriteRune(slotMarks[st])
+			switch st {
+			case kenwoodutil.SlotUsed:
+				used++
+			case kenwoodutil.SlotPending:
+				added++
+			}
+		}
+		fmt.Printf("%03d-%03d %s %3d", bank*kenwoodutil.BankSize, (bank+1)*kenwoodutil.BankSize-1, row.String(), used)
+		if added > 0 {
+			fmt.Printf(" +%d", added)
+		}
+		fmt.Println()
+	}
+	return nil
+}
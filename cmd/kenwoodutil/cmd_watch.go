@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := fs.Duration("interval", 5*time.Second, "polling interval")
+	format := fs.String("format", "jsonl", "log format, jsonl or csv")
+	bandName := fs.String("band", "both", "band to watch, A, B or both")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("error: expected a log file to append to")
+	}
+	if *format != "jsonl" && *format != "csv" {
+		return fmt.Errorf("error: unknown log format %q, expected jsonl or csv", *format)
+	}
+	bands := []int{0, 1}
+	if *bandName != "both" {
+		band, err := units.ParseBand(*bandName)
+		if err != nil {
+			return err
+		}
+		bands = []int{band}
+	}
+
+	f, err := os.OpenFile(fs.Arg(0), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %w", err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("error opening log file: %w", err)
+	}
+	cw := csv.NewWriter(f)
+	enc := json.NewEncoder(f)
+	write := func(rec kenwoodutil.TuneRecord) error {
+		if *format == "jsonl" {
+			return enc.Encode(rec)
+		}
+		cw.Write(rec.CSV())
+		cw.Flush()
+		return cw.Error()
+	}
+	if *format == "csv" && fi.Size() == 0 {
+		cw.Write(kenwoodutil.TuneRecordHeader)
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	last := map[int]kenwoodutil.TuneRecord{}
+	log.Info().Str("file", fs.Arg(0)).Dur("interval", *interval).Msg("Watching radio.")
+	for {
+		now := time.Now()
+		for _, band := range bands {
+			s, err := r.BandStatus(band)
+			if err != nil {
+				return err
+			}
+			rec := kenwoodutil.NewTuneRecord(now, s)
+			if prev, ok := last[band]; ok && prev.SameTuning(rec) {
+				continue
+			}
+			last[band] = rec
+			log.Info().Str("band", rec.Band).Str("frequency", rec.Frequency).Str("channel", rec.Channel).Msg("tuning changed")
+			if err := write(rec); err != nil {
+				return fmt.Errorf("error writing log: %w", err)
+			}
+		}
+
+		select {
+		case <-sig:
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
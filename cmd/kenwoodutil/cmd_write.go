@@ -0,0 +1,301 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func runWrite(args []string) error {
+	fs := flag.NewFlagSet("write", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print the commands that would be sent and exit without touching the radio")
+	withSettings := fs.Bool("settings", true, "also write menu settings from the dump")
+	withSpecial := fs.Bool("special", true, "also write call channels and program scan edges from the dump")
+	withDTMF := fs.Bool("dtmf", true, "also write DTMF memories from the dump")
+	withAPRS := fs.Bool("aprs", true, "also write APRS config from the dump")
+	fast := fs.Int("fast", 0, "switch the PC port to this baud rate (up to 57600) for the transfer")
+	resume := fs.Bool("resume", false, "go on with an interrupted write from where it stopped")
+	duplicates := fs.String("duplicates", string(kenwoodutil.DuplicatesError), "what to do with channels numbered alike: error, or last to keep the last copy")
+	watch := fs.Bool("watch", false, "keep watching the file and write the memory channels changed on every save, until interrupted")
+	fs.Parse(args)
+	policy, err := kenwoodutil.ParseDuplicatePolicy(*duplicates)
+	if err != nil {
+		return err
+	}
+
+	path := defaultDumpPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	// a dump piped in can not be resumed against nor written back
+	stdin := path == kenwoodutil.StdioPath
+	if stdin && (*resume || *watch) {
+		return fmt.Errorf("error: -resume and -watch need a dump file, not %s", kenwoodutil.StdioPath)
+	}
+	if *watch {
+		return watchWrite(path, policy)
+	}
+
+	log.Info().Msg("Loading memory from file...")
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+	if err := d.ResolveDuplicates(policy); err != nil {
+		return err
+	}
+	if !*withSettings {
+		d.Settings = nil
+	}
+	if !*withSpecial {
+		d.Special = nil
+	}
+	if !*withDTMF {
+		d.DTMF = nil
+	}
+	if !*withAPRS {
+		d.APRS = nil
+	}
+	log.Info().Msg("Memory loaded from file...")
+
+	if *dryRun {
+		plan := &kenwoodutil.Radio{Model: expectedModel}
+		if d.Radio != nil {
+			plan.Model = d.Radio.Model
+		}
+		c, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if plan.Licence, err = configuredLicence(c, ""); err != nil {
+			return err
+		}
+		if err := plan.SetMemory(d.Memory); err != nil {
+			return err
+		}
+		if err := plan.ValidateMemory(); err != nil {
+			log.Warn().Msg(err.Error())
+		}
+		kenwoodutil.WarnNameChanges(plan.Capabilities().NameChanges(plan.OccupedChannels()))
+		for _, line := range plan.WritePlan() {
+			fmt.Println(line)
+		}
+		for _, m := range d.Special {
+			fmt.Println(m.WriteChannelLine())
+		}
+		for _, e := range d.DTMF {
+			fmt.Println(e.WriteLine())
+		}
+		if d.APRS != nil {
+			for _, line := range d.APRS.Lines() {
+				fmt.Println(line)
+			}
+		}
+		for _, line := range d.Settings.Lines() {
+			fmt.Println(line)
+		}
+		log.Info().Int("channels", len(plan.OccupedChannels())).Msg("Dry run, nothing was written.")
+		return nil
+	}
+
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	restore, err := fastTransfer(r, *fast)
+	if err != nil {
+		return err
+	}
+	defer restore()
+	if err := r.SetMemory(d.Memory); err != nil {
+		return err
+	}
+
+	var cp *kenwoodutil.Checkpoint
+	first := 0
+	done := func(ch int) {}
+	if *resume {
+		if cp, err = kenwoodutil.LoadCheckpoint("write", r, path); err != nil {
+			return err
+		}
+		if first, err = cp.ResumeWrite(r); err != nil {
+			return err
+		}
+		log.Info().Int("channel", first).Msg("Resuming write...")
+	} else if !stdin {
+		if cp, err = kenwoodutil.NewCheckpoint("write", r, path); err != nil {
+			return err
+		}
+	}
+	if cp != nil {
+		done = func(ch int) { cp.Done(ch, r) }
+	}
+	log.Info().Msg("Writing memory...")
+	summary, err := r.WriteMemoryFrom(first, done)
+	if err != nil {
+		if cp == nil {
+			return fmt.Errorf("error writing memory: %w", err)
+		}
+		if serr := cp.Save(); serr != nil {
+			log.Warn().Err(serr).Msg("checkpoint not saved, the write can not be resumed")
+		} else {
+			log.Warn().Int("channel", cp.Last).Msg("Write interrupted, run write -resume to go on.")
+		}
+		return fmt.Errorf("error writing memory: %w", err)
+	}
+	if cp != nil {
+		if err := cp.Remove(); err != nil {
+			log.Warn().Err(err).Msg("stale checkpoint left behind")
+		}
+	}
+	log.Info().Int("channels", summary.Written).Msg("Writing memory done.")
+	if len(summary.NamesSkipped) > 0 {
+		log.Warn().Interface("channels", summary.NamesSkipped).Msg("Names were not written for some channels, the radio does not support them there.")
+	}
+	if !stdin {
+		if err := recordWrite(path, r.OccupedChannels()); err != nil {
+			return err
+		}
+	}
+
+	if len(d.Special) > 0 {
+		log.Info().Msg("Writing special channels...")
+		if err := r.WriteSpecialChannels(d.Special); err != nil {
+			return err
+		}
+	}
+
+	if len(d.DTMF) > 0 {
+		log.Info().Msg("Writing DTMF memories...")
+		if err := r.WriteDTMFMemories(d.DTMF); err != nil {
+			return err
+		}
+	}
+
+	if d.APRS != nil {
+		log.Info().Msg("Writing APRS config...")
+		if err := r.WriteAPRSConfig(d.APRS); err != nil {
+			return err
+		}
+	}
+
+	if len(d.Settings) > 0 {
+		log.Info().Msg("Writing settings...")
+		changes, err := r.ApplySettings(d.Settings)
+		if err != nil {
+			return err
+		}
+		for _, c := range changes {
+			log.Info().Str("setting", c.Key).Str("from", c.Old).Str("to", c.New).Msg("setting changed")
+		}
+		log.Info().Int("changed", len(changes)).Msg("Writing settings done.")
+	}
+	return nil
+}
+
+// recordWrite notes in the dump at path when channels were written. It
+// loads the file again, the dump written from has sections and duplicate
+// channels left out by the flags and must not replace it.
+func recordWrite(path string, channels []kenwoodutil.MemoryEntry) error {
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, m := range channels {
+		d.RecordWrite(m.Number, now)
+	}
+	return d.Save(path)
+}
+
+// watchPoll is how often write -watch looks for a new save of the file.
+const watchPoll = 500 * time.Millisecond
+
+// watchWrite writes the memory channels of the dump at path every time it
+// is saved, only those that differ from the radio. Channels removed from
+// the file since the last save are cleared, others the file never had are
+// left alone. A save that does not load or validate is reported and
+// skipped.
+func watchWrite(path string, policy kenwoodutil.DuplicatePolicy) error {
+	r, err := openRadio()
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Reading memory...")
+	if err := r.ReadMemory(); err != nil {
+		return err
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(watchPoll)
+	defer ticker.Stop()
+
+	var last time.Time
+	var planned map[uint16]bool
+	log.Info().Str("file", path).Msg("Watching file, interrupt to stop.")
+	for {
+		fi, err := os.Stat(path)
+		if err != nil {
+			log.Warn().Err(err).Msg("file not readable")
+		} else if !fi.ModTime().Equal(last) {
+			last = fi.ModTime()
+			if next, err := writeSaved(r, path, policy, planned); err != nil {
+				log.Error().Err(err).Msg("save not written, waiting for the next one")
+			} else {
+				planned = next
+			}
+		}
+		select {
+		case <-sig:
+			log.Info().Msg("Stopped watching.")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeSaved writes a save of the dump at path, clearing the channels of
+// planned it no longer has, and returns the channels it has.
+func writeSaved(r *kenwoodutil.Radio, path string, policy kenwoodutil.DuplicatePolicy, planned map[uint16]bool) (map[uint16]bool, error) {
+	d, err := kenwoodutil.LoadDump(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.ResolveDuplicates(policy); err != nil {
+		return nil, err
+	}
+	if d.Radio == nil {
+		d.Radio = &kenwoodutil.RadioInfo{Model: r.Model}
+	}
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	next := map[uint16]bool{}
+	for _, m := range d.Memory {
+		next[m.Number] = true
+	}
+	layout := &kenwoodutil.Dump{}
+	for _, m := range r.OccupedChannels() {
+		if !planned[m.Number] || next[m.Number] {
+			layout.Memory = append(layout.Memory, m)
+		}
+	}
+	kenwoodutil.PlaceImported(layout, d.Memory, path)
+	diffs := kenwoodutil.DiffMemory(r.OccupedChannels(), layout.Memory)
+	if len(diffs) == 0 {
+		log.Info().Msg("Saved, the radio is up to date.")
+		return next, nil
+	}
+	s, err := r.ApplyLayout(layout.Memory)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Int("written", s.Written).Int("cleared", len(diffs)-s.Written).Msg("Saved changes written.")
+	return next, nil
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/skrzyp/kenwoodutil"
+)
+
+// editViewColumns are the columns of the table view: the sheet column shown,
+// the editor field it is edited through, its header and width.
+var editViewColumns = []struct {
+	sheet, field, header string
+	width                int
+}{
+	{"rx", "freq", "freq", 10},
+	{"tx", "tx", "tx", 10},
+	{"offset", "offset", "offset", 8},
+	{"tone", "tone", "tone", 5},
+	{"ctcss", "ctcss", "ctcss", 5},
+	{"dcs", "dcs", "dcs", 3},
+	{"mode", "mode", "mode", 4},
+	{"step", "step", "step", 5},
+	{"name", "name", "name", 8},
+	{"lockout", "lockout", "lock", 4},
+}
+
+const editViewHelp = "arrows move  enter edit  d delete/undelete  g go to  c commit  q quit"
+
+// editView is the full screen table of the memory editor: a row per memory
+// channel, empty ones included, edited cell by cell.
+type editView struct {
+	t      *terminal
+	out    io.Writer
+	e      *kenwoodutil.MemoryEditor
+	commit func() error
+	line   *lineEditor
+
+	size        int
+	cols, rows  int
+	channel     int
+	column      int
+	top         int
+	status      string
+	statusError bool
+	// quitting is set after q with uncommitted changes, a second q drops
+	// them
+	quitting bool
+}
+
+// runEditView edits e in the table view until it is left.
+func runEditView(t *terminal, e *kenwoodutil.MemoryEditor, commit func() error) error {
+	v := &editView{t: t, out: os.Stdout, e: e, commit: commit, line: newLineEditor(t), size: e.Capabilities.MemorySize(), status: editViewHelp}
+	if channels := e.Channels(); len(channels) > 0 {
+		v.channel = int(channels[0].Number)
+	}
+	fmt.Fprint(v.out, ansiAltScreen)
+	defer fmt.Fprint(v.out, ansiShowCursor+ansiMainScreen)
+	for {
+		v.draw()
+		key, err := t.readKey()
+		if err != nil {
+			return err
+		}
+		done, err := v.handle(key)
+		if done || err != nil {
+			return err
+		}
+	}
+}
+
+// handle acts on a key, done is set when the view is left.
+func (v *editView) handle(key rune) (done bool, err error) {
+	visible := v.rows - 2
+	v.status, v.statusError = editViewHelp, false
+	if key != 'q' && key != keyInterrupt {
+		v.quitting = false
+	}
+	switch key {
+	case keyUp, 'k':
+		v.channel--
+	case keyDown, 'j':
+		v.channel++
+	case keyPageUp:
+		v.channel -= visible
+	case keyPageDown:
+		v.channel += visible
+	case keyHome:
+		v.channel = 0
+	case keyEnd:
+		v.channel = v.size - 1
+	case keyLeft, 'h':
+		if v.column > 0 {
+			v.column--
+		}
+	case keyRight, 'l':
+		if v.column < len(editViewColumns)-1 {
+			v.column++
+		}
+	case keyEnter, 'e':
+		v.editCell()
+	case 'd':
+		n := uint16(v.channel)
+		if v.e.Status(n) == "D" {
+			err = v.e.Undelete(n)
+		} else {
+			err = v.e.Delete(n)
+		}
+		v.fail(err)
+	case 'g':
+		v.goTo()
+	case 'c':
+		v.commitChanges()
+	case 'q', keyInterrupt, keyEOF:
+		n := len(v.e.Changes())
+		if n == 0 || v.quitting {
+			return true, nil
+		}
+		v.quitting = true
+		v.status, v.statusError = fmt.Sprintf("%d channels have uncommitted changes, c commits them, q again drops them", n), true
+	case ctrlL:
+		fmt.Fprint(v.out, ansiClearScreen)
+	}
+	if v.channel < 0 {
+		v.channel = 0
+	}
+	if v.channel >= v.size {
+		v.channel = v.size - 1
+	}
+	return false, nil
+}
+
+// fail shows err on the status line, if there is one.
+func (v *editView) fail(err error) {
+	if err != nil {
+		v.status, v.statusError = err.Error(), true
+	}
+}
+
+// editCell edits the current cell in place, validated by the editor.
+func (v *editView) editCell() {
+	n := uint16(v.channel)
+	c := editViewColumns[v.column]
+	value := ""
+	if m, ok := v.e.Channel(n); ok {
+		value = kenwoodutil.SheetColumns[c.sheet].Value(m)
+	}
+	fmt.Fprint(v.out, ansiShowCursor)
+	defer fmt.Fprint(v.out, ansiHideCursor)
+	v.line.row, v.line.col, v.line.width = v.channel-v.top+2, v.cellColumn(v.column), c.width
+	if w := v.cols - v.line.col; v.line.width < 12 && w >= 12 {
+		v.line.width = 12
+	}
+	value, err := v.line.readLine("", value)
+	if errors.Is(err, errInterrupted) {
+		return
+	}
+	if err == nil {
+		err = v.e.Set(n, c.field, value)
+	}
+	v.fail(err)
+}
+
+// goTo asks for a channel number on the status line and moves to it.
+func (v *editView) goTo() {
+	fmt.Fprint(v.out, ansiShowCursor)
+	defer fmt.Fprint(v.out, ansiHideCursor)
+	v.line.row, v.line.col, v.line.width = v.rows, 1, v.cols-1
+	s, err := v.line.readLine("go to channel: ", "")
+	if err != nil {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 || n >= v.size {
+		v.fail(fmt.Errorf("error: %q is not a channel number of 000-%03d", s, v.size-1))
+		return
+	}
+	v.channel = n
+}
+
+// commitChanges writes the changes once the status line confirms it.
+func (v *editView) commitChanges() {
+	n := len(v.e.Changes())
+	if n == 0 {
+		v.status = "No changes."
+		return
+	}
+	v.status = fmt.Sprintf("Write %d changed channels? y/n", n)
+	v.draw()
+	if key, err := v.t.readKey(); err != nil || (key != 'y' && key != 'Y') {
+		v.status = editViewHelp
+		return
+	}
+	// the editor logs what it writes, that is drawn over afterwards
+	fmt.Fprint(v.out, ansiMoveTo(v.rows, 1)+ansiClearLine+"Writing...\n")
+	if err := v.commit(); err != nil {
+		v.fail(err)
+		return
+	}
+	fmt.Fprint(v.out, ansiClearScreen)
+	v.status = fmt.Sprintf("%d channels written.", n)
+}
+
+// cellColumn is the screen column column i starts at.
+func (v *editView) cellColumn(i int) int {
+	col := len("M 000 ") + 1
+	for _, c := range editViewColumns[:i] {
+		col += c.width + 1
+	}
+	return col
+}
+
+// draw redraws the whole screen: the header, the channels around the
+// current one and the status line.
+func (v *editView) draw() {
+	v.cols, v.rows = v.t.size()
+	visible := v.rows - 2
+	if visible < 1 {
+		visible = 1
+	}
+	if v.channel < v.top {
+		v.top = v.channel
+	}
+	if v.channel >= v.top+visible {
+		v.top = v.channel - visible + 1
+	}
+
+	var b strings.Builder
+	b.WriteString(ansiHideCursor + ansiMoveTo(1, 1))
+	header := "  ch  "
+	for _, c := range editViewColumns {
+		header += pad(c.header, c.width) + " "
+	}
+	changed := len(v.e.Changes())
+	title := fmt.Sprintf("%d changed", changed)
+	b.WriteString(ansiBold + clip(header, v.cols-len(title)-1) + ansiReset)
+	b.WriteString(ansiMoveTo(1, v.cols-len(title)+1) + title + ansiClearLine)
+
+	for i := 0; i < visible; i++ {
+		b.WriteString(ansiMoveTo(i+2, 1))
+		n := v.top + i
+		if n >= v.size {
+			b.WriteString(ansiClearLine)
+			continue
+		}
+		m, ok := v.e.Channel(uint16(n))
+		mark := v.e.Status(uint16(n))
+		if mark == "" && v.e.Pinned[uint16(n)] {
+			mark = "P"
+		}
+		if mark == "D" {
+			// deleted channels still show what they held
+			for _, d := range v.e.Channels() {
+				if d.Number == uint16(n) {
+					m, ok = d, true
+				}
+			}
+		}
+		used := len("M 000 ")
+		fmt.Fprintf(&b, "%-1s %03d ", mark, n)
+		for j, c := range editViewColumns {
+			if used+c.width > v.cols {
+				break
+			}
+			cell := ""
+			if ok {
+				cell = kenwoodutil.SheetColumns[c.sheet].Value(m)
+			}
+			cell = pad(clip(cell, c.width), c.width)
+			if n == v.channel && j == v.column {
+				cell = ansiReverse + cell + ansiReset
+			} else if n == v.channel {
+				cell = ansiBold + cell + ansiReset
+			}
+			b.WriteString(cell + " ")
+			used += c.width + 1
+		}
+		b.WriteString(ansiClearLine)
+	}
+
+	b.WriteString(ansiMoveTo(v.rows, 1))
+	status := clip(v.status, v.cols-1)
+	if v.statusError {
+		status = ansiRed + status + ansiReset
+	}
+	b.WriteString(status + ansiClearLine)
+	fmt.Fprint(v.out, b.String())
+}
+
+// pad fills s with spaces to width characters.
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// clip cuts s to width characters.
+func clip(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if r := []rune(s); len(r) > width {
+		return string(r[:width])
+	}
+	return s
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// errInterrupted is returned by lineEditor.readLine on Ctrl-C.
+var errInterrupted = errors.New("interrupted")
+
+// lineEditor reads lines on a raw terminal. It moves the cursor with the
+// arrow keys, Home, End, Ctrl-A and Ctrl-E, deletes with Backspace, Delete,
+// Ctrl-U, Ctrl-K and Ctrl-W, recalls earlier lines with up and down, and
+// completes the line with Tab.
+type lineEditor struct {
+	t       *terminal
+	out     io.Writer
+	history []string
+	// complete returns the lines line could be completed to, if set
+	complete func(line string) []string
+	// row, col and width, when row is set, place the line on the screen,
+	// padded to width, instead of on the line of the cursor
+	row, col, width int
+}
+
+func newLineEditor(t *terminal) *lineEditor {
+	return &lineEditor{t: t, out: os.Stdout}
+}
+
+// readLine reads a line after prompt, starting from initial. It returns
+// io.EOF on Ctrl-D on an empty line and errInterrupted on Ctrl-C. Lines
+// that are not empty go into the history.
+func (l *lineEditor) readLine(prompt, initial string) (string, error) {
+	buf := []rune(initial)
+	pos := len(buf)
+	// browsing the history, saved is the line being typed
+	recalled, saved := len(l.history), buf
+	for {
+		l.draw(prompt, buf, pos)
+		key, err := l.t.readKey()
+		if err != nil {
+			return "", err
+		}
+		switch key {
+		case keyEnter:
+			line := string(buf)
+			if l.row == 0 {
+				fmt.Fprint(l.out, "\n")
+			}
+			if strings.TrimSpace(line) != "" && (len(l.history) == 0 || l.history[len(l.history)-1] != line) {
+				l.history = append(l.history, line)
+			}
+			return line, nil
+		case keyInterrupt:
+			if l.row == 0 {
+				fmt.Fprint(l.out, "^C\n")
+			}
+			return "", errInterrupted
+		case keyEscape:
+			// cancels editing a cell, a terminal line has Ctrl-C for it
+			if l.row > 0 {
+				return "", errInterrupted
+			}
+		case keyEOF:
+			if len(buf) == 0 {
+				if l.row == 0 {
+					fmt.Fprint(l.out, "\n")
+				}
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+		case keyLeft:
+			if pos > 0 {
+				pos--
+			}
+		case keyRight:
+			if pos < len(buf) {
+				pos++
+			}
+		case keyHome, ctrlA:
+			pos = 0
+		case keyEnd, ctrlE:
+			pos = len(buf)
+		case keyBackspace:
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+		case keyDelete:
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+		case ctrlU:
+			buf, pos = append([]rune{}, buf[pos:]...), 0
+		case ctrlK:
+			buf = buf[:pos]
+		case ctrlW:
+			start := pos
+			for start > 0 && buf[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && buf[start-1] != ' ' {
+				start--
+			}
+			buf, pos = append(buf[:start], buf[pos:]...), start
+		case keyUp, keyDown:
+			if recalled == len(l.history) {
+				saved = buf
+			}
+			if key == keyUp && recalled > 0 {
+				recalled--
+			} else if key == keyDown && recalled < len(l.history) {
+				recalled++
+			}
+			if recalled == len(l.history) {
+				buf = saved
+			} else {
+				buf = []rune(l.history[recalled])
+			}
+			pos = len(buf)
+		case keyTab:
+			if l.complete == nil {
+				break
+			}
+			candidates := l.complete(string(buf))
+			switch len(candidates) {
+			case 0:
+			case 1:
+				buf = []rune(candidates[0])
+			default:
+				buf = []rune(commonPrefix(candidates))
+				if l.row == 0 {
+					fmt.Fprint(l.out, "\r"+ansiClearLine+strings.Join(candidates, "  ")+"\n")
+				}
+			}
+			pos = len(buf)
+		default:
+			if key >= ' ' && key != utf8.RuneError {
+				buf = append(buf[:pos], append([]rune{key}, buf[pos:]...)...)
+				pos++
+			}
+		}
+	}
+}
+
+// draw shows prompt and buf with the cursor at pos.
+func (l *lineEditor) draw(prompt string, buf []rune, pos int) {
+	var b strings.Builder
+	if l.row > 0 {
+		b.WriteString(ansiMoveTo(l.row, l.col))
+		b.WriteString(ansiReverse + prompt + string(buf))
+		if pad := l.width - utf8.RuneCountInString(prompt) - len(buf); pad > 0 {
+			b.WriteString(strings.Repeat(" ", pad))
+		}
+		b.WriteString(ansiReset)
+		b.WriteString(ansiMoveTo(l.row, l.col+utf8.RuneCountInString(prompt)+pos))
+	} else {
+		b.WriteString("\r" + prompt + string(buf) + ansiClearLine)
+		if back := len(buf) - pos; back > 0 {
+			fmt.Fprintf(&b, "\033[%dD", back)
+		}
+	}
+	fmt.Fprint(l.out, b.String())
+}
+
+// commonPrefix is the longest prefix all of v share.
+func commonPrefix(v []string) string {
+	prefix := v[0]
+	for _, s := range v[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+// defaultDumpPath is the dump commands use when not given one, the -radio
+// profile may change it.
+var defaultDumpPath = "./kenwood-memory.json"
+
+var (
+	portPath   = flag.String("port", "/dev/ttyUSB0", "serial port the radio is connected to, tcp://host:port for serial-over-TCP or replay://session.jsonl to play back a recorded session")
+	baudRate   = flag.Int("baud", 9600, "serial port baud rate")
+	autoPort   = flag.Bool("auto", false, "probe all serial ports and baud rates for a radio instead of using -port and -baud")
+	logLevel   = flag.String("loglevel", "debug", "log level (debug, info, warn, error)")
+	configPath = flag.String("config", kenwoodutil.DefaultConfigPath(), "config file")
+	modelsPath = flag.String("models", kenwoodutil.DefaultModelsPath(), "file adding or overriding radio models")
+	dataBits   = flag.Int("databits", 8, "serial port data bits")
+	parity     = flag.String("parity", "none", "serial port parity (none, odd, even, mark, space)")
+	stopBits   = flag.String("stopbits", "1", "serial port stop bits (1, 1.5, 2)")
+	dtr        = flag.String("dtr", "", "DTR line state to set on open (on, off), some cables are powered by it")
+	rts        = flag.String("rts", "", "RTS line state to set on open (on, off)")
+	flowCtl    = flag.String("flow", "none", "flow control (none, rtscts)")
+	tracePath  = flag.String("trace", "", "write every command, reply, latency and retry to this file as JSON lines")
+	recordPath = flag.String("record", "", "record the serial traffic with the radio to this session file, to be played back with -port replay://file")
+	backupDir  = flag.String("backup-dir", "backups", "directory channels are backed up to before they are written or cleared")
+	noBackup   = flag.Bool("no-backup", false, "do not back up channels before writing or clearing them")
+	radioName  = flag.String("radio", "", "named radio from the config file, giving the port, baud and dump path; other flags override it")
+)
+
+// expectedModel is the model the -radio profile says to expect.
+var expectedModel string
+
+// applyRadioProfile takes the settings of the named radio of the config for
+// the flags not given on the command line.
+func applyRadioProfile(name string) error {
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	p, ok := c.Radios[name]
+	if !ok {
+		var known []string
+		for k := range c.Radios {
+			known = append(known, k)
+		}
+		sort.Strings(known)
+		return fmt.Errorf("error: no radio %q in %s, known radios: %s", name, *configPath, strings.Join(known, ", "))
+	}
+	given := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	for f, v := range p.Flags() {
+		if v == "" || given[f] {
+			continue
+		}
+		if err := flag.Set(f, v); err != nil {
+			return fmt.Errorf("error in radio %q of %s: %s: %w", name, *configPath, f, err)
+		}
+	}
+	if p.Dump != "" {
+		defaultDumpPath = p.Dump
+	}
+	expectedModel = p.Model
+	return nil
+}
+
+func serialMode() (kenwoodutil.SerialMode, error) {
+	return kenwoodutil.ParseSerialMode(*dataBits, *parity, *stopBits, *dtr, *rts, *flowCtl)
+}
+
+// tracer is attached to the radio by openRadio and flushed once the command
+// is done.
+var tracer *kenwoodutil.Tracer
+
+// currentCommand is the command being run, which openRadio checks the radio
+// supports before it starts.
+var currentCommand string
+
+// consoleLog is where logs go locally, remote targets are added next to it.
+var consoleLog = zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.Stamp}
+
+type command struct {
+	Name  string
+	Usage string
+	Run   func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"read", "read [-o file] [-resume] [-format json|jsonl] - read radio memory into a dump file, or stream channels as JSON Lines", runRead},
+		{"write", "write [-dry-run] [-resume] [-watch] [-duplicates error|last] [file] - write a dump file to the radio, or with -watch its changed channels on every save", runWrite},
+		{"restore", "restore -from-backup [file] - put back the channels of a safety backup, the latest one by default", runRestore},
+		{"pm", "pm backup|restore [file] - save or restore programmable memories 1-5", runPM},
+		{"dtmf", "dtmf [-f file] list | set n code [name] | clear n - edit DTMF memories in a dump", runDTMF},
+		{"gps", "gps [-format nmea|json] [-valid] [-gpsd host:port] - print the data of the GPS receiver attached to the radio (TM-D710), or the fixes of gpsd", runGPS},
+		{"tnc", "tnc [-band A|B] [off|aprs|packet] - show or set the built-in TNC mode (TM-D710)", runTNC},
+		{"clock", "clock [sync] - show the radio clock offset, or set it from this computer (TM-D710)", runClock},
+		{"raw", "raw [command] - send a raw command, or start an interactive session without one; on a terminal it edits lines, recalls them with up and down and completes commands with Tab, \"?\" lists the commands of the radio and \"M?\" those starting with M", runRaw},
+		{"commands", "commands [-model M] [-json] [prefix] - list the protocol commands known per model with their parameters", runCommandCatalog},
+		{"reorganize", "reorganize -compact|-sort key|-map file [-start n] [-dry-run] [-o file] - rearrange radio memory", runReorganize},
+		{"import", "import repeaterbook -country name -lat deg -lon deg [-radius km] [-channels 500-599] [file] | csv|xlsx [-map field=Column,...] [-mapfile file] [-offline] [-auto-offset] [-force] sheet|url [file] | mcp [-force] export.hmk [file] - add channels to a dump", runImport},
+		{"lock", "lock [status|on|off] - show or set the key lock, against knobs turned by accident", runLock},
+		{"display", "display [status] | brightness 0-8 | auto on|off - show or set the display backlight", runDisplay},
+		{"power", "power [-band A|B] status|on|off|set high|mid|low - switch the radio on or off and set the transmit power", runPower},
+		{"scan", "scan [-band A|B] start|stop | resume [time|carrier|seek] - control scanning", runScan},
+		{"lockout", "lockout [-set channels] [-clear channels] [-radio] [-force] [file] - lock channels out of scanning or back in", runLockout},
+		{"group", "group [-channels 100-199] [-band A|B] [-force] list|add|remove|read|write|select [name] [file] - name channel ranges and read, write or tune them as a whole", runGroup},
+		{"pin", "pin [-set channels] [-clear channels] [file] - protect dump channels from imports and bulk edits, or list the pinned ones", runPin},
+		{"tag", "tag [-f file] [-set channels] [-clear channels] [name] - tag dump channels, or list the tags or the channels with one", runTag},
+		{"trash", "trash [-channels list] list|restore|empty [file] - show, bring back or drop channels removed from a dump", runTrash},
+		{"refresh", "refresh -country name [-radio] [-yes] [-dry-run] [-force] [file] - propose tone and offset corrections of repeater channels from RepeaterBook, applied once confirmed", runRefresh},
+		{"audit", "audit [-radio] [file] - report duplicate frequencies and names and other signs of a messy channel list", runAudit},
+		{"rename", "rename -match regexp -replace name [-radio] [-dry-run] [-force] [file] - rename channels by pattern", runRename},
+		{"licence", "licence [-licence file] [file] - list channels transmitting outside the licensed allocations, which writes refuse", runLicence},
+		{"bandplan", "bandplan [-region R1|R2|R3] [file] - list channels outside amateur bands", runBandPlan},
+		{"ch", "ch set <channel> -freq MHz [-tx MHz | -offset MHz] [-tone Hz | -ctcss Hz | -dcs code] [-mode m] [-step kHz] [-name name] [-lockout] - write a single channel to the radio, asking for the fields left out without -freq", runChannel},
+		{"edit", "edit [-f file [-force]] [-lines] - edit channels in a table on a terminal, or by line commands, writing back only the changed ones", runEdit},
+		{"list", "list [-format table|markdown|html] [-title text] [-radio] [file] - print the channels of a dump or the radio", runList},
+		{"export", "export [-format csv|xlsx] [-columns ch,name,rx,...] [-only bank:3,group:name,tag:SOTA] [-share] -o sheet [file] - write the memory channels of a dump as a spreadsheet, with -share masking channels tagged private", runExport},
+		{"clone", "clone -to port [-to-baud n] - copy memory of the radio on -port to another radio and verify it", runClone},
+		{"lsp", "lsp - serve the Language Server Protocol on stdin and stdout, so that editors show the problems of dump files as they are edited", runLSP},
+		{"migrate", "migrate [-o file] [-force] file - upgrade a dump made by an older version and validate it", runMigrate},
+		{"redact", "redact [-jitter] [-seed n] [-o file] file - strip names and notes from a dump so it can be shared", runRedact},
+		{"viz", "viz coverage [-bins n] [-split MHz] [-svg file] [file] | banks [-channels 500-599] [-count n] [file] - chart frequency coverage or memory occupancy of a dump", runViz},
+		{"pipeline", "pipeline run [-dry-run] file - run a JSON pipeline file reading channels from radio, dump, sheet or repeaterbook sources, through find, rename, renumber, dedupe and validate transforms, into radio, dump or sheet sinks", runPipeline},
+		{"find", "find [-freq MHz] [-name pattern] [-band 70cm] [-tone Hz] [-radio] [file] - print the channels of a dump or the radio matching all the given criteria", runFind},
+		{"info", "info [-json] - print model, serial number, firmware, built-in options and what the model can do", runInfo},
+		{"snapshot", "snapshot [-memory=false] [-o file] - write model, firmware, what both bands are tuned to and codeplug checksums as JSON, for logbooks", runSnapshot},
+		{"diff", "diff [file] - compare a dump file against radio memory", runDiff},
+		{"serve", "serve [-listen :8080] - serve a JSON API to control the radio over HTTP", runServe},
+		{"exporter", "exporter [-listen :9188] [-interval 5s] - serve frequency, S-meter, squelch, PTT and serial error counts as Prometheus metrics", runExporter},
+		{"mqtt", "mqtt [-broker host:port] [-topic kenwood] [-discovery homeassistant] - publish radio state to MQTT and take tune, channel and PTT commands, for home automation", runMQTT},
+		{"oplog", "oplog [-from time] [-to time] [-format csv|jsonl] [file] - export the operating log of the daemon for a time range", runOpLog},
+		{"kiosk", "kiosk [-plan file] - back up, program and verify every radio plugged in with the club plan, reporting through config hooks", runKiosk},
+		{"hotplug", "hotplug - run the config hooks as the radio port appears and disappears, like with the ignition of a mobile install", runHotplug},
+		{"daemon", "daemon - run watchdog rules, scheduled profiles and the operating log set in the config file", runDaemon},
+		{"igate", "igate - keep the radio set up as an APRS igate rig, from the config file", runIGate},
+		{"run", "run [macro [name=value...]] - run a macro from the config file, or list them", runMacro},
+		{"report", "report [-o file.zip] [command args...] - bundle version, platform, port probe and a transcript of command for a bug report", runReport},
+		{"ports", "ports - list serial ports", runPorts},
+		{"band", "band [status] | control|ptt|select A|B | mode A|B vfo|mr|call|wx | crossband on|off - show or set the control and PTT bands, band modes and cross-band repeat", runBandControl},
+		{"vfo", "vfo [-band A|B] [freq <MHz> | mode <FM|AM|NFM> | select] - show or change VFO", runVFO},
+		{"quick", "quick <MHz> [offset MHz] [-tone Hz] [-channel 999] - program a scratch channel and tune to it", runQuick},
+		{"status", "status [-follow] [-interval 1s] - show the control band status", runStatus},
+		{"monitor", "monitor [-band A|B] [-interval 500ms] [-json] [-changes] [-log file] [-log-format adif|csv] - show S-meter and squelch state as it changes", runMonitor},
+		{"watch", "watch [-interval 5s] [-format jsonl|csv] [-band A|B|both] file - log what the radio is tuned to whenever it changes", runWatch},
+		{"survey", "survey -from MHz -to MHz [-step kHz] [-band A|B] [-dwell 150ms] [-channels 900-999] [-passes n] [-log file.csv] [file] - sweep a range with the VFO and store active frequencies into scratch channels", runSurvey},
+		{"drivetest", "drivetest -channel n -o file.csv [-band A|B] [-interval 1s] [-gpsd host:port] [-geojson file] - log the S-meter of a channel with the gpsd position, for coverage surveys", runDriveTest},
+		{"heard", "heard -track file [-format kml|geojson] [-o file] [-freq MHz] [-channel ch] log.csv... - map where receptions of monitor logs were heard, from a gps -format json track", runHeard},
+		{"activity", "activity [-by hour|day] [-format chart|csv] [-from MHz] [-to MHz] log.csv... - chart how busy each frequency was from monitor and survey reception logs", runActivity},
+		{"bookmark", "bookmark [-band A|B] [-note text] [file] - save what the radio is tuned to into the dump inbox", runBookmark},
+		{"ptt", "ptt [-max 30s] [-i-know-what-im-doing] on|off - key or release the transmitter", runPTT},
+		{"support", "support [-probe] - list which commands of this tool the radio supports and which of its protocol commands the others miss", runSupport},
+		{"calibrate", "calibrate [-samples n] [-apply] - measure link latency and recommend pacing", runCalibrate},
+	}
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <command> [args]\n\ncommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s\n", c.Usage)
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "\nfile arguments may be - for standard input, or standard output where written\n")
+	fmt.Fprintf(flag.CommandLine.Output(), "\nflags:\n")
+	flag.PrintDefaults()
+}
+
+func loadConfig() (*kenwoodutil.Config, error) {
+	return kenwoodutil.LoadConfig(*configPath)
+}
+
+// remoteLogging adds the remote log targets from the config to the console
+// log. The returned func flushes and closes them.
+func remoteLogging(c kenwoodutil.LogConfig) (func(), error) {
+	remotes, err := kenwoodutil.RemoteLogWriters(c)
+	if err != nil {
+		return nil, err
+	}
+	if len(remotes) == 0 {
+		return func() {}, nil
+	}
+	writers := []io.Writer{consoleLog}
+	for _, w := range remotes {
+		writers = append(writers, w)
+	}
+	log.Logger = log.Output(zerolog.MultiLevelWriter(writers...))
+	return func() {
+		log.Logger = log.Output(consoleLog)
+		for _, w := range remotes {
+			w.Close()
+		}
+	}, nil
+}
+
+// notifyDone sends the notifications of the config about command, started
+// at started, if it wants them.
+func notifyDone(command string, started time.Time, summary *kenwoodutil.SummaryLog, err error) {
+	c, cerr := loadConfig()
+	if cerr != nil || !c.Notify.Wants(command, time.Since(started)) {
+		return
+	}
+	n := kenwoodutil.Notification{Command: command, Success: err == nil, Started: started, Finished: time.Now(), Summary: summary.Lines()}
+	if err != nil {
+		n.Error = err.Error()
+	}
+	if nerr := c.Notify.Send(n); nerr != nil {
+		log.Warn().Err(nerr).Msg("notification not sent")
+	}
+}
+
+// errorHint suggests what to check after a protocol failure.
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, kenwoodutil.ErrTimeout):
+		return "check that the radio is on, the cable and the baud rate"
+	case errors.Is(err, kenwoodutil.ErrGarbage):
+		return "the link is noisy, try a lower baud rate or run calibrate"
+	case errors.Is(err, kenwoodutil.ErrRadioNAK):
+		return "the radio refused a command, it may not support it or not in the current mode"
+	case errors.Is(err, kenwoodutil.ErrParse):
+		return "unexpected reply, the radio model may not be supported"
+	case errors.Is(err, kenwoodutil.ErrEmptyChannel):
+		return "the channel is not programmed"
+	case errors.Is(err, kenwoodutil.ErrUnsupported):
+		return "run support to see what the radio can do, or support -probe to check again"
+	}
+	return ""
+}
+
+// fastTransfer switches r to baud for a bulk transfer. The returned func
+// switches it back. A zero baud leaves the port speed alone.
+func fastTransfer(r *kenwoodutil.Radio, baud int) (restore func(), err error) {
+	if baud == 0 {
+		return func() {}, nil
+	}
+	previous, err := r.SetPortSpeed(baud)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if _, err := r.SetPortSpeed(previous); err != nil {
+			log.Error().Err(err).Int("baud", previous).Msg("could not restore port speed, set it on the radio menu")
+		}
+	}, nil
+}
+
+// loadDumpOrNew loads the dump at path to be updated, or starts an empty one
+// when there is none yet. Standard input is only read when something is
+// piped in, a terminal there has no dump to give.
+func loadDumpOrNew(path string) (*kenwoodutil.Dump, error) {
+	if path == kenwoodutil.StdioPath && stdinIsTerminal() {
+		return &kenwoodutil.Dump{}, nil
+	}
+	d, err := kenwoodutil.LoadDump(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &kenwoodutil.Dump{}, nil
+	}
+	return d, err
+}
+
+func openRadio() (*kenwoodutil.Radio, error) {
+	if *autoPort {
+		mode, err := serialMode()
+		if err != nil {
+			return nil, err
+		}
+		path, baud, model, err := kenwoodutil.DetectRadio(mode)
+		if err != nil {
+			return nil, err
+		}
+		log.Info().Str("port", path).Int("baud", baud).Str("radio model", model).Msg("Found radio")
+		*portPath, *baudRate = path, baud
+	}
+	r, err := openRadioAt(*portPath, *baudRate)
+	if err != nil {
+		return nil, err
+	}
+	if expectedModel != "" && r.Model != expectedModel {
+		log.Warn().Str("expected", expectedModel).Str("radio model", r.Model).Msgf("%s is not the radio %q of the config", *portPath, *radioName)
+	}
+	return r, nil
+}
+
+// traceWriter writes the protocol trace of -trace, shared by all the
+// radios of a command.
+var traceWriter *kenwoodutil.TraceWriter
+
+// attachHooks logs the protocol events of r at debug level and writes them
+// to the -trace file.
+func attachHooks(r *kenwoodutil.Radio) error {
+	r.Hooks = append(r.Hooks, kenwoodutil.NewLogHook())
+	if *tracePath == "" {
+		return nil
+	}
+	if traceWriter == nil {
+		f, err := os.OpenFile(*tracePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("error opening trace file: %w", err)
+		}
+		traceWriter = kenwoodutil.NewTraceWriter(f)
+	}
+	r.Hooks = append(r.Hooks, traceWriter)
+	return nil
+}
+
+// openRadioAt connects to the radio at path, not the one given by global
+// flags.
+func openRadioAt(path string, baud int) (_ *kenwoodutil.Radio, err error) {
+	mode, err := serialMode()
+	if err != nil {
+		return nil, err
+	}
+	r, err := kenwoodutil.NewRadio(path, baud, mode)
+	if err != nil {
+		return nil, err
+	}
+	// the port is not left open behind a radio that cannot be used
+	defer func() {
+		if err != nil {
+			r.Close()
+		}
+	}()
+	if err := attachHooks(r); err != nil {
+		return nil, err
+	}
+	if *recordPath != "" {
+		f, err := os.OpenFile(*recordPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("error opening session file: %w", err)
+		}
+		r.RecordSession(kenwoodutil.NewSessionRecorder(f))
+	}
+	if !*noBackup {
+		r.BackupDir = *backupDir
+	}
+	if err := r.Identify(); err != nil {
+		return nil, fmt.Errorf("error identifying radio: %w", err)
+	}
+	log.Info().Str("radio model", r.Model).Msg("Connected")
+	c, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	tracer = kenwoodutil.NewTracer(c.Tracing)
+	r.Tracer = tracer
+	if r.Licence, err = configuredLicence(c, ""); err != nil {
+		return nil, err
+	}
+	cache, ok := kenwoodutil.LoadRadioCache(r)
+	if ok && cache.Tuning != (kenwoodutil.Tuning{}) {
+		r.Tuning = cache.Tuning
+		log.Debug().Dur("pacing", r.Tuning.Pacing).Int("pipeline depth", r.Tuning.PipelineDepth).Msg("using calibrated settings")
+	}
+	r.Support = cache.Support
+	if r.Support == nil && !kenwoodutil.BuiltinModel(r.Model) {
+		log.Info().Str("radio model", r.Model).Msg("model only partly known, probing the commands it answers")
+		if r.Support, err = r.ProbeSupport(); err != nil {
+			return nil, err
+		}
+		cache.Support = r.Support
+		if err := kenwoodutil.SaveRadioCache(r, cache); err != nil {
+			log.Warn().Err(err).Msg("probed commands not cached")
+		}
+	}
+	if err := r.Require(currentCommand); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	level, err := zerolog.ParseLevel(*logLevel)
+	if err != nil {
+		level = zerolog.DebugLevel
+	}
+	zerolog.SetGlobalLevel(level)
+	log.Logger = log.Output(consoleLog)
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	if err := kenwoodutil.LoadModels(*modelsPath); err != nil {
+		log.Fatal().Err(err).Msg("loading radio models")
+	}
+	if *radioName != "" {
+		if err := applyRadioProfile(*radioName); err != nil {
+			log.Fatal().Err(err).Msg("selecting radio")
+		}
+	}
+	for _, c := range commands {
+		if c.Name == flag.Arg(0) {
+			summary := &kenwoodutil.SummaryLog{}
+			log.Logger = log.Output(zerolog.MultiLevelWriter(consoleLog, summary))
+			started := time.Now()
+			currentCommand = c.Name
+			err := c.Run(flag.Args()[1:])
+			if ferr := tracer.Flush(); ferr != nil {
+				log.Warn().Err(ferr).Msg("traces were not exported")
+			}
+			notifyDone(c.Name, started, summary, err)
+			if err != nil {
+				ev := log.Fatal().Err(err)
+				if hint := errorHint(err); hint != "" {
+					ev = ev.Str("hint", hint)
+				}
+				ev.Msgf("%s failed", c.Name)
+			}
+			return
+		}
+	}
+	log.Fatal().Str("command", flag.Arg(0)).Msg("unknown command")
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// errNoRawMode is returned by openTerminal where the terminal cannot be put
+// into raw mode, the interactive commands fall back to reading lines then.
+var errNoRawMode = errors.New("error: no raw terminal mode on this system")
+
+// Keys other than runes read by terminal.readKey.
+const (
+	keyUp rune = -1 - iota
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyPageUp
+	keyPageDown
+	keyDelete
+	keyBackspace
+	keyEnter
+	keyTab
+	keyEscape
+	keyInterrupt
+	keyEOF
+)
+
+// Control characters taken for line editing.
+const (
+	ctrlA = 'A' - '@'
+	ctrlE = 'E' - '@'
+	ctrlK = 'K' - '@'
+	ctrlL = 'L' - '@'
+	ctrlU = 'U' - '@'
+	ctrlW = 'W' - '@'
+)
+
+// terminal is stdin in raw mode, read key by key, with output to stdout.
+type terminal struct {
+	in      *bufio.Reader
+	restore func()
+}
+
+// openTerminal puts stdin into raw mode. Close puts it back.
+func openTerminal() (*terminal, error) {
+	if !stdinIsTerminal() {
+		return nil, errNoRawMode
+	}
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	return &terminal{in: bufio.NewReader(os.Stdin), restore: restore}, nil
+}
+
+func (t *terminal) Close() {
+	t.restore()
+}
+
+// size returns the columns and rows of the terminal, 80x24 when it does not
+// say.
+func (t *terminal) size() (cols, rows int) {
+	cols, rows = termSize(int(os.Stdout.Fd()))
+	if cols <= 0 || rows <= 0 {
+		cols, rows = 80, 24
+		if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+			cols = n
+		}
+		if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 0 {
+			rows = n
+		}
+	}
+	return cols, rows
+}
+
+// readKey reads a key press: a rune or one of the key constants. Escape
+// sequences of the cursor and editing keys come in one read, so an escape
+// with nothing buffered after it is the escape key itself.
+func (t *terminal) readKey() (rune, error) {
+	c, _, err := t.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch c {
+	case '\r', '\n':
+		return keyEnter, nil
+	case '\t':
+		return keyTab, nil
+	case 127, '\b':
+		return keyBackspace, nil
+	case 'C' - '@':
+		return keyInterrupt, nil
+	case 'D' - '@':
+		return keyEOF, nil
+	case 27:
+	default:
+		return c, nil
+	}
+	if t.in.Buffered() == 0 {
+		return keyEscape, nil
+	}
+	// CSI "ESC [" or SS3 "ESC O", then parameters and a final byte
+	intro, _ := t.in.ReadByte()
+	if intro != '[' && intro != 'O' {
+		return keyEscape, nil
+	}
+	var params []byte
+	for t.in.Buffered() > 0 {
+		b, _ := t.in.ReadByte()
+		if b >= '0' && b <= '9' || b == ';' {
+			params = append(params, b)
+			continue
+		}
+		switch b {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		case 'C':
+			return keyRight, nil
+		case 'D':
+			return keyLeft, nil
+		case 'H':
+			return keyHome, nil
+		case 'F':
+			return keyEnd, nil
+		case '~':
+			switch string(params) {
+			case "1", "7":
+				return keyHome, nil
+			case "4", "8":
+				return keyEnd, nil
+			case "3":
+				return keyDelete, nil
+			case "5":
+				return keyPageUp, nil
+			case "6":
+				return keyPageDown, nil
+			}
+		}
+		break
+	}
+	return keyEscape, nil
+}
+
+// ANSI sequences of the interactive views.
+const (
+	ansiClearLine   = "\033[K"
+	ansiClearScreen = "\033[H\033[2J"
+	ansiReverse     = "\033[7m"
+	ansiBold        = "\033[1m"
+	ansiRed         = "\033[31m"
+	ansiReset       = "\033[0m"
+	ansiAltScreen   = "\033[?1049h"
+	ansiMainScreen  = "\033[?1049l"
+	ansiHideCursor  = "\033[?25l"
+	ansiShowCursor  = "\033[?25h"
+)
+
+// ansiMoveTo moves the cursor to row and col, counted from 1.
+func ansiMoveTo(row, col int) string {
+	return fmt.Sprintf("\033[%d;%dH", row, col)
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd && !openbsd && !netbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!openbsd,!netbsd,!dragonfly
+
+package main
+
+func makeRaw(fd int) (func(), error) {
+	return nil, errNoRawMode
+}
+
+func termSize(fd int) (cols, rows int) {
+	return 0, 0
+}
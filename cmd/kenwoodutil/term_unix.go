@@ -0,0 +1,45 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd || dragonfly
+// +build linux darwin freebsd openbsd netbsd dragonfly
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+func ioctl(fd int, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// makeRaw turns off line buffering, echo and signal keys on the terminal
+// fd, returning a function putting them back. Output processing is kept,
+// so "\n" still starts a new line.
+func makeRaw(fd int) (func(), error) {
+	var saved syscall.Termios
+	if err := ioctl(fd, ioctlGetTermios, unsafe.Pointer(&saved)); err != nil {
+		return nil, fmt.Errorf("error reading terminal mode: %w", err)
+	}
+	raw := saved
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, fmt.Errorf("error setting terminal mode: %w", err)
+	}
+	return func() { ioctl(fd, ioctlSetTermios, unsafe.Pointer(&saved)) }, nil
+}
+
+// termSize returns the size of the terminal fd, zero when it is unknown.
+func termSize(fd int) (cols, rows int) {
+	var ws struct{ Row, Col, X, Y uint16 }
+	if err := ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return 0, 0
+	}
+	return int(ws.Col), int(ws.Row)
+}
@@ -0,0 +1,103 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// meField describes one comma separated field of ME and CC lines, in the
+// order the radio sends them.
+type meField struct {
+	Name  string
+	Width int
+	Bits  int
+	Get   func(m *MemoryEntry) uint64
+	Set   func(m *MemoryEntry, v uint64)
+}
+
+// meFields is the ME/CC line layout. The first field is the channel number
+// for ME and the band for CC. LockOut is missing on some firmware, and
+// models that keep a power level per channel send it after LockOut, see
+// Power.
+var meFields = []meField{
+	{"Number", 3, 16, func(m *MemoryEntry) uint64 { return uint64(m.Number) }, func(m *MemoryEntry, v uint64) { m.Number = uint16(v) }},
+	{"RXFrequency", 10, 32, func(m *MemoryEntry) uint64 { return uint64(m.RXFrequency) }, func(m *MemoryEntry, v uint64) { m.RXFrequency = uint32(v) }},
+	{"RXStepSize", 1, 8, func(m *MemoryEntry) uint64 { return uint64(m.RXStepSize) }, func(m *MemoryEntry, v uint64) { m.RXStepSize = uint8(v) }},
+	{"ShiftDirection", 1, 8, func(m *MemoryEntry) uint64 { return uint64(m.ShiftDirection) }, func(m *MemoryEntry, v uint64) { m.ShiftDirection = uint8(v) }},
+	{"ReverseEnabled", 1, 8, func(m *MemoryEntry) uint64 { return uint64(m.ReverseEnabled) }, func(m *MemoryEntry, v uint64) { m.ReverseEnabled = uint8(v) }},
+	{"ToneEnabled", 1, 8, func(m *MemoryEntry) uint64 { return uint64(m.ToneEnabled) }, func(m *MemoryEntry, v uint64) { m.ToneEnabled = uint8(v) }},
+	{"CTCSSEnabled", 1, 8, func(m *MemoryEntry) uint64 { return uint64(m.CTCSSEnabled) }, func(m *MemoryEntry, v uint64) { m.CTCSSEnabled = uint8(v) }},
+	{"DCSEnabled", 1, 8, func(m *MemoryEntry) uint64 { return uint64(m.DCSEnabled) }, func(m *MemoryEntry, v uint64) { m.DCSEnabled = uint8(v) }},
+	{"ToneFrequency", 2, 16, func(m *MemoryEntry) uint64 { return uint64(m.ToneFrequency) }, func(m *MemoryEntry, v uint64) { m.ToneFrequency = uint16(v) }},
+	{"CTCSSFrequency", 2, 16, func(m *MemoryEntry) uint64 { return uint64(m.CTCSSFrequency) }, func(m *MemoryEntry, v uint64) { m.CTCSSFrequency = uint16(v) }},
+	{"DCSFrequency", 3, 16, func(m *MemoryEntry) uint64 { return uint64(m.DCSFrequency) }, func(m *MemoryEntry, v uint64) { m.DCSFrequency = uint16(v) }},
+	{"OffsetFrequency", 8, 32, func(m *MemoryEntry) uint64 { return uint64(m.OffsetFrequency) }, func(m *MemoryEntry, v uint64) { m.OffsetFrequency = uint32(v) }},
+	{"Mode", 1, 8, func(m *MemoryEntry) uint64 { return uint64(m.Mode) }, func(m *MemoryEntry, v uint64) { m.Mode = uint8(v) }},
+	{"TXFrequency", 10, 32, func(m *MemoryEntry) uint64 { return uint64(m.TXFrequency) }, func(m *MemoryEntry, v uint64) { m.TXFrequency = uint32(v) }},
+	{"TXStepSize", 1, 8, func(m *MemoryEntry) uint64 { return uint64(m.TXStepSize) }, func(m *MemoryEntry, v uint64) { m.TXStepSize = uint8(v) }},
+	{"LockOut", 1, 8, func(m *MemoryEntry) uint64 { return uint64(m.LockOut) }, func(m *MemoryEntry, v uint64) { m.LockOut = uint8(v) }},
+}
+
+const meOptionalFields = 1
+
+// mnemonic returns the command and width of the first field of the channel
+// line: call channels are addressed by band with CC, everything else by
+// number with ME.
+func (m *MemoryEntry) mnemonic() (string, int) {
+	if m.Kind == CallChannel {
+		return "CC", 1
+	}
+	return "ME", 3
+}
+
+func (m *MemoryEntry) MarshalME() string {
+	mnemonic, numberWidth := m.mnemonic()
+	values := make([]string, len(meFields))
+	for i, f := range meFields {
+		width := f.Width
+		if i == 0 {
+			width = numberWidth
+		}
+		values[i] = fmt.Sprintf("%0*d", width, f.Get(m))
+	}
+	if m.Power != PowerUnset {
+		values = append(values, strconv.Itoa(int(m.Power)-1))
+	}
+	return mnemonic + " " + strings.Join(values, ",")
+}
+
+func (m *MemoryEntry) UnmarshalME(line string) error {
+	mnemonic, _ := m.mnemonic()
+	payload := strings.TrimSuffix(line, "\r")
+	if !strings.HasPrefix(payload, mnemonic+" ") {
+		return parseError("channel line", line, "not a %s line", mnemonic)
+	}
+	values := strings.Split(strings.TrimPrefix(payload, mnemonic+" "), ",")
+	if len(values) < len(meFields)-meOptionalFields || len(values) > len(meFields)+1 {
+		return parseError("channel line", line, "%d fields, expected %d", len(values), len(meFields))
+	}
+	var parsed MemoryEntry
+	if len(values) > len(meFields) {
+		p, err := strconv.ParseUint(values[len(meFields)], 10, 8)
+		if err != nil || p >= uint64(len(powerLevelNames)-1) {
+			return parseError("channel line", line, "bad Power %q", values[len(meFields)])
+		}
+		parsed.Power = PowerLevel(p + 1)
+		values = values[:len(meFields)]
+	}
+	for i, v := range values {
+		f := meFields[i]
+		n, err := strconv.ParseUint(v, 10, f.Bits)
+		if err != nil {
+			return parseError("channel line", line, "bad %s %q", f.Name, v)
+		}
+		f.Set(&parsed, n)
+	}
+	for _, f := range meFields {
+		f.Set(m, f.Get(&parsed))
+	}
+	m.Power = parsed.Power
+	m.Split = m.TXFrequency != 0
+	return nil
+}
@@ -0,0 +1,25 @@
+//go:build go1.18
+// +build go1.18
+
+package kenwoodutil
+
+import "testing"
+
+func FuzzME(f *testing.F) {
+	for _, line := range recordedLines {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		m := MemoryEntry{Kind: kindOf(line + "  ")}
+		if err := m.ReadChannelLine(line + "\r"); err != nil || line == "N" {
+			return
+		}
+		again := MemoryEntry{Kind: m.Kind}
+		if err := again.ReadChannelLine(m.WriteChannelLine() + "\r"); err != nil {
+			t.Fatalf("cannot parse %q written from %q: %v", m.WriteChannelLine(), line, err)
+		}
+		if again != m {
+			t.Fatalf("%q parsed as %+v, re-encoded and parsed as %+v", line, m, again)
+		}
+	})
+}
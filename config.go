@@ -0,0 +1,130 @@
+package kenwoodutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	Macros       map[string]Macro `json:",omitempty"`
+	Daemon       DaemonConfig
+	IGate        IGateConfig
+	Log          LogConfig
+	Tracing      TracingConfig
+	BandDefaults []BandDefaults `json:",omitempty"`
+	BandPlan     BandPlanConfig
+	Radios       map[string]RadioProfile `json:",omitempty"`
+	Hotplug      HotplugConfig
+	Kiosk        KioskConfig
+	MQTT         MQTTConfig
+	Notify       NotifyConfig
+	// Licence is a licence file, see Licence, writes to the radio keep to.
+	Licence string `json:",omitempty"`
+}
+
+// HotplugConfig sets up the hotplug command, for mobile installs where the
+// cable is powered with the ignition and the port comes and goes with it.
+type HotplugConfig struct {
+	// Interval is how often the port is looked for, 2s by default.
+	Interval Duration `json:",omitempty"`
+	// OnConnect is a macro run once the radio answers on the port.
+	OnConnect string `json:",omitempty"`
+	// OnDisconnect lists programs, with their arguments, run once the port
+	// is gone, after logs and traces are flushed.
+	OnDisconnect [][]string `json:",omitempty"`
+}
+
+// RadioProfile is a named radio, standing in for the connection flags and
+// the dump path when selected with -radio. Empty fields leave the defaults.
+type RadioProfile struct {
+	Port     string `json:",omitempty"`
+	Baud     int    `json:",omitempty"`
+	DataBits int    `json:",omitempty"`
+	Parity   string `json:",omitempty"`
+	StopBits string `json:",omitempty"`
+	DTR      string `json:",omitempty"`
+	RTS      string `json:",omitempty"`
+	Flow     string `json:",omitempty"`
+	// Model is the model the radio is expected to identify as, to notice
+	// when the port leads to another radio.
+	Model string `json:",omitempty"`
+	Dump  string `json:",omitempty"`
+}
+
+// Flags maps global flag names to the profile values that set them.
+func (p RadioProfile) Flags() map[string]string {
+	v := map[string]string{"port": p.Port, "parity": p.Parity, "stopbits": p.StopBits, "dtr": p.DTR, "rts": p.RTS, "flow": p.Flow}
+	if p.Baud != 0 {
+		v["baud"] = strconv.Itoa(p.Baud)
+	}
+	if p.DataBits != 0 {
+		v["databits"] = strconv.Itoa(p.DataBits)
+	}
+	return v
+}
+
+type DaemonConfig struct {
+	Interval Duration       `json:",omitempty"`
+	Watchdog []WatchdogRule `json:",omitempty"`
+	// OperatingLog is a JSON lines file the daemon appends what both bands
+	// are tuned to whenever it changes, checked every Interval.
+	OperatingLog string `json:",omitempty"`
+	// Schedule lists profiles the daemon applies by time of day.
+	Schedule []ScheduledProfile `json:",omitempty"`
+}
+
+// Duration is a time.Duration written as "30s" in config files.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "kenwoodutil.json"
+	}
+	return filepath.Join(dir, "kenwoodutil", "config.json")
+}
+
+// DefaultModelsPath is where users describe radio models of their own, next
+// to the config file.
+func DefaultModelsPath() string {
+	return filepath.Join(filepath.Dir(DefaultConfigPath()), "models.json")
+}
+
+// LoadConfig reads the config file at path. A missing file is not an error
+// and gives an empty config.
+func LoadConfig(path string) (*Config, error) {
+	c := &Config{}
+	j, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+	if err := json.Unmarshal(j, c); err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+	return c, nil
+}
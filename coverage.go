@@ -0,0 +1,105 @@
+package kenwoodutil
+
+import (
+	"sort"
+)
+
+// CoverageBin counts channels with RX frequency in [Low, High).
+type CoverageBin struct {
+	Low, High uint32
+	Channels  int
+}
+
+// CoverageSegment is a cluster of channels with no spacing wider than the
+// split given to Coverage. Gaps are runs of empty bins.
+type CoverageSegment struct {
+	Low, High uint32
+	Channels  int
+	Bins      []CoverageBin
+	Gaps      []FrequencyRange
+}
+
+// Coverage groups channels into segments split wherever neighbouring
+// frequencies are more than split Hz apart, and bins every segment into at
+// most bins bins.
+func Coverage(memory []MemoryEntry, bins int, split uint32) []CoverageSegment {
+	var freqs []uint32
+	for _, m := range memory {
+		if m.RXFrequency != 0 {
+			freqs = append(freqs, m.RXFrequency)
+		}
+	}
+	sort.Slice(freqs, func(i, j int) bool { return freqs[i] < freqs[j] })
+
+	var segments []CoverageSegment
+	start := 0
+	for i := 1; i <= len(freqs); i++ {
+		if i < len(freqs) && freqs[i]-freqs[i-1] <= split {
+			continue
+		}
+		segments = append(segments, coverageSegment(freqs[start:i], bins))
+		start = i
+	}
+	return segments
+}
+
+func coverageSegment(freqs []uint32, bins int) CoverageSegment {
+	s := CoverageSegment{Low: freqs[0], High: freqs[len(freqs)-1], Channels: len(freqs)}
+	width := (s.High-s.Low)/uint32(bins) + 1
+	for low := s.Low; low <= s.High; low += width {
+		s.Bins = append(s.Bins, CoverageBin{Low: low, High: low + width})
+	}
+	for _, f := range freqs {
+		s.Bins[(f-s.Low)/width].Channels++
+	}
+	for i := 0; i < len(s.Bins); i++ {
+		if s.Bins[i].Channels != 0 {
+			continue
+		}
+		gap := FrequencyRange{Low: s.Bins[i].Low}
+		for i < len(s.Bins) && s.Bins[i].Channels == 0 {
+			gap.High = s.Bins[i].High
+			i++
+		}
+		s.Gaps = append(s.Gaps, gap)
+	}
+	return s
+}
+
+// Peak returns the channel count of the fullest bin.
+func (s CoverageSegment) Peak() (n int) {
+	for _, b := range s.Bins {
+		if b.Channels > n {
+			n = b.Channels
+		}
+	}
+	return n
+}
+
+// BankSize is how many channels make a bank in occupancy views, the
+// radios themselves have no banks.
+const BankSize = 100
+
+// Slot states in Occupancy.
+const (
+	SlotEmpty = iota
+	SlotUsed
+	SlotPending
+)
+
+// Occupancy returns the state of every slot of a memory of size channels,
+// marking pending ones that an import would fill.
+func Occupancy(memory []MemoryEntry, pending []int, size int) []int {
+	slots := make([]int, size)
+	for _, m := range memory {
+		if m.RXFrequency != 0 && int(m.Number) < len(slots) {
+			slots[m.Number] = SlotUsed
+		}
+	}
+	for _, ch := range pending {
+		if ch >= 0 && ch < len(slots) && slots[ch] == SlotEmpty {
+			slots[ch] = SlotPending
+		}
+	}
+	return slots
+}
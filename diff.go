@@ -0,0 +1,119 @@
+package kenwoodutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+type DiffKind uint8
+
+const (
+	ChannelAdded DiffKind = iota
+	ChannelRemoved
+	ChannelChanged
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case ChannelAdded:
+		return "added"
+	case ChannelRemoved:
+		return "removed"
+	default:
+		return "changed"
+	}
+}
+
+type FieldChange struct {
+	Field    string
+	Old, New string
+}
+
+type ChannelDiff struct {
+	Number  uint16
+	Kind    DiffKind
+	Changes []FieldChange `json:",omitempty"`
+}
+
+func channelIndex(memory []MemoryEntry) map[uint16]MemoryEntry {
+	idx := map[uint16]MemoryEntry{}
+	for _, m := range memory {
+		if m.RXFrequency != 0 {
+			idx[m.Number] = m
+		}
+	}
+	return idx
+}
+
+// DiffMemory compares occupied channels of from and to, reporting what has
+// to change to turn from into to.
+func DiffMemory(from, to []MemoryEntry) (v []ChannelDiff) {
+	a, b := channelIndex(from), channelIndex(to)
+	for n, old := range a {
+		nu, ok := b[n]
+		if !ok {
+			v = append(v, ChannelDiff{Number: n, Kind: ChannelRemoved})
+			continue
+		}
+		if old.Equal(nu) {
+			continue
+		}
+		v = append(v, ChannelDiff{Number: n, Kind: ChannelChanged, Changes: fieldChanges(old, nu)})
+	}
+	for n := range b {
+		if _, ok := a[n]; !ok {
+			v = append(v, ChannelDiff{Number: n, Kind: ChannelAdded})
+		}
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].Number < v[j].Number })
+	return v
+}
+
+// diffChannels returns the numbers of the channels in diffs.
+func diffChannels(diffs []ChannelDiff) []int {
+	v := make([]int, len(diffs))
+	for i, d := range diffs {
+		v[i] = int(d.Number)
+	}
+	return v
+}
+
+// MemoryChecksum sums channels as the radio would be programmed with them,
+// so that a memory read back and the dump it was written from sum the same.
+func MemoryChecksum(channels []MemoryEntry) string {
+	var v []MemoryEntry
+	for _, m := range channels {
+		if m.RXFrequency != 0 {
+			m.Normalize()
+			v = append(v, m)
+		}
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].Number < v[j].Number })
+	h := sha256.New()
+	for _, m := range v {
+		fmt.Fprintf(h, "%s\n%s\n", m.WriteChannelLine(), m.Name)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func fieldChanges(old, nu MemoryEntry) (v []FieldChange) {
+	old.Normalize()
+	nu.Normalize()
+	for _, f := range meFields[1:] {
+		if o, n := f.Get(&old), f.Get(&nu); o != n {
+			v = append(v, FieldChange{Field: f.Name, Old: fmt.Sprint(o), New: fmt.Sprint(n)})
+		}
+	}
+	if old.Power != nu.Power {
+		v = append(v, FieldChange{Field: "Power", Old: old.Power.String(), New: nu.Power.String()})
+	}
+	if old.Name != nu.Name {
+		v = append(v, FieldChange{Field: "Name", Old: old.Name, New: nu.Name})
+	}
+	if old.Kind != nu.Kind {
+		v = append(v, FieldChange{Field: "Kind", Old: old.Kind.String(), New: nu.Kind.String()})
+	}
+	return v
+}
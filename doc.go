@@ -0,0 +1,23 @@
+// Package kenwoodutil talks to Kenwood TM-D710 and TM-V71 radios over their
+// PC port: memory channels, VFOs, settings, the APRS TNC and the GPS data
+// passed through. Around it are the dump files channels are kept in, their
+// imports and exports, and the watchdog, scheduler and logs of long running
+// modes. The kenwoodutil command in cmd/kenwoodutil is built on it, and
+// examples holds smaller programs showing how to use it.
+//
+// A radio is opened with NewRadio and identified before use:
+//
+//	r, err := kenwoodutil.NewRadio("/dev/ttyUSB0", 9600, kenwoodutil.DefaultSerialMode)
+//	if err != nil {
+//		return err
+//	}
+//	defer r.Close()
+//	if err := r.Identify(); err != nil {
+//		return err
+//	}
+//	if err := r.ReadMemory(); err != nil {
+//		return err
+//	}
+//	d := &kenwoodutil.Dump{Radio: r.Info(), Memory: r.OccupedChannels()}
+//	return d.Save("memory.json")
+package kenwoodutil
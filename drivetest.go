@@ -0,0 +1,51 @@
+package kenwoodutil
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// CoverageSample is the signal of a channel at a place, as sampled along a
+// drive test. Fix is false when there was no GPS position.
+type CoverageSample struct {
+	Time                time.Time
+	Fix                 bool
+	Latitude, Longitude float64
+	Frequency           uint32
+	SMeter              int
+	Busy                bool
+}
+
+// coverageTime keeps milliseconds, for sampling intervals under a second.
+const coverageTime = "2006-01-02T15:04:05.000Z07:00"
+
+var CoverageHeader = []string{"time", "latitude", "longitude", "frequency", "smeter", "busy"}
+
+func (s CoverageSample) CSV() []string {
+	lat, lon := "", ""
+	if s.Fix {
+		lat, lon = strconv.FormatFloat(s.Latitude, 'f', 6, 64), strconv.FormatFloat(s.Longitude, 'f', 6, 64)
+	}
+	return []string{s.Time.UTC().Format(coverageTime), lat, lon, units.FormatMHz(s.Frequency), strconv.Itoa(s.SMeter), strconv.FormatBool(s.Busy)}
+}
+
+// WriteCoverageGeoJSON writes the samples with a position as a GeoJSON
+// feature collection of points, S-meter readings in their properties.
+func WriteCoverageGeoJSON(w io.Writer, samples []CoverageSample) error {
+	features := []geoFeature{}
+	for _, s := range samples {
+		if !s.Fix {
+			continue
+		}
+		features = append(features, geoPoint(s.Latitude, s.Longitude, map[string]interface{}{
+			"time":      s.Time.UTC().Format(coverageTime),
+			"frequency": units.FormatMHz(s.Frequency),
+			"smeter":    s.SMeter,
+			"busy":      s.Busy,
+		}))
+	}
+	return writeFeatureCollection(w, features)
+}
@@ -0,0 +1,114 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+const (
+	DMFormat        = "DM %1d,%s,%s"
+	DMCommandFormat = "DM %1d\r"
+
+	DTMFMemories      = 10
+	MaxDTMFCodeLength = 16
+	dtmfDigits        = "0123456789ABCD*#"
+)
+
+// DTMFEntry is a DTMF autodial memory.
+type DTMFEntry struct {
+	Number uint8
+	Code   string `json:",omitempty"`
+	Name   string `json:",omitempty"`
+}
+
+func (e *DTMFEntry) ReadLine(line string) error {
+	items := strings.Split(strings.TrimSuffix(line, "\r"), ",")
+	if !strings.HasPrefix(items[0], "DM ") || len(items) < 2 || len(items) > 3 {
+		return parseError("DTMF memory line", line, "")
+	}
+	e.Code = items[1]
+	e.Name = ""
+	if len(items) == 3 {
+		e.Name = strings.TrimSpace(items[2])
+	}
+	return nil
+}
+
+func (e *DTMFEntry) WriteLine() string {
+	return fmt.Sprintf(DMFormat, e.Number, e.Code, e.Name)
+}
+
+func (e *DTMFEntry) Validate() error {
+	var p []string
+	if int(e.Number) >= DTMFMemories {
+		p = append(p, fmt.Sprintf("DTMF memory number must be 0-%d", DTMFMemories-1))
+	}
+	if len(e.Code) > MaxDTMFCodeLength {
+		p = append(p, fmt.Sprintf("code is longer than %d digits", MaxDTMFCodeLength))
+	}
+	for _, c := range strings.ToUpper(e.Code) {
+		if !strings.ContainsRune(dtmfDigits, c) {
+			p = append(p, fmt.Sprintf("%q is not a DTMF digit", c))
+			break
+		}
+	}
+	if len(e.Name) > units.MaxNameLength {
+		p = append(p, fmt.Sprintf("name %q is longer than %d characters", e.Name, units.MaxNameLength))
+	}
+	if strings.ContainsAny(e.Name, ",\r") {
+		p = append(p, fmt.Sprintf("name %q contains a comma or line break", e.Name))
+	}
+	if len(p) > 0 {
+		return fmt.Errorf("DTMF memory %d: %s", e.Number, strings.Join(p, "; "))
+	}
+	return nil
+}
+
+// ReadDTMFMemories reads the non-empty DTMF memories. Radios without them
+// give an empty list.
+func (r *Radio) ReadDTMFMemories() (v []DTMFEntry, err error) {
+	for i := 0; i < DTMFMemories; i++ {
+		line, err := r.WriteReadString(fmt.Sprintf(DMCommandFormat, i))
+		if errors.Is(err, ErrRadioNAK) {
+			log.Warn().Msg("radio does not support DTMF memories, skipping")
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading DTMF memory %d: %w", i, err)
+		}
+		e := DTMFEntry{Number: uint8(i)}
+		if err := e.ReadLine(line); err != nil {
+			return nil, err
+		}
+		if e.Code != "" || e.Name != "" {
+			v = append(v, e)
+		}
+	}
+	return v, nil
+}
+
+// WriteDTMFMemories programs all DTMF memories, clearing the ones missing
+// from entries.
+func (r *Radio) WriteDTMFMemories(entries []DTMFEntry) error {
+	all := make([]DTMFEntry, DTMFMemories)
+	for i := range all {
+		all[i].Number = uint8(i)
+	}
+	for _, e := range entries {
+		if err := e.Validate(); err != nil {
+			return fmt.Errorf("refusing to write DTMF memories: %w", err)
+		}
+		e.Code = strings.ToUpper(e.Code)
+		all[e.Number] = e
+	}
+	for _, e := range all {
+		if _, err := r.WriteReadString(e.WriteLine() + "\r"); err != nil {
+			return fmt.Errorf("error writing DTMF memory %d: %w", e.Number, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,398 @@
+package kenwoodutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// DumpVersion is the dump format version written by Save. Version 0 dumps
+// are a bare list of channels, version 1 ones have no Version field.
+const DumpVersion = 5
+
+// ToolVersion is set at build time with
+// -ldflags "-X github.com/skrzyp/kenwoodutil.ToolVersion=...".
+var ToolVersion = "devel"
+
+// ToolInfo records which program wrote a dump.
+type ToolInfo struct {
+	Name    string
+	Version string
+	Written time.Time
+}
+
+// RadioInfo records which radio a dump was read from.
+type RadioInfo struct {
+	Model    string
+	Serial   string `json:",omitempty"`
+	Firmware string `json:",omitempty"`
+	// TNC is set when the built-in TNC answered, DataBand is the band it
+	// works on.
+	TNC      bool   `json:",omitempty"`
+	DataBand string `json:",omitempty"`
+	// GPS is set for models passing the data of an attached GPS receiver
+	// through; whether one is attached can not be asked.
+	GPS bool `json:",omitempty"`
+}
+
+// DumpChecksums are sums of the dump sections as saved, to notice dumps
+// changed by hand or damaged since.
+type DumpChecksums struct {
+	Memory   string
+	Special  string `json:",omitempty"`
+	Settings string `json:",omitempty"`
+}
+
+type Dump struct {
+	Version   int
+	Tool      *ToolInfo      `json:",omitempty"`
+	Radio     *RadioInfo     `json:",omitempty"`
+	Checksums *DumpChecksums `json:",omitempty"`
+	Memory    []MemoryEntry
+	Special   []MemoryEntry           `json:",omitempty"`
+	Settings  Settings                `json:",omitempty"`
+	Profiles  []PMProfile             `json:",omitempty"`
+	DTMF      []DTMFEntry             `json:",omitempty"`
+	APRS      *APRSConfig             `json:",omitempty"`
+	Inbox     []Bookmark              `json:",omitempty"`
+	Meta      map[uint16]*ChannelMeta `json:",omitempty"`
+	Groups    []MemoryGroup           `json:",omitempty"`
+	Trash     []TrashedChannel        `json:",omitempty"`
+	Backup    *BackupInfo             `json:",omitempty"`
+}
+
+// dumpMigrations[v] upgrades a version v dump to version v+1.
+var dumpMigrations = []func(raw map[string]json.RawMessage) error{
+	// 0: bare list of channels, wrapped by LoadDump
+	func(raw map[string]json.RawMessage) error { return nil },
+	// 1: no header
+	func(raw map[string]json.RawMessage) error { return nil },
+	// 2: PC port speed setting was keyed by index
+	func(raw map[string]json.RawMessage) error {
+		return renameSettings(raw, map[string]string{"MU.41": "MU.pc_port_speed"})
+	},
+	// 3: no radio info nor checksums, left out until the next read
+	func(raw map[string]json.RawMessage) error { return nil },
+	// 4: split channels were told by their TX frequency alone
+	markSplitChannels,
+}
+
+// DuplicatePolicy says what to do with memory channels of a dump that share
+// a number, as hand edited files may have.
+type DuplicatePolicy string
+
+const (
+	// DuplicatesError refuses the dump.
+	DuplicatesError DuplicatePolicy = "error"
+	// DuplicatesLastWins keeps the copy found last in the file.
+	DuplicatesLastWins DuplicatePolicy = "last"
+)
+
+func ParseDuplicatePolicy(s string) (DuplicatePolicy, error) {
+	switch p := DuplicatePolicy(s); p {
+	case DuplicatesError, DuplicatesLastWins:
+		return p, nil
+	}
+	return "", fmt.Errorf("error: unknown duplicate policy %q, expected error or last", s)
+}
+
+// ResolveDuplicates applies p to the memory channels of d.
+func (d *Dump) ResolveDuplicates(p DuplicatePolicy) error {
+	last := map[uint16]int{}
+	var dups []string
+	for i, m := range d.Memory {
+		if _, ok := last[m.Number]; ok {
+			dups = append(dups, fmt.Sprintf("%03d", m.Number))
+		}
+		last[m.Number] = i
+	}
+	if len(dups) == 0 {
+		return nil
+	}
+	if p != DuplicatesLastWins {
+		return fmt.Errorf("error: channels %s: %w", strings.Join(dups, ", "), ErrDuplicateChannel)
+	}
+	var memory []MemoryEntry
+	for i, m := range d.Memory {
+		if last[m.Number] != i {
+			log.Warn().Str("channel", fmt.Sprintf("%03d", m.Number)).Str("name", m.Name).Str("frequency", units.FormatMHz(m.RXFrequency)).Msg("Duplicate channel dropped, a later copy wins.")
+			continue
+		}
+		memory = append(memory, m)
+	}
+	d.Memory = memory
+	return nil
+}
+
+// markSplitChannels sets Split on the channels with a TX frequency.
+func markSplitChannels(raw map[string]json.RawMessage) error {
+	mark := func(m map[string]json.RawMessage) {
+		if tx, ok := m["TXFrequency"]; ok && string(tx) != "0" {
+			m["Split"] = json.RawMessage("true")
+		}
+	}
+	for _, key := range []string{"Memory", "Special"} {
+		j, ok := raw[key]
+		if !ok {
+			continue
+		}
+		var channels []map[string]json.RawMessage
+		if err := json.Unmarshal(j, &channels); err != nil {
+			return err
+		}
+		for _, m := range channels {
+			mark(m)
+		}
+		var err error
+		if raw[key], err = json.Marshal(channels); err != nil {
+			return err
+		}
+	}
+	j, ok := raw["Trash"]
+	if !ok {
+		return nil
+	}
+	var trash []map[string]json.RawMessage
+	if err := json.Unmarshal(j, &trash); err != nil {
+		return err
+	}
+	for _, t := range trash {
+		if _, ok := t["Channel"]; !ok {
+			continue
+		}
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(t["Channel"], &m); err != nil {
+			return err
+		}
+		mark(m)
+		var err error
+		if t["Channel"], err = json.Marshal(m); err != nil {
+			return err
+		}
+	}
+	var err error
+	raw["Trash"], err = json.Marshal(trash)
+	return err
+}
+
+// renameSettings renames keys of the dump settings and the settings of its
+// programmable memories.
+func renameSettings(raw map[string]json.RawMessage, names map[string]string) error {
+	rename := func(j json.RawMessage) (json.RawMessage, error) {
+		s := map[string]string{}
+		if err := json.Unmarshal(j, &s); err != nil {
+			return nil, err
+		}
+		for from, to := range names {
+			if v, ok := s[from]; ok {
+				delete(s, from)
+				s[to] = v
+			}
+		}
+		return json.Marshal(s)
+	}
+
+	var err error
+	if j, ok := raw["Settings"]; ok {
+		if raw["Settings"], err = rename(j); err != nil {
+			return err
+		}
+	}
+	j, ok := raw["Profiles"]
+	if !ok {
+		return nil
+	}
+	var profiles []map[string]json.RawMessage
+	if err := json.Unmarshal(j, &profiles); err != nil {
+		return err
+	}
+	for _, p := range profiles {
+		if j, ok := p["Settings"]; ok {
+			if p["Settings"], err = rename(j); err != nil {
+				return err
+			}
+		}
+	}
+	raw["Profiles"], err = json.Marshal(profiles)
+	return err
+}
+
+func LoadDump(path string) (*Dump, error) {
+	jj, err := ReadFileOrStdin(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading memory dump: %w", err)
+	}
+	return ParseDump(jj, path)
+}
+
+// ParseDump parses and migrates the dump in jj, read from path.
+func ParseDump(jj []byte, path string) (*Dump, error) {
+	raw := map[string]json.RawMessage{}
+	version := 0
+	if bytes.HasPrefix(bytes.TrimSpace(jj), []byte("[")) {
+		raw["Memory"] = jj
+	} else {
+		if err := json.Unmarshal(jj, &raw); err != nil {
+			return nil, fmt.Errorf("error parsing memory dump: %w", err)
+		}
+		version = 1
+		if v, ok := raw["Version"]; ok {
+			if err := json.Unmarshal(v, &version); err != nil {
+				return nil, fmt.Errorf("error parsing memory dump version: %w", err)
+			}
+		}
+	}
+	if version > DumpVersion {
+		return nil, fmt.Errorf("error: memory dump %s is version %d, this kenwoodutil only knows up to %d, upgrade it", path, version, DumpVersion)
+	}
+	if version < DumpVersion {
+		log.Info().Str("file", path).Int("from", version).Int("to", DumpVersion).Msg("migrating memory dump")
+	}
+	for ; version < DumpVersion; version++ {
+		if err := dumpMigrations[version](raw); err != nil {
+			return nil, fmt.Errorf("error migrating memory dump from version %d: %w", version, err)
+		}
+	}
+	raw["Version"] = json.RawMessage(strconv.Itoa(DumpVersion))
+
+	jj, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing memory dump: %w", err)
+	}
+	d := &Dump{}
+	if err := json.Unmarshal(jj, d); err != nil {
+		return nil, fmt.Errorf("error parsing memory dump: %w", err)
+	}
+	if d.Checksums != nil && *d.Checksums != d.checksums() {
+		log.Warn().Str("file", path).Msg("memory dump changed since it was saved, edited by hand?")
+	}
+	return d, nil
+}
+
+func (d *Dump) checksums() DumpChecksums {
+	c := DumpChecksums{Memory: MemoryChecksum(d.Memory)}
+	if len(d.Special) > 0 {
+		c.Special = MemoryChecksum(d.Special)
+	}
+	if len(d.Settings) > 0 {
+		var keys []string
+		for k := range d.Settings {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		h := sha256.New()
+		for _, k := range keys {
+			fmt.Fprintf(h, "%s=%s\n", k, d.Settings[k])
+		}
+		c.Settings = hex.EncodeToString(h.Sum(nil))[:16]
+	}
+	return c
+}
+
+func (d *Dump) Save(path string) error {
+	return d.save(path, true)
+}
+
+// save writes d to path, keeping the previous file as a backup if
+// keepBackup.
+func (d *Dump) save(path string, keepBackup bool) error {
+	d.Version = DumpVersion
+	d.Tool = &ToolInfo{Name: "kenwoodutil", Version: ToolVersion, Written: time.Now()}
+	c := d.checksums()
+	d.Checksums = &c
+	j, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling memory: %w", err)
+	}
+	err = WriteFileAtomic(path, j, 0644, keepBackup)
+	if err != nil {
+		return fmt.Errorf("error writing memory to file: %w", err)
+	}
+	return nil
+}
+
+// MemorySize is the memory size of the model the dump was read from,
+// DefaultMemoryChannels when it does not say.
+func (d *Dump) MemorySize() int {
+	if d.Radio == nil {
+		return DefaultMemoryChannels
+	}
+	return CapabilitiesFor(d.Radio.Model).MemorySize()
+}
+
+func (d *Dump) Channel(number uint16) (MemoryEntry, bool) {
+	for _, m := range d.Memory {
+		if m.Number == number && m.RXFrequency != 0 {
+			return m, true
+		}
+	}
+	return MemoryEntry{}, false
+}
+
+// FreeChannels returns which of candidates have no channel in d.
+func (d *Dump) FreeChannels(candidates []int) (v []int) {
+	for _, ch := range candidates {
+		if _, ok := d.Channel(uint16(ch)); !ok {
+			v = append(v, ch)
+		}
+	}
+	return v
+}
+
+// InferMissing fills in what older dumps did not record: empty channel
+// slots are dropped, channels canonicalized and scan edges marked by their
+// number. It returns how many channels changed.
+func (d *Dump) InferMissing() (changed int) {
+	var memory []MemoryEntry
+	for _, m := range d.Memory {
+		if m.RXFrequency == 0 {
+			changed++
+			continue
+		}
+		before := m
+		m.Canonicalize()
+		if m != before {
+			changed++
+		}
+		memory = append(memory, m)
+	}
+	d.Memory = memory
+	for i := range d.Special {
+		m := &d.Special[i]
+		before := *m
+		if m.Kind == RegularChannel && m.Number >= ScanEdgeBase {
+			m.Kind = ScanEdgeChannel
+		}
+		m.Canonicalize()
+		if *m != before {
+			changed++
+		}
+	}
+	return changed
+}
+
+// PlaceImported puts channels at their own numbers, replacing what is there.
+func PlaceImported(d *Dump, channels []MemoryEntry, source string) {
+	for _, m := range channels {
+		replaced := false
+		for i := range d.Memory {
+			if d.Memory[i].Number == m.Number {
+				d.Memory[i], replaced = m, true
+			}
+		}
+		if !replaced {
+			d.Memory = append(d.Memory, m)
+		}
+		d.RecordImport(m.Number, source)
+	}
+	sort.SliceStable(d.Memory, func(i, j int) bool { return d.Memory[i].Number < d.Memory[j].Number })
+}
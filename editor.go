@@ -0,0 +1,251 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// editColumns are the sheet columns a channel is edited through, so that
+// edits parse like spreadsheet cells.
+var editColumns = []string{"rx", "offset", "tone", "ctcss", "dcs", "mode", "step", "name"}
+
+// EditFields are the fields MemoryEditor.Set takes.
+var EditFields = []string{"freq", "tx", "offset", "tone", "ctcss", "dcs", "mode", "step", "name", "lockout"}
+
+// MemoryEditor keeps edits to a copy of memory, so that only the channels
+// that changed are written back.
+type MemoryEditor struct {
+	Capabilities Capabilities
+	// Pinned channels are refused by Set and Delete.
+	Pinned   map[uint16]bool
+	original []MemoryEntry
+	working  map[uint16]MemoryEntry
+	deleted  map[uint16]MemoryEntry
+}
+
+func NewMemoryEditor(channels []MemoryEntry, c Capabilities) *MemoryEditor {
+	e := &MemoryEditor{Capabilities: c}
+	e.Reset(channels)
+	return e
+}
+
+func (e *MemoryEditor) Reset(channels []MemoryEntry) {
+	e.original = nil
+	e.working = map[uint16]MemoryEntry{}
+	e.deleted = map[uint16]MemoryEntry{}
+	for _, m := range channels {
+		if m.RXFrequency != 0 {
+			e.original = append(e.original, m)
+			e.working[m.Number] = m
+		}
+	}
+}
+
+// Channels returns the edited channels, including the ones marked for
+// deletion, by number.
+func (e *MemoryEditor) Channels() []MemoryEntry {
+	var v []MemoryEntry
+	for _, m := range e.working {
+		v = append(v, m)
+	}
+	for _, m := range e.deleted {
+		v = append(v, m)
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].Number < v[j].Number })
+	return v
+}
+
+// Memory returns the channels as they are to be written.
+func (e *MemoryEditor) Memory() []MemoryEntry {
+	var v []MemoryEntry
+	for _, m := range e.working {
+		v = append(v, m)
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].Number < v[j].Number })
+	return v
+}
+
+func (e *MemoryEditor) Channel(n uint16) (MemoryEntry, bool) {
+	m, ok := e.working[n]
+	return m, ok
+}
+
+// Status is "D" for channels marked for deletion, "M" for changed or added
+// ones and empty otherwise.
+func (e *MemoryEditor) Status(n uint16) string {
+	if _, ok := e.deleted[n]; ok {
+		return "D"
+	}
+	for _, d := range e.Changes() {
+		if d.Number == n {
+			return "M"
+		}
+	}
+	return ""
+}
+
+// Set changes one field of channel n, given as a spreadsheet cell would be.
+// Setting freq on an empty channel creates it. The change is refused when
+// it leaves the channel invalid for the radio.
+func (e *MemoryEditor) Set(n uint16, field, value string) error {
+	if last := e.Capabilities.MemorySize() - 1; int(n) > last {
+		return fmt.Errorf("error: channel %d out of 000-%03d", n, last)
+	}
+	if _, ok := e.deleted[n]; ok {
+		return fmt.Errorf("error: channel %03d is marked for deletion", n)
+	}
+	if e.Pinned[n] {
+		return fmt.Errorf("error changing channel %03d: %w", n, ErrPinned)
+	}
+	cur, ok := e.working[n]
+	if !ok && field != "freq" {
+		return fmt.Errorf("error: channel %03d is empty, set its freq first", n)
+	}
+	if !ok {
+		cur = MemoryEntry{Number: n}
+	}
+	value = strings.TrimSpace(value)
+
+	values := map[string]string{}
+	if ok {
+		for _, c := range editColumns {
+			values[SheetColumns[c].Header] = SheetColumns[c].Value(cur)
+		}
+	}
+	switch field {
+	case "tone", "ctcss", "dcs":
+		values["tone"], values["ctcss"], values["dcs"] = "", "", ""
+	case "tx":
+		values["offset"] = ""
+	case "freq":
+		// the old step may not fit, let it be picked again
+		values["step"] = ""
+	case "name":
+		if len(value) > e.Capabilities.MaxName() {
+			return fmt.Errorf("error: name %q is longer than %d characters", value, e.Capabilities.MaxName())
+		}
+	case "lockout":
+		m := cur
+		switch strings.ToLower(value) {
+		case "yes", "on", "1":
+			m.LockOut = 1
+		case "no", "off", "0", "":
+			m.LockOut = 0
+		default:
+			return fmt.Errorf("error: lockout is yes or no, not %q", value)
+		}
+		e.working[n] = m
+		return nil
+	case "offset", "mode", "step":
+	default:
+		return fmt.Errorf("error: unknown field %q, expected one of %s", field, strings.Join(EditFields, ", "))
+	}
+	values[field] = value
+
+	m, err := CSVChannel(func(f string) string { return values[f] })
+	if err != nil {
+		return err
+	}
+	m.Number, m.Kind = n, cur.Kind
+	m.ReverseEnabled, m.LockOut = cur.ReverseEnabled, cur.LockOut
+	if field != "tx" && field != "offset" {
+		m.Split, m.TXFrequency, m.TXStepSize = cur.Split, cur.TXFrequency, cur.TXStepSize
+	}
+	// keep what the cells do not show: tones set up but disabled and the
+	// offset of simplex channels
+	if m.ToneEnabled == 0 {
+		m.ToneFrequency = cur.ToneFrequency
+	}
+	if m.CTCSSEnabled == 0 {
+		m.CTCSSFrequency = cur.CTCSSFrequency
+	}
+	if m.DCSEnabled == 0 {
+		m.DCSFrequency = cur.DCSFrequency
+	}
+	if m.ShiftDirection == 0 && cur.ShiftDirection == 0 {
+		m.OffsetFrequency = cur.OffsetFrequency
+	}
+	if err := m.ValidateFor(e.Capabilities); err != nil {
+		return err
+	}
+	e.working[n] = m
+	return nil
+}
+
+// SetFields sets several fields of channel n, all or none of them.
+func (e *MemoryEditor) SetFields(n uint16, fields [][2]string) error {
+	before, had := e.working[n]
+	for _, f := range fields {
+		if err := e.Set(n, f[0], f[1]); err != nil {
+			if had {
+				e.working[n] = before
+			} else {
+				delete(e.working, n)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete marks channel n for deletion.
+func (e *MemoryEditor) Delete(n uint16) error {
+	m, ok := e.working[n]
+	if !ok {
+		return fmt.Errorf("error: channel %03d is empty", n)
+	}
+	if e.Pinned[n] {
+		return fmt.Errorf("error deleting channel %03d: %w", n, ErrPinned)
+	}
+	delete(e.working, n)
+	e.deleted[n] = m
+	return nil
+}
+
+// Undelete takes back the deletion of channel n.
+func (e *MemoryEditor) Undelete(n uint16) error {
+	m, ok := e.deleted[n]
+	if !ok {
+		return fmt.Errorf("error: channel %03d is not marked for deletion", n)
+	}
+	delete(e.deleted, n)
+	e.working[n] = m
+	return nil
+}
+
+// Changes are what has to be written to turn the original memory into the
+// edited one.
+func (e *MemoryEditor) Changes() []ChannelDiff {
+	return DiffMemory(e.original, e.Memory())
+}
+
+// Commit writes the changed channels to r and clears the deleted ones, then
+// takes the result as the new original. r.Memory is kept in step.
+func (e *MemoryEditor) Commit(r *Radio) (err error) {
+	span := r.startSpan("commit edits")
+	defer func() { r.endSpan(span, err) }()
+	changes := e.Changes()
+	if err := r.Backup(diffChannels(changes)); err != nil {
+		return err
+	}
+	for _, d := range changes {
+		if d.Kind == ChannelRemoved {
+			if err := r.ClearChannel(int(d.Number)); err != nil {
+				return err
+			}
+			if err := r.SetChannel(MemoryEntry{Number: d.Number}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := r.SetChannel(e.working[d.Number]); err != nil {
+			return err
+		}
+		if _, err := r.WriteChannel(int(d.Number)); err != nil {
+			return err
+		}
+	}
+	e.Reset(e.Memory())
+	return nil
+}
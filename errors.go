@@ -0,0 +1,58 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Protocol failures, to be tested with errors.Is.
+var (
+	ErrRadioNAK     = errors.New("radio sent ? and did not understood us")
+	ErrGarbage      = errors.New("radio reply looks like line noise")
+	ErrTimeout      = errors.New("radio did not answer in time")
+	ErrParse        = errors.New("radio reply could not be parsed")
+	ErrEmptyChannel = errors.New("channel is empty")
+)
+
+// ErrPinned is returned by operations that would change a pinned channel.
+var ErrPinned = errors.New("channel is pinned")
+
+// ErrNoSuchChannel is returned for channel numbers past the memory of the
+// model.
+var ErrNoSuchChannel = errors.New("no such memory channel")
+
+// ErrDuplicateChannel is returned for channel lists with two channels of the
+// same number.
+var ErrDuplicateChannel = errors.New("channel number used more than once")
+
+// ErrUnsupported is returned upfront for operations needing commands the
+// radio does not answer.
+var ErrUnsupported = errors.New("operation not supported by the radio")
+
+// ErrOutOfRange is returned when tuning to a frequency the radio does not
+// cover.
+var ErrOutOfRange = errors.New("frequency is out of the radio range")
+
+// ParseError is a reply that did not have the expected format. It matches
+// ErrParse.
+type ParseError struct {
+	What   string
+	Line   string
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	s := fmt.Sprintf("error parsing %s: %q", e.What, e.Line)
+	if e.Reason != "" {
+		s += ": " + e.Reason
+	}
+	return s
+}
+
+func (e *ParseError) Is(target error) bool {
+	return target == ErrParse
+}
+
+func parseError(what, line, reason string, args ...interface{}) error {
+	return &ParseError{What: what, Line: line, Reason: fmt.Sprintf(reason, args...)}
+}
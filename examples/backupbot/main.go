@@ -0,0 +1,96 @@
+// Backupbot reads the memory of a radio every interval and keeps a dump of
+// it whenever it changed, dropping all but the newest ones. A radio that is
+// off or unplugged is tried again at the next interval.
+//
+//	go run ./examples/backupbot -port /dev/ttyUSB0 -dir backups -every 1h
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+)
+
+func main() {
+	port := flag.String("port", "/dev/ttyUSB0", "serial port of the radio, or tcp://host:port")
+	baud := flag.Int("baud", 9600, "baud rate")
+	dir := flag.String("dir", "backups", "directory to keep dumps in")
+	every := flag.Duration("every", time.Hour, "time between backups")
+	keep := flag.Int("keep", 30, "dumps to keep per model")
+	flag.Parse()
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.Stamp})
+
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		log.Fatal().Err(err).Msg("creating backup directory")
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	tick := time.NewTicker(*every)
+	defer tick.Stop()
+	for {
+		if err := backup(*port, *baud, *dir, *keep); err != nil {
+			log.Error().Err(err).Msg("backup failed, trying again later")
+		}
+		select {
+		case <-sig:
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// backup saves the memory, call channels, scan edges and settings of the
+// radio on port, unless its memory is what the last dump has.
+func backup(port string, baud int, dir string, keep int) error {
+	r, err := kenwoodutil.NewRadio(port, baud, kenwoodutil.DefaultSerialMode)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := r.Identify(); err != nil {
+		return err
+	}
+	if err := r.ReadMemory(); err != nil {
+		return err
+	}
+	d := &kenwoodutil.Dump{Radio: r.Info(), Memory: r.OccupedChannels()}
+	if d.Special, err = r.ReadSpecialChannels(); err != nil {
+		return err
+	}
+	if d.Settings, err = r.ReadSettings(); err != nil {
+		return err
+	}
+
+	// the timestamp in the names sorts them oldest first
+	dumps, err := filepath.Glob(filepath.Join(dir, r.Model+"-*.json"))
+	if err != nil {
+		return err
+	}
+	if len(dumps) > 0 {
+		last, err := kenwoodutil.LoadDump(dumps[len(dumps)-1])
+		if err == nil && kenwoodutil.MemoryChecksum(last.Memory) == kenwoodutil.MemoryChecksum(d.Memory) {
+			log.Info().Str("radio model", r.Model).Str("dump", dumps[len(dumps)-1]).Msg("memory unchanged")
+			return nil
+		}
+	}
+	path := filepath.Join(dir, r.Model+"-"+time.Now().Format("20060102-150405")+".json")
+	if err := d.Save(path); err != nil {
+		return err
+	}
+	log.Info().Str("radio model", r.Model).Int("channels", len(d.Memory)).Str("dump", path).Msg("memory backed up")
+
+	for len(dumps)+1 > keep && len(dumps) > 0 {
+		if err := os.Remove(dumps[0]); err != nil {
+			return err
+		}
+		dumps = dumps[1:]
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Igate babysits a radio feeding an APRS igate: it parks a band on the APRS
+// frequency as the data band and puts it back whenever somebody turned the
+// knob or the radio was power cycled, reconnecting when the radio stops
+// answering. The health of the rig goes to a JSON file for monitoring.
+//
+//	go run ./examples/igate -port /dev/ttyUSB0 -band A -freq 144.800 -health igate.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+func main() {
+	port := flag.String("port", "/dev/ttyUSB0", "serial port of the radio, or tcp://host:port")
+	baud := flag.Int("baud", 9600, "baud rate")
+	band := flag.String("band", "A", "band to park on the APRS frequency")
+	freq := flag.String("freq", "144.800", "APRS frequency in MHz")
+	every := flag.Duration("every", time.Minute, "time between checks")
+	healthFile := flag.String("health", "", "file to write the health of the rig to after every check")
+	flag.Parse()
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.Stamp})
+
+	preset := kenwoodutil.IGateConfig{Band: *band, Frequency: *freq}
+	rules, err := preset.Rules()
+	if err != nil {
+		log.Fatal().Err(err).Msg("igate preset")
+	}
+	b, err := units.ParseBand(*band)
+	if err != nil {
+		log.Fatal().Err(err).Msg("igate preset")
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	tick := time.NewTicker(*every)
+	defer tick.Stop()
+	health := &kenwoodutil.Health{Started: time.Now()}
+	var r *kenwoodutil.Radio
+	for {
+		if r == nil {
+			if r, err = connect(*port, *baud); err != nil {
+				health.Record(nil, err)
+				log.Error().Err(err).Msg("radio not answering")
+			}
+		}
+		if r != nil {
+			for _, rule := range rules {
+				restored, err := r.Enforce(rule)
+				health.Record(restored, err)
+				if err != nil {
+					log.Error().Err(err).Msg("check failed, reconnecting")
+					r.Close()
+					r = nil
+					break
+				}
+				if len(restored) > 0 {
+					log.Warn().Strs("restored", restored).Msg("igate setup was changed, restored")
+				}
+			}
+		}
+		if r != nil {
+			if s, err := r.GetSMeter(b); err == nil {
+				health.SMeter = s
+			}
+		}
+		if *healthFile != "" {
+			if err := writeHealth(*healthFile, health); err != nil {
+				log.Error().Err(err).Msg("writing health file")
+			}
+		}
+		select {
+		case <-sig:
+			if r != nil {
+				r.Close()
+			}
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// connect opens the radio on port and identifies it.
+func connect(port string, baud int) (*kenwoodutil.Radio, error) {
+	r, err := kenwoodutil.NewRadio(port, baud, kenwoodutil.DefaultSerialMode)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Identify(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	log.Info().Str("radio model", r.Model).Msg("connected")
+	return r, nil
+}
+
+func writeHealth(path string, h *kenwoodutil.Health) error {
+	j, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return kenwoodutil.WriteFileAtomic(path, j, 0644, false)
+}
@@ -0,0 +1,98 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// MetricsExporter polls the radio and serves what it found as Prometheus
+// metrics, in the text exposition format.
+type MetricsExporter struct {
+	Radio *Radio
+
+	mu       sync.Mutex
+	samples  []SignalSample
+	polls    uint64
+	failed   uint64
+	up       bool
+	lastPoll time.Time
+}
+
+// Poll samples both bands.
+func (e *MetricsExporter) Poll() error {
+	var samples []SignalSample
+	var err error
+	for band := 0; band < 2 && err == nil; band++ {
+		var s SignalSample
+		if s, err = e.Radio.Sample(band); err == nil {
+			samples = append(samples, s)
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.polls++
+	e.lastPoll = time.Now()
+	e.up = err == nil
+	if err != nil {
+		e.failed++
+		return err
+	}
+	e.samples = samples
+	return nil
+}
+
+func (e *MetricsExporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	flag := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	metric("kenwood_up", "gauge", "Whether the last poll of the radio succeeded.")
+	fmt.Fprintf(w, "kenwood_up{model=%q} %d\n", e.Radio.Model, flag(e.up))
+	metric("kenwood_polls_total", "counter", "Polls of the radio.")
+	fmt.Fprintf(w, "kenwood_polls_total %d\n", e.polls)
+	metric("kenwood_poll_errors_total", "counter", "Polls of the radio that failed.")
+	fmt.Fprintf(w, "kenwood_poll_errors_total %d\n", e.failed)
+	if !e.lastPoll.IsZero() {
+		metric("kenwood_last_poll_timestamp_seconds", "gauge", "When the radio was last polled.")
+		fmt.Fprintf(w, "kenwood_last_poll_timestamp_seconds %d\n", e.lastPoll.Unix())
+	}
+
+	bands := func(name, help string, value func(s SignalSample) interface{}) {
+		metric(name, "gauge", help)
+		for _, s := range e.samples {
+			fmt.Fprintf(w, "%s{band=%q} %v\n", name, units.BandName(s.Band), value(s))
+		}
+	}
+	bands("kenwood_frequency_hertz", "Frequency the band is tuned to.", func(s SignalSample) interface{} { return s.Frequency })
+	bands("kenwood_smeter", "S-meter reading of the band, 0 to 5.", func(s SignalSample) interface{} { return s.SMeter })
+	bands("kenwood_squelch_open", "Whether the squelch of the band is open.", func(s SignalSample) interface{} { return flag(s.Busy) })
+
+	metric("kenwood_ptt", "gauge", "Whether kenwoodutil keyed the transmitter.")
+	fmt.Fprintf(w, "kenwood_ptt %d\n", flag(e.Radio.Transmitting()))
+
+	st := e.Radio.Stats()
+	metric("kenwood_commands_total", "counter", "Commands sent to the radio.")
+	fmt.Fprintf(w, "kenwood_commands_total %d\n", st.Commands)
+	metric("kenwood_command_errors_total", "counter", "Commands that failed, by reason.")
+	for _, c := range []struct {
+		reason string
+		n      uint64
+	}{{"timeout", st.Timeouts}, {"nak", st.NAKs}, {"garbage", st.Garbage}, {"other", st.Errors}} {
+		fmt.Fprintf(w, "kenwood_command_errors_total{reason=%q} %d\n", c.reason, c.n)
+	}
+	metric("kenwood_resyncs_total", "counter", "Times the conversation with the radio had to be resynchronized.")
+	fmt.Fprintf(w, "kenwood_resyncs_total %d\n", st.Resyncs)
+}
@@ -0,0 +1,85 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// ChannelQuery selects channels by what they are programmed with. Zero
+// fields match every channel.
+type ChannelQuery struct {
+	// Frequency matches the receive or the transmit frequency.
+	Frequency uint32
+	// Name is a shell pattern, like "*club*", matched without regard to
+	// case.
+	Name string
+	// Band is an amateur band name, like "2m" or "70cm".
+	Band string
+	// Tone matches the tone or CTCSS frequency in Hz.
+	Tone float64
+}
+
+// Validate checks the name pattern and the band name.
+func (q ChannelQuery) Validate() error {
+	if _, err := path.Match(strings.ToLower(q.Name), ""); err != nil {
+		return fmt.Errorf("error in name pattern %q: %w", q.Name, err)
+	}
+	if q.Band == "" {
+		return nil
+	}
+	var known []string
+	for _, b := range adifBands {
+		if strings.EqualFold(b.name, q.Band) {
+			return nil
+		}
+		known = append(known, b.name)
+	}
+	return fmt.Errorf("error: unknown band %q, expected one of %s", q.Band, strings.Join(known, ", "))
+}
+
+func (q ChannelQuery) Match(m MemoryEntry) bool {
+	if m.RXFrequency == 0 {
+		return false
+	}
+	if q.Frequency != 0 && m.RXFrequency != q.Frequency && m.ShiftedTX() != q.Frequency {
+		return false
+	}
+	if q.Name != "" {
+		if ok, _ := path.Match(strings.ToLower(q.Name), strings.ToLower(m.Name)); !ok {
+			return false
+		}
+	}
+	if q.Band != "" {
+		in := false
+		for _, b := range adifBands {
+			if strings.EqualFold(b.name, q.Band) && m.RXFrequency >= b.low && m.RXFrequency <= b.high {
+				in = true
+			}
+		}
+		if !in {
+			return false
+		}
+	}
+	if q.Tone != 0 {
+		tone := m.ToneEnabled == 1 && math.Abs(units.ToneHz(m.ToneFrequency)-q.Tone) < 0.05
+		ctcss := m.CTCSSEnabled == 1 && math.Abs(units.ToneHz(m.CTCSSFrequency)-q.Tone) < 0.05
+		if !tone && !ctcss {
+			return false
+		}
+	}
+	return true
+}
+
+// FindChannels returns the channels matching q.
+func FindChannels(channels []MemoryEntry, q ChannelQuery) (v []MemoryEntry) {
+	for _, m := range channels {
+		if q.Match(m) {
+			v = append(v, m)
+		}
+	}
+	return v
+}
@@ -0,0 +1,514 @@
+package kenwoodutil
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// Source gives the channels a Pipeline starts with.
+type Source interface {
+	Channels() ([]MemoryEntry, error)
+}
+
+// Transform changes the channels going through a Pipeline.
+type Transform interface {
+	Transform(channels []MemoryEntry) ([]MemoryEntry, error)
+}
+
+// Sink takes the channels coming out of a Pipeline.
+type Sink interface {
+	Put(channels []MemoryEntry) error
+}
+
+// Pipeline puts the channels of all its sources, through its transforms in
+// order, into all its sinks.
+type Pipeline struct {
+	Sources    []Source
+	Transforms []Transform
+	Sinks      []Sink
+}
+
+// Collect reads the sources and applies the transforms.
+func (p *Pipeline) Collect() ([]MemoryEntry, error) {
+	var channels []MemoryEntry
+	for _, s := range p.Sources {
+		v, err := s.Channels()
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, v...)
+	}
+	for _, t := range p.Transforms {
+		var err error
+		if channels, err = t.Transform(channels); err != nil {
+			return nil, err
+		}
+	}
+	return channels, nil
+}
+
+// Run collects the channels and puts them into the sinks.
+func (p *Pipeline) Run() ([]MemoryEntry, error) {
+	channels, err := p.Collect()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range p.Sinks {
+		if err := s.Put(channels); err != nil {
+			return channels, err
+		}
+	}
+	return channels, nil
+}
+
+// RadioSource reads the memory channels of a radio.
+type RadioSource struct{ Radio *Radio }
+
+func (s RadioSource) Channels() ([]MemoryEntry, error) {
+	if err := s.Radio.ReadMemory(); err != nil {
+		return nil, err
+	}
+	return s.Radio.OccupedChannels(), nil
+}
+
+// DumpSource reads the memory channels of a dump file, only those Only
+// selects when set.
+type DumpSource struct {
+	Path string
+	Only ChannelFilter
+}
+
+func (s DumpSource) Channels() ([]MemoryEntry, error) {
+	d, err := LoadDump(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	if s.Only != nil {
+		return s.Only.Select(d)
+	}
+	return d.Memory, nil
+}
+
+// SheetSource reads the channels of a CSV or xlsx spreadsheet file or URL,
+// like import does. Format is taken from the extension when empty.
+type SheetSource struct {
+	Path    string
+	Format  string
+	Mapping CSVMapping
+}
+
+func (s SheetSource) Channels() ([]MemoryEntry, error) {
+	format := s.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(s.Path)), ".")
+	}
+	channels, _, err := ReadSheetChannels(format, s.Path, s.Mapping, nil, false)
+	return channels, err
+}
+
+// Waypoint is a point of a route.
+type Waypoint struct {
+	Lat, Lon float64
+}
+
+// RepeaterBookSource gives the repeaters of a country within Radius km of
+// Lat, Lon, nearest first, or with a Route, within Radius km of any of its
+// waypoints, in the order they come along the route.
+type RepeaterBookSource struct {
+	Country  string
+	Lat, Lon float64
+	Route    []Waypoint
+	Radius   float64
+	// Model, when set, drops the channels it cannot transmit on
+	Model string
+}
+
+func (s RepeaterBookSource) Channels() ([]MemoryEntry, error) {
+	repeaters, err := FetchRepeaterBook(s.Country)
+	if err != nil {
+		return nil, err
+	}
+	route := s.Route
+	if len(route) == 0 {
+		route = []Waypoint{{s.Lat, s.Lon}}
+	}
+	type found struct {
+		rp       Repeater
+		waypoint int
+		distance float64
+	}
+	var near []found
+	for _, rp := range repeaters {
+		f := found{rp: rp, waypoint: -1}
+		for i, w := range route {
+			if d := rp.Distance(w.Lat, w.Lon); d <= s.Radius && (f.waypoint < 0 || d < f.distance) {
+				f.waypoint, f.distance = i, d
+			}
+		}
+		if f.waypoint >= 0 {
+			near = append(near, f)
+		}
+	}
+	sort.Slice(near, func(i, j int) bool {
+		if near[i].waypoint != near[j].waypoint {
+			return near[i].waypoint < near[j].waypoint
+		}
+		return near[i].distance < near[j].distance
+	})
+	var channels []MemoryEntry
+	for _, f := range near {
+		channels = append(channels, f.rp.MemoryEntry())
+	}
+	return Transmittable(channels, s.Model), nil
+}
+
+// FindTransform keeps the channels matching Query.
+type FindTransform struct{ Query ChannelQuery }
+
+func (t FindTransform) Transform(channels []MemoryEntry) ([]MemoryEntry, error) {
+	if err := t.Query.Validate(); err != nil {
+		return nil, err
+	}
+	return FindChannels(channels, t.Query), nil
+}
+
+// RenameTransform renames the channels whose whole name Pattern matches to
+// Replace, as the rename command does.
+type RenameTransform struct {
+	Pattern *regexp.Regexp
+	Replace string
+}
+
+func (t RenameTransform) Transform(channels []MemoryEntry) ([]MemoryEntry, error) {
+	renamed := map[uint16]string{}
+	for _, r := range RenameChannels(channels, t.Pattern, t.Replace) {
+		renamed[r.Number] = r.New
+	}
+	v := make([]MemoryEntry, len(channels))
+	for i, m := range channels {
+		if name, ok := renamed[m.Number]; ok {
+			m.Name = name
+		}
+		v[i] = m
+	}
+	return v, nil
+}
+
+// RenumberTransform numbers the channels in order with Channels, failing
+// when there are more channels than numbers.
+type RenumberTransform struct{ Channels []int }
+
+func (t RenumberTransform) Transform(channels []MemoryEntry) ([]MemoryEntry, error) {
+	if len(channels) > len(t.Channels) {
+		return nil, fmt.Errorf("error: %d channels do not fit in %d channel numbers", len(channels), len(t.Channels))
+	}
+	v := make([]MemoryEntry, len(channels))
+	for i, m := range channels {
+		m.Number = uint16(t.Channels[i])
+		v[i] = m
+	}
+	return v, nil
+}
+
+// DedupeTransform drops the channels for a repeater already given by an
+// earlier one.
+type DedupeTransform struct{}
+
+func (DedupeTransform) Transform(channels []MemoryEntry) ([]MemoryEntry, error) {
+	var v []MemoryEntry
+	for _, m := range channels {
+		duplicate := false
+		for _, kept := range v {
+			if SameRepeater(kept, m) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			v = append(v, m)
+		}
+	}
+	return v, nil
+}
+
+// ValidateTransform fails on invalid channels, or channels numbered out of
+// the memory of Model or more than once.
+type ValidateTransform struct{ Model string }
+
+func (t ValidateTransform) Transform(channels []MemoryEntry) ([]MemoryEntry, error) {
+	d := &Dump{Memory: channels}
+	if t.Model != "" {
+		d.Radio = &RadioInfo{Model: t.Model}
+	}
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// DumpSink puts the channels into a dump file, created if missing: at
+// their numbers, or with Replace instead of the memory channels there
+// were.
+type DumpSink struct {
+	Path    string
+	Replace bool
+}
+
+func (s DumpSink) Put(channels []MemoryEntry) error {
+	d, err := LoadDump(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		d, err = &Dump{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	if s.Replace {
+		d.Memory = nil
+	}
+	PlaceImported(d, channels, "pipeline")
+	if err := d.ResolveDuplicates(DuplicatesError); err != nil {
+		return err
+	}
+	return d.Save(s.Path)
+}
+
+// SheetSink writes the channels as a CSV or xlsx spreadsheet, the format
+// taken from the extension of Path.
+type SheetSink struct {
+	Path    string
+	Columns []string
+}
+
+func (s SheetSink) Put(channels []MemoryEntry) error {
+	columns := s.Columns
+	if len(columns) == 0 {
+		columns = DefaultSheetColumns
+	}
+	rows := SheetRows(channels, columns)
+	var b bytes.Buffer
+	switch ext := strings.ToLower(filepath.Ext(s.Path)); ext {
+	case ".xlsx":
+		if err := WriteXLSX(&b, rows); err != nil {
+			return err
+		}
+	case ".csv", "":
+		w := csv.NewWriter(&b)
+		w.WriteAll(rows)
+		if err := w.Error(); err != nil {
+			return fmt.Errorf("error writing spreadsheet: %w", err)
+		}
+	default:
+		return fmt.Errorf("error: unknown spreadsheet format %q, use .csv or .xlsx", ext)
+	}
+	if err := WriteFileAtomic(s.Path, b.Bytes(), 0644, false); err != nil {
+		return fmt.Errorf("error writing spreadsheet: %w", err)
+	}
+	return nil
+}
+
+// RadioSink programs the channels into a radio at their numbers, leaving
+// the other channels alone, or with Replace clearing them. With Channels
+// set, the channels must be among them and only they are cleared. Verify
+// reads the memory back after writing and fails on any difference.
+type RadioSink struct {
+	Radio    *Radio
+	Replace  bool
+	Channels []int
+	Verify   bool
+}
+
+func (s RadioSink) Put(channels []MemoryEntry) error {
+	inRange := func(n uint16) bool { return true }
+	if s.Channels != nil {
+		allowed := map[uint16]bool{}
+		for _, n := range s.Channels {
+			allowed[uint16(n)] = true
+		}
+		inRange = func(n uint16) bool { return allowed[n] }
+	}
+	for _, m := range channels {
+		if !inRange(m.Number) {
+			return fmt.Errorf("error: channel %03d is not among the channels to write", m.Number)
+		}
+	}
+	if err := s.Radio.ReadMemory(); err != nil {
+		return err
+	}
+	d := &Dump{}
+	for _, m := range s.Radio.OccupedChannels() {
+		if !s.Replace || !inRange(m.Number) {
+			d.Memory = append(d.Memory, m)
+		}
+	}
+	PlaceImported(d, channels, "pipeline")
+	if _, err := s.Radio.ApplyLayout(d.Memory); err != nil {
+		return err
+	}
+	if !s.Verify {
+		return nil
+	}
+	if err := s.Radio.ReadMemory(); err != nil {
+		return err
+	}
+	if diffs := DiffMemory(d.Memory, s.Radio.OccupedChannels()); len(diffs) > 0 {
+		return fmt.Errorf("error verifying: channels %v differ from what was written", diffChannels(diffs))
+	}
+	log.Info().Int("channels", len(d.Memory)).Msg("Radio memory verified.")
+	return nil
+}
+
+// PipelineSpec is a pipeline file, the JSON form of a Pipeline.
+type PipelineSpec struct {
+	Sources    []PipelineStep
+	Transforms []PipelineStep
+	Sinks      []PipelineStep
+}
+
+// PipelineStep describes a source, transform or sink of a pipeline file.
+// Type says which, and which of the other fields are used:
+//
+//	radio                   source, or sink with Replace, Channels and Verify
+//	dump          Path      source with Only, or sink with Replace
+//	sheet         Path      source with Format and Mapping, or sink with Columns
+//	repeaterbook  Country, Lat, Lon or Route, Radius, Model
+//	find          Query
+//	validate      Model
+//	rename        Match, To
+//	renumber      Channels, like "500-599"
+//	dedupe
+//
+// Channels of a radio sink limit the channels written, like "500-799" for
+// banks 5 to 7.
+type PipelineStep struct {
+	Type     string
+	Path     string       `json:",omitempty"`
+	Format   string       `json:",omitempty"`
+	Mapping  CSVMapping   `json:",omitempty"`
+	Columns  []string     `json:",omitempty"`
+	Replace  bool         `json:",omitempty"`
+	Verify   bool         `json:",omitempty"`
+	Only     string       `json:",omitempty"`
+	Country  string       `json:",omitempty"`
+	Lat      float64      `json:",omitempty"`
+	Lon      float64      `json:",omitempty"`
+	Route    []Waypoint   `json:",omitempty"`
+	Radius   float64      `json:",omitempty"`
+	Model    string       `json:",omitempty"`
+	Query    ChannelQuery `json:",omitempty"`
+	Match    string       `json:",omitempty"`
+	To       string       `json:",omitempty"`
+	Channels string       `json:",omitempty"`
+}
+
+// LoadPipelineSpec reads a pipeline file. It is JSON, which also makes it
+// a valid YAML file of the flow style, but block style YAML is not read.
+func LoadPipelineSpec(path string) (*PipelineSpec, error) {
+	data, err := ReadFileOrStdin(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pipeline: %w", err)
+	}
+	var s PipelineSpec
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("error parsing pipeline %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Pipeline builds the pipeline s describes. The radio steps share the
+// radio openRadio gives, opened only when s has one.
+func (s *PipelineSpec) Pipeline(openRadio func() (*Radio, error)) (*Pipeline, error) {
+	var radio *Radio
+	getRadio := func() (*Radio, error) {
+		if radio != nil {
+			return radio, nil
+		}
+		var err error
+		radio, err = openRadio()
+		return radio, err
+	}
+	p := &Pipeline{}
+	for _, st := range s.Sources {
+		switch st.Type {
+		case "radio":
+			r, err := getRadio()
+			if err != nil {
+				return nil, err
+			}
+			p.Sources = append(p.Sources, RadioSource{Radio: r})
+		case "dump":
+			src := DumpSource{Path: st.Path}
+			if st.Only != "" {
+				f, err := ParseChannelFilter(st.Only)
+				if err != nil {
+					return nil, err
+				}
+				src.Only = f
+			}
+			p.Sources = append(p.Sources, src)
+		case "sheet":
+			p.Sources = append(p.Sources, SheetSource{Path: st.Path, Format: st.Format, Mapping: st.Mapping})
+		case "repeaterbook":
+			p.Sources = append(p.Sources, RepeaterBookSource{Country: st.Country, Lat: st.Lat, Lon: st.Lon, Route: st.Route, Radius: st.Radius, Model: st.Model})
+		default:
+			return nil, fmt.Errorf("error: unknown pipeline source %q, use radio, dump, sheet or repeaterbook", st.Type)
+		}
+	}
+	for _, st := range s.Transforms {
+		switch st.Type {
+		case "find":
+			p.Transforms = append(p.Transforms, FindTransform{Query: st.Query})
+		case "rename":
+			re, err := regexp.Compile(st.Match)
+			if err != nil {
+				return nil, fmt.Errorf("error in rename pattern %q: %w", st.Match, err)
+			}
+			p.Transforms = append(p.Transforms, RenameTransform{Pattern: re, Replace: ExpandReplacement(st.To)})
+		case "renumber":
+			channels, err := units.ParseChannelList(st.Channels)
+			if err != nil {
+				return nil, err
+			}
+			p.Transforms = append(p.Transforms, RenumberTransform{Channels: channels})
+		case "dedupe":
+			p.Transforms = append(p.Transforms, DedupeTransform{})
+		case "validate":
+			p.Transforms = append(p.Transforms, ValidateTransform{Model: st.Model})
+		default:
+			return nil, fmt.Errorf("error: unknown pipeline transform %q, use find, rename, renumber, dedupe or validate", st.Type)
+		}
+	}
+	for _, st := range s.Sinks {
+		switch st.Type {
+		case "radio":
+			r, err := getRadio()
+			if err != nil {
+				return nil, err
+			}
+			sink := RadioSink{Radio: r, Replace: st.Replace, Verify: st.Verify}
+			if st.Channels != "" {
+				if sink.Channels, err = units.ParseChannelList(st.Channels); err != nil {
+					return nil, err
+				}
+			}
+			p.Sinks = append(p.Sinks, sink)
+		case "dump":
+			p.Sinks = append(p.Sinks, DumpSink{Path: st.Path, Replace: st.Replace})
+		case "sheet":
+			p.Sinks = append(p.Sinks, SheetSink{Path: st.Path, Columns: st.Columns})
+		default:
+			return nil, fmt.Errorf("error: unknown pipeline sink %q, use radio, dump or sheet", st.Type)
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,163 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// GPSFix is a position as reported by the GPS receiver attached to the
+// radio. Speed is in km/h, Course and Altitude in degrees and metres.
+type GPSFix struct {
+	Time       time.Time
+	Valid      bool
+	Latitude   float64
+	Longitude  float64
+	Altitude   float64 `json:",omitempty"`
+	Speed      float64 `json:",omitempty"`
+	Course     float64 `json:",omitempty"`
+	Satellites int     `json:",omitempty"`
+}
+
+// Update brings f up to date with an NMEA sentence. RMC sentences, which
+// GPS receivers send once per fix, complete it; GGA ones add altitude and
+// satellites and other sentences are ignored.
+func (f *GPSFix) Update(sentence string) (complete bool, err error) {
+	sentence = strings.TrimSpace(sentence)
+	body := strings.TrimPrefix(sentence, "$")
+	if body == sentence {
+		return false, parseError("NMEA sentence", sentence, "expected $")
+	}
+	if i := strings.LastIndexByte(body, '*'); i >= 0 {
+		want, err := strconv.ParseUint(body[i+1:], 16, 8)
+		if err != nil {
+			return false, parseError("NMEA sentence", sentence, "bad checksum")
+		}
+		var sum byte
+		for _, c := range []byte(body[:i]) {
+			sum ^= c
+		}
+		if uint64(sum) != want {
+			return false, parseError("NMEA sentence", sentence, "checksum %02X does not match %02X", sum, want)
+		}
+		body = body[:i]
+	}
+	fields := strings.Split(body, ",")
+	field := func(i int) string {
+		if i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+	if len(fields[0]) != 5 {
+		return false, nil
+	}
+	switch fields[0][2:] {
+	case "RMC":
+		date, err := time.Parse("020106150405", field(9)+nmeaTime(field(1)))
+		if err != nil {
+			return false, parseError("NMEA sentence", sentence, "bad date or time")
+		}
+		f.Time = date.Add(nmeaFraction(field(1)))
+		f.Valid = field(2) == "A"
+		if f.Latitude, err = nmeaDegrees(field(3), field(4), "S"); err != nil {
+			return false, parseError("NMEA sentence", sentence, "%v", err)
+		}
+		if f.Longitude, err = nmeaDegrees(field(5), field(6), "W"); err != nil {
+			return false, parseError("NMEA sentence", sentence, "%v", err)
+		}
+		knots, _ := strconv.ParseFloat(field(7), 64)
+		f.Speed = knots * 1.852
+		f.Course, _ = strconv.ParseFloat(field(8), 64)
+		return true, nil
+	case "GGA":
+		f.Satellites, _ = strconv.Atoi(field(7))
+		f.Altitude, _ = strconv.ParseFloat(field(9), 64)
+	}
+	return false, nil
+}
+
+// nmeaTime drops the fraction of a hhmmss.ss time.
+func nmeaTime(s string) string {
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func nmeaFraction(s string) time.Duration {
+	i := strings.IndexByte(s, '.')
+	if i < 0 {
+		return 0
+	}
+	v, _ := strconv.ParseFloat("0"+s[i:], 64)
+	return time.Duration(v * float64(time.Second))
+}
+
+// nmeaDegrees converts a (d)ddmm.mmmm coordinate, negative on the
+// negative hemisphere.
+func nmeaDegrees(s, hemisphere, negative string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad coordinate %q", s)
+	}
+	deg := math.Floor(v/100) + math.Mod(v, 100)/60
+	if hemisphere == negative {
+		deg = -deg
+	}
+	return deg, nil
+}
+
+func (r *Radio) checkGPS() error {
+	if !r.Capabilities().HasGPS {
+		return fmt.Errorf("error: %s does not pass GPS data through", r.Model)
+	}
+	return nil
+}
+
+// ReadGPS reads the NMEA sentences the radio passes through from its GPS
+// receiver once its PC port is set to output GPS data. fn gets every
+// sentence and the fix as of it, complete when the sentence ended one, and
+// stops reading by returning false. Other commands wait meanwhile.
+func (r *Radio) ReadGPS(fn func(sentence string, fix GPSFix, complete bool) bool) error {
+	if err := r.checkGPS(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var fix GPSFix
+	var partial string
+	for {
+		// read straight from the port, ReadString drops what came before a
+		// timeout
+		line, err := r.PortRW.ReadString('\n')
+		if errors.Is(err, ErrTimeout) {
+			// a GPS sends once a second, a quiet port is waited through
+			partial += line
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error reading GPS data: %w", err)
+		}
+		line, partial = strings.TrimSpace(partial+line), ""
+		if !strings.HasPrefix(line, "$") {
+			continue
+		}
+		complete, err := fix.Update(line)
+		if err != nil {
+			log.Debug().Err(err).Msg("GPS sentence skipped")
+			continue
+		}
+		if !fn(line, fix, complete) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package kenwoodutil
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// DefaultGPSD is where gpsd listens by default.
+const DefaultGPSD = "localhost:2947"
+
+const gpsdWatch = `?WATCH={"enable":true,"json":true};` + "\n"
+
+// gpsdTPV is the part of a gpsd time-position-velocity report used.
+type gpsdTPV struct {
+	Class string
+	Mode  int
+	Time  time.Time
+	Lat   float64
+	Lon   float64
+	Alt   float64
+	Speed float64
+	Track float64
+}
+
+// ReadGPSD calls fn with every fix gpsd at addr reports until fn returns
+// false.
+func ReadGPSD(addr string, fn func(fix GPSFix) bool) error {
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("error connecting to gpsd: %w", err)
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, gpsdWatch); err != nil {
+		return fmt.Errorf("error starting gpsd watch: %w", err)
+	}
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		var tpv gpsdTPV
+		if json.Unmarshal(sc.Bytes(), &tpv) != nil || tpv.Class != "TPV" {
+			continue
+		}
+		// mode 2 and 3 are 2D and 3D fixes
+		fix := GPSFix{Time: tpv.Time, Valid: tpv.Mode >= 2, Latitude: tpv.Lat, Longitude: tpv.Lon, Altitude: tpv.Alt, Speed: tpv.Speed * 3.6, Course: tpv.Track}
+		if !fn(fix) {
+			return nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("error reading from gpsd: %w", err)
+	}
+	return errors.New("error: gpsd closed the connection")
+}
+
+// ReadTrack reads fixes written one JSON object per line, as gps -format
+// json prints them.
+func ReadTrack(r io.Reader) ([]GPSFix, error) {
+	var v []GPSFix
+	dec := json.NewDecoder(r)
+	for {
+		var fix GPSFix
+		err := dec.Decode(&fix)
+		if err == io.EOF {
+			return v, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading track: %w", err)
+		}
+		if fix.Valid {
+			v = append(v, fix)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MemoryGroup names a range of channels, the way the radios show memory in
+// groups on the display.
+type MemoryGroup struct {
+	Name        string
+	First, Last uint16
+}
+
+func (g MemoryGroup) Contains(n uint16) bool {
+	return n >= g.First && n <= g.Last
+}
+
+func (g MemoryGroup) String() string {
+	return fmt.Sprintf("%s (%03d-%03d)", g.Name, g.First, g.Last)
+}
+
+// Group returns the group called name, ignoring case.
+func (d *Dump) Group(name string) (MemoryGroup, error) {
+	for _, g := range d.Groups {
+		if strings.EqualFold(g.Name, name) {
+			return g, nil
+		}
+	}
+	return MemoryGroup{}, fmt.Errorf("error: no group %q in dump", name)
+}
+
+// AddGroup adds a group. Groups may not share names or channels.
+func (d *Dump) AddGroup(g MemoryGroup) error {
+	if g.Name == "" {
+		return fmt.Errorf("error: group needs a name")
+	}
+	if last := d.MemorySize() - 1; g.First > g.Last || int(g.Last) > last {
+		return fmt.Errorf("error: group %s is not a range of channels 000-%03d", g, last)
+	}
+	for _, o := range d.Groups {
+		if strings.EqualFold(o.Name, g.Name) {
+			return fmt.Errorf("error: group %q already exists", g.Name)
+		}
+		if g.First <= o.Last && o.First <= g.Last {
+			return fmt.Errorf("error: group %s overlaps %s", g, o)
+		}
+	}
+	d.Groups = append(d.Groups, g)
+	return nil
+}
+
+func (d *Dump) RemoveGroup(name string) error {
+	for i, g := range d.Groups {
+		if strings.EqualFold(g.Name, name) {
+			d.Groups = append(d.Groups[:i], d.Groups[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("error: no group %q in dump", name)
+}
+
+// GroupChannels returns the channels of the dump in g.
+func (d *Dump) GroupChannels(g MemoryGroup) (v []MemoryEntry) {
+	for _, m := range d.Memory {
+		if g.Contains(m.Number) && m.RXFrequency != 0 {
+			v = append(v, m)
+		}
+	}
+	return v
+}
+
+// ReplaceGroup replaces the channels of g with channels, trashing the ones
+// that are gone. Changes to pinned channels are refused unless force is set.
+func (d *Dump) ReplaceGroup(g MemoryGroup, channels []MemoryEntry, reason string, force bool) (trashed int, err error) {
+	var memory []MemoryEntry
+	for _, m := range d.Memory {
+		if !g.Contains(m.Number) {
+			memory = append(memory, m)
+		}
+	}
+	if !force {
+		var changed []uint16
+		for _, c := range DiffMemory(d.GroupChannels(g), channels) {
+			changed = append(changed, c.Number)
+		}
+		if err := d.CheckPinned(changed); err != nil {
+			return 0, err
+		}
+	}
+	for _, m := range channels {
+		if !g.Contains(m.Number) {
+			return 0, fmt.Errorf("error: channel %03d is not in group %s", m.Number, g)
+		}
+		memory = append(memory, m)
+	}
+	sort.Slice(memory, func(i, j int) bool { return memory[i].Number < memory[j].Number })
+	return d.ReplaceMemory(memory, reason), nil
+}
+
+// ReadGroup reads the channels of g from the radio into r.Memory, returning
+// the occupied ones.
+func (r *Radio) ReadGroup(g MemoryGroup) (v []MemoryEntry, err error) {
+	span := r.startSpan("read group", "group", g.Name)
+	defer func() { r.endSpan(span, err) }()
+	defer r.reindex()
+	for n := int(g.First); n <= int(g.Last); n++ {
+		if err := r.CheckChannel(n); err != nil {
+			return nil, err
+		}
+		if r.Memory[n], err = r.readChannelRetrying(n); err != nil {
+			return nil, fmt.Errorf("error reading group %s: %w", g.Name, err)
+		}
+		if r.Memory[n].RXFrequency != 0 {
+			v = append(v, r.Memory[n])
+		}
+	}
+	return v, nil
+}
+
+// WriteGroup makes the channels of g on the radio what channels are,
+// writing the ones that differ and clearing the ones left empty. The rest of
+// memory is not touched.
+func (r *Radio) WriteGroup(g MemoryGroup, channels []MemoryEntry) (s WriteSummary, err error) {
+	for _, m := range channels {
+		if !g.Contains(m.Number) {
+			return s, fmt.Errorf("error: channel %03d is not in group %s", m.Number, g)
+		}
+	}
+	if _, err := r.ReadGroup(g); err != nil {
+		return s, err
+	}
+	// channels outside the group were not read and are left empty on both
+	// sides, so ApplyLayout leaves them alone
+	for i := range r.Memory {
+		if !g.Contains(uint16(i)) {
+			r.Memory[i] = MemoryEntry{}
+		}
+	}
+	r.reindex()
+	return r.ApplyLayout(channels)
+}
@@ -0,0 +1,26 @@
+package kenwoodutil
+
+import "time"
+
+// Health counts the outcome of periodic checks done by long running modes.
+type Health struct {
+	Started   time.Time
+	LastCheck time.Time
+	Checks    int
+	Restores  int
+	Errors    int
+	LastError string `json:",omitempty"`
+	SMeter    int
+}
+
+func (h *Health) Record(restored []string, err error) {
+	h.LastCheck = time.Now()
+	h.Checks++
+	if err != nil {
+		h.Errors++
+		h.LastError = err.Error()
+	}
+	if len(restored) > 0 {
+		h.Restores++
+	}
+}
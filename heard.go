@@ -0,0 +1,140 @@
+package kenwoodutil
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// HeardPoint is a reception placed where it was heard.
+type HeardPoint struct {
+	Hit
+	Latitude, Longitude float64
+}
+
+// LocateHits places every hit at the fix of track nearest to its start,
+// leaving out hits with no fix within maxGap, like while the receiver had
+// no view of the sky.
+func LocateHits(hits []Hit, track []GPSFix, maxGap time.Duration) []HeardPoint {
+	track = append([]GPSFix(nil), track...)
+	sort.Slice(track, func(i, j int) bool { return track[i].Time.Before(track[j].Time) })
+	var v []HeardPoint
+	for _, h := range hits {
+		i := sort.Search(len(track), func(i int) bool { return !track[i].Time.Before(h.Start) })
+		best, gap := -1, maxGap
+		for _, j := range []int{i - 1, i} {
+			if j < 0 || j >= len(track) {
+				continue
+			}
+			d := track[j].Time.Sub(h.Start)
+			if d < 0 {
+				d = -d
+			}
+			if d <= gap {
+				best, gap = j, d
+			}
+		}
+		if best >= 0 {
+			v = append(v, HeardPoint{Hit: h, Latitude: track[best].Latitude, Longitude: track[best].Longitude})
+		}
+	}
+	return v
+}
+
+func (p HeardPoint) label() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	if p.Channel != "" {
+		return p.Channel
+	}
+	return units.FormatMHz(p.Frequency)
+}
+
+func (p HeardPoint) description() string {
+	return fmt.Sprintf("%s MHz %s, %s, %s", units.FormatMHz(p.Frequency), units.ModeName(p.Modulation), p.Report(), p.End.Sub(p.Start))
+}
+
+type kmlPlacemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	When        string `xml:"TimeStamp>when"`
+	Coordinates string `xml:"Point>coordinates"`
+}
+
+// WriteHeardKML writes points as KML placemarks, to be opened in Google
+// Earth or most map apps.
+func WriteHeardKML(w io.Writer, points []HeardPoint) error {
+	doc := struct {
+		XMLName    xml.Name       `xml:"kml"`
+		NS         string         `xml:"xmlns,attr"`
+		Name       string         `xml:"Document>name"`
+		Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+	}{NS: "http://www.opengis.net/kml/2.2", Name: "kenwoodutil receptions"}
+	for _, p := range points {
+		doc.Placemarks = append(doc.Placemarks, kmlPlacemark{
+			Name:        p.label(),
+			Description: p.description(),
+			When:        p.Start.UTC().Format(time.RFC3339),
+			Coordinates: fmt.Sprintf("%f,%f", p.Longitude, p.Latitude),
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("error writing KML: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteHeardGeoJSON writes points as a GeoJSON feature collection, with
+// the hits as feature properties.
+func WriteHeardGeoJSON(w io.Writer, points []HeardPoint) error {
+	features := []geoFeature{}
+	for _, p := range points {
+		features = append(features, geoPoint(p.Latitude, p.Longitude, map[string]interface{}{
+			"name":      p.label(),
+			"frequency": units.FormatMHz(p.Frequency),
+			"mode":      units.ModeName(p.Modulation),
+			"channel":   p.Channel,
+			"report":    p.Report(),
+			"start":     p.Start.UTC().Format(time.RFC3339),
+			"end":       p.End.UTC().Format(time.RFC3339),
+		}))
+	}
+	return writeFeatureCollection(w, features)
+}
+
+type geoFeature struct {
+	Type     string `json:"type"`
+	Geometry struct {
+		Type        string     `json:"type"`
+		Coordinates [2]float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+func geoPoint(lat, lon float64, properties map[string]interface{}) geoFeature {
+	f := geoFeature{Type: "Feature", Properties: properties}
+	f.Geometry.Type = "Point"
+	f.Geometry.Coordinates = [2]float64{lon, lat}
+	return f
+}
+
+func writeFeatureCollection(w io.Writer, features []geoFeature) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(map[string]interface{}{"type": "FeatureCollection", "features": features}); err != nil {
+		return fmt.Errorf("error writing GeoJSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package kenwoodutil
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// ReadHitLog reads hits from a CSV reception log written by monitor or
+// survey.
+func ReadHitLog(r io.Reader) ([]Hit, error) {
+	cr := csv.NewReader(r)
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading reception log: %w", err)
+	}
+	if len(rows) == 0 || strings.Join(rows[0], ",") != strings.Join(HitHeader, ",") {
+		return nil, fmt.Errorf("error: not a CSV reception log, expected a %s header", strings.Join(HitHeader, ","))
+	}
+	var hits []Hit
+	for i, row := range rows[1:] {
+		h, err := parseHit(row)
+		if err != nil {
+			return nil, fmt.Errorf("error in reception log line %d: %w", i+2, err)
+		}
+		hits = append(hits, h)
+	}
+	return hits, nil
+}
+
+func parseHit(row []string) (h Hit, err error) {
+	if len(row) < len(HitHeader) {
+		return h, fmt.Errorf("error: %d fields, expected %d", len(row), len(HitHeader))
+	}
+	if h.Start, err = time.Parse(time.RFC3339, row[0]); err != nil {
+		return h, err
+	}
+	if h.End, err = time.Parse(time.RFC3339, row[1]); err != nil {
+		return h, err
+	}
+	if h.Frequency, err = units.ParseMHz(row[2]); err != nil {
+		return h, err
+	}
+	if h.Modulation, err = units.ParseMode(row[3]); err != nil {
+		return h, err
+	}
+	h.Channel, h.Name = row[4], row[5]
+	if rs, err := strconv.Atoi(row[6]); err == nil && rs%10 > 0 {
+		h.PeakSMeter = (rs%10 - 1) * sMeterMax / 8
+	}
+	return h, nil
+}
+
+// ActivityHistogram is how busy each frequency was, by hour of the day or
+// by day.
+type ActivityHistogram struct {
+	Buckets     []string
+	Frequencies []uint32
+	// Hits and Busy are by frequency, then by bucket.
+	Hits map[uint32][]int
+	Busy map[uint32][]time.Duration
+}
+
+// NewActivityHistogram sorts hits into local hours of the day, or into days
+// when daily is set. A hit counts in the bucket it started in.
+func NewActivityHistogram(hits []Hit, daily bool) *ActivityHistogram {
+	a := &ActivityHistogram{Hits: map[uint32][]int{}, Busy: map[uint32][]time.Duration{}}
+	bucket := map[string]int{}
+	if !daily {
+		for h := 0; h < 24; h++ {
+			bucket[fmt.Sprintf("%02d", h)] = h
+			a.Buckets = append(a.Buckets, fmt.Sprintf("%02d", h))
+		}
+	} else {
+		for _, h := range hits {
+			day := h.Start.Local().Format("2006-01-02")
+			if _, ok := bucket[day]; !ok {
+				bucket[day] = 0
+				a.Buckets = append(a.Buckets, day)
+			}
+		}
+		sort.Strings(a.Buckets)
+		for i, day := range a.Buckets {
+			bucket[day] = i
+		}
+	}
+	for _, h := range hits {
+		key := h.Start.Local().Format("15")
+		if daily {
+			key = h.Start.Local().Format("2006-01-02")
+		}
+		if _, ok := a.Hits[h.Frequency]; !ok {
+			a.Frequencies = append(a.Frequencies, h.Frequency)
+			a.Hits[h.Frequency] = make([]int, len(a.Buckets))
+			a.Busy[h.Frequency] = make([]time.Duration, len(a.Buckets))
+		}
+		i := bucket[key]
+		a.Hits[h.Frequency][i]++
+		a.Busy[h.Frequency][i] += h.End.Sub(h.Start)
+	}
+	sort.Slice(a.Frequencies, func(i, j int) bool { return a.Frequencies[i] < a.Frequencies[j] })
+	return a
+}
+
+// TotalBusy is how long hz was busy over all buckets.
+func (a *ActivityHistogram) TotalBusy(hz uint32) (d time.Duration) {
+	for _, b := range a.Busy[hz] {
+		d += b
+	}
+	return d
+}
+
+// Rows lays the histogram out as CSV rows, one per frequency and bucket
+// with activity, with a header row first.
+func (a *ActivityHistogram) Rows() [][]string {
+	rows := [][]string{{"frequency", "bucket", "hits", "busy_seconds"}}
+	for _, hz := range a.Frequencies {
+		for i, b := range a.Buckets {
+			if n := a.Hits[hz][i]; n > 0 {
+				rows = append(rows, []string{units.FormatMHz(hz), b, strconv.Itoa(n), strconv.FormatFloat(a.Busy[hz][i].Seconds(), 'f', 0, 64)})
+			}
+		}
+	}
+	return rows
+}
+
+var chartLevels = []rune(" ▁▂▃▄▅▆▇█")
+
+// Chart draws a line per frequency, quietest first, with a bar per bucket
+// scaled to the busiest bucket of all.
+func (a *ActivityHistogram) Chart(w io.Writer) {
+	var most time.Duration
+	for _, hz := range a.Frequencies {
+		for _, b := range a.Busy[hz] {
+			if b > most {
+				most = b
+			}
+		}
+	}
+	freqs := append([]uint32(nil), a.Frequencies...)
+	sort.SliceStable(freqs, func(i, j int) bool { return a.TotalBusy(freqs[i]) < a.TotalBusy(freqs[j]) })
+	fmt.Fprintf(w, "%-12s %s  busy\n", "MHz", strings.Join(a.Buckets, "|"))
+	for _, hz := range freqs {
+		var line strings.Builder
+		for i, b := range a.Busy[hz] {
+			if i > 0 {
+				line.WriteRune(' ')
+			}
+			level := 0
+			if most > 0 {
+				level = int(b * time.Duration(len(chartLevels)-1) / most)
+			}
+			if level == 0 && a.Hits[hz][i] > 0 {
+				level = 1
+			}
+			cell := strings.Repeat(string(chartLevels[level]), len(a.Buckets[i]))
+			line.WriteString(cell)
+		}
+		fmt.Fprintf(w, "%-12s %s  %s\n", units.FormatMHz(hz), line.String(), a.TotalBusy(hz).Round(time.Second))
+	}
+}
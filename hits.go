@@ -0,0 +1,128 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// Hit is one reception heard while monitoring: the squelch opened on a
+// frequency and closed again, or the radio was retuned.
+type Hit struct {
+	Start      time.Time
+	End        time.Time
+	Frequency  uint32
+	Modulation uint8
+	Channel    string `json:",omitempty"`
+	Name       string `json:",omitempty"`
+	PeakSMeter int
+}
+
+// HitTracker turns signal samples into hits.
+type HitTracker struct {
+	open *Hit
+}
+
+// Add feeds a sample and returns the hit it ended, if any.
+func (t *HitTracker) Add(s SignalSample) (done *Hit) {
+	if t.open != nil && (!s.Busy || t.open.Frequency != s.Frequency) {
+		done = t.Close(s.Time)
+	}
+	if !s.Busy {
+		return done
+	}
+	if t.open == nil {
+		t.open = &Hit{Start: s.Time, Frequency: s.Frequency, Modulation: s.Modulation}
+		if s.Mode == MemoryMode {
+			t.open.Channel = fmt.Sprintf("%03d", s.Channel)
+			t.open.Name = s.Name
+		}
+	}
+	t.open.End = s.Time
+	if s.SMeter > t.open.PeakSMeter {
+		t.open.PeakSMeter = s.SMeter
+	}
+	return done
+}
+
+// Close ends the hit in progress at end, returning it.
+func (t *HitTracker) Close(end time.Time) *Hit {
+	h := t.open
+	if h != nil {
+		h.End = end
+	}
+	t.open = nil
+	return h
+}
+
+// Report is the signal report of the hit in RS form, the S-meter bars
+// scaled to S1-S9 with readability assumed perfect.
+func (h Hit) Report() string {
+	n := h.PeakSMeter
+	if n > sMeterMax {
+		n = sMeterMax
+	}
+	return "5" + strconv.Itoa(1+n*8/sMeterMax)
+}
+
+var HitHeader = []string{"start", "end", "frequency", "mode", "channel", "name", "report"}
+
+func (h Hit) CSV() []string {
+	return []string{h.Start.Format(time.RFC3339), h.End.Format(time.RFC3339), units.FormatMHz(h.Frequency), units.ModeName(h.Modulation), h.Channel, h.Name, h.Report()}
+}
+
+var adifBands = []struct {
+	name      string
+	low, high uint32
+}{
+	{"6m", 50000000, 54000000},
+	{"4m", 70000000, 71000000},
+	{"2m", 144000000, 148000000},
+	{"1.25m", 222000000, 225000000},
+	{"70cm", 420000000, 450000000},
+	{"33cm", 902000000, 928000000},
+	{"23cm", 1240000000, 1300000000},
+}
+
+// ADIFHeader starts an ADIF file of hits.
+const ADIFHeader = "kenwoodutil monitor log\n<ADIF_VER:5>3.1.4 <PROGRAMID:11>kenwoodutil <EOH>\n"
+
+func adifField(b *strings.Builder, name, value string) {
+	if value != "" {
+		fmt.Fprintf(b, "<%s:%d>%s ", name, len(value), value)
+	}
+}
+
+// ADIF formats the hit as a short wave listener record. There is no
+// callsign, the channel name goes to the comment.
+func (h Hit) ADIF() string {
+	var b strings.Builder
+	start, end := h.Start.UTC(), h.End.UTC()
+	adifField(&b, "QSO_DATE", start.Format("20060102"))
+	adifField(&b, "TIME_ON", start.Format("150405"))
+	adifField(&b, "QSO_DATE_OFF", end.Format("20060102"))
+	adifField(&b, "TIME_OFF", end.Format("150405"))
+	adifField(&b, "FREQ", strconv.FormatFloat(float64(h.Frequency)/1e6, 'f', 6, 64))
+	for _, band := range adifBands {
+		if h.Frequency >= band.low && h.Frequency <= band.high {
+			adifField(&b, "BAND", band.name)
+		}
+	}
+	mode := units.ModeName(h.Modulation)
+	if mode == "NFM" {
+		mode = "FM"
+	}
+	adifField(&b, "MODE", mode)
+	adifField(&b, "RST_RCVD", h.Report())
+	adifField(&b, "SWL", "Y")
+	comment := h.Name
+	if h.Channel != "" {
+		comment = strings.TrimSpace("ch " + h.Channel + " " + h.Name)
+	}
+	adifField(&b, "COMMENT", comment)
+	b.WriteString("<EOR>\n")
+	return b.String()
+}
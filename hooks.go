@@ -0,0 +1,111 @@
+package kenwoodutil
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Kinds of ProtocolEvent.
+const (
+	EventCommand = "command"
+	EventRetry   = "retry"
+	EventResync  = "resync"
+)
+
+// ProtocolEvent is something that happened in the conversation with a
+// radio: a command and its reply, a command retried after it failed, or an
+// attempt to get back in step with the radio. Latency, from sending the
+// command to its reply, is in nanoseconds in JSON.
+type ProtocolEvent struct {
+	Time    time.Time
+	Kind    string
+	Command string        `json:",omitempty"`
+	Reply   string        `json:",omitempty"`
+	Latency time.Duration `json:",omitempty"`
+	Attempt int           `json:",omitempty"`
+	Error   string        `json:",omitempty"`
+}
+
+// ProtocolHook gets the protocol events of a radio it is attached to, see
+// Radio.Hooks. Event is called with the command queue of the radio held, so
+// it should not block nor send commands itself.
+type ProtocolHook interface {
+	Event(e ProtocolEvent)
+}
+
+// ProtocolHookFunc makes a function a ProtocolHook.
+type ProtocolHookFunc func(e ProtocolEvent)
+
+func (f ProtocolHookFunc) Event(e ProtocolEvent) { f(e) }
+
+// emit passes an event to the hooks of r.
+func (r *Radio) emit(kind, command, reply string, start time.Time, attempt int, err error) {
+	if len(r.Hooks) == 0 {
+		return
+	}
+	e := ProtocolEvent{Time: start.UTC(), Kind: kind, Command: command, Reply: reply, Attempt: attempt}
+	if kind == EventCommand {
+		e.Latency = time.Since(start)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	for _, h := range r.Hooks {
+		h.Event(e)
+	}
+}
+
+// LogHook logs protocol events at debug level.
+type LogHook struct {
+	Logger zerolog.Logger
+}
+
+// NewLogHook returns a LogHook writing to the global logger.
+func NewLogHook() LogHook {
+	return LogHook{Logger: log.Logger}
+}
+
+func (h LogHook) Event(e ProtocolEvent) {
+	ev := h.Logger.Debug().Str("kind", e.Kind)
+	if e.Command != "" {
+		ev = ev.Str("send", e.Command)
+	}
+	if e.Reply != "" {
+		ev = ev.Str("recv", e.Reply)
+	}
+	if e.Latency != 0 {
+		ev = ev.Dur("latency", e.Latency)
+	}
+	if e.Attempt != 0 {
+		ev = ev.Int("attempt", e.Attempt)
+	}
+	if e.Error != "" {
+		ev = ev.Str("error", e.Error)
+	}
+	ev.Msg("serial")
+}
+
+// TraceWriter writes protocol events as JSON, one object per line.
+type TraceWriter struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	failed bool
+}
+
+func NewTraceWriter(w io.Writer) *TraceWriter {
+	return &TraceWriter{enc: json.NewEncoder(w)}
+}
+
+func (t *TraceWriter) Event(e ProtocolEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.enc.Encode(e); err != nil && !t.failed {
+		t.failed = true
+		log.Warn().Err(err).Msg("protocol trace failed, the trace file is incomplete")
+	}
+}
@@ -0,0 +1,67 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// IGateConfig is the single config block of the igate preset: a band parked
+// on the APRS frequency and used as the data band, kept there by the
+// watchdog.
+type IGateConfig struct {
+	Band       string            `json:",omitempty"`
+	Frequency  string            `json:",omitempty"`
+	DataSpeed  string            `json:",omitempty"`
+	Settings   map[string]string `json:",omitempty"`
+	APRS       *APRSConfig       `json:",omitempty"`
+	Interval   Duration          `json:",omitempty"`
+	HealthFile string            `json:",omitempty"`
+}
+
+const (
+	defaultIGateBand      = "A"
+	defaultIGateFrequency = "144.800"
+	defaultIGateInterval  = time.Minute
+)
+
+// Rules turns the preset into watchdog rules.
+func (c IGateConfig) Rules() ([]WatchdogRule, error) {
+	if c.Band == "" {
+		c.Band = defaultIGateBand
+	}
+	if c.Frequency == "" {
+		c.Frequency = defaultIGateFrequency
+	}
+	band, err := units.ParseBand(c.Band)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := units.ParseMHz(c.Frequency); err != nil {
+		return nil, err
+	}
+
+	settings := map[string]string{
+		"MU.ext_data_band": fmt.Sprint(band),
+	}
+	if c.DataSpeed != "" {
+		settings["MU.ext_data_speed"] = c.DataSpeed
+	}
+	for k, v := range c.Settings {
+		settings[k] = v
+	}
+	return []WatchdogRule{{
+		Name:      "igate",
+		Band:      c.Band,
+		Frequency: c.Frequency,
+		Settings:  settings,
+	}}, nil
+}
+
+func (c IGateConfig) CheckInterval() time.Duration {
+	if c.Interval <= 0 {
+		return defaultIGateInterval
+	}
+	return time.Duration(c.Interval)
+}
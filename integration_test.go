@@ -0,0 +1,585 @@
+//go:build integration
+// +build integration
+
+package kenwoodutil
+
+// The integration tests run the kenwoodutil binary against a simulated
+// radio on the far end of a pty pair made by socat, so that flags, serial
+// port handling and framing are covered as well. Run them with
+//
+//	go test -tags integration -run Integration .
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// simRadio answers the PC protocol the way a TM-D710 does, from memory.
+type simRadio struct {
+	mu     sync.Mutex
+	model  string
+	memory map[int]string
+	names  map[int]string
+	vfo    [2]string
+	mode   [2]int
+	mc     [2]int
+	bc     [2]int
+	tx     bool
+	// rejectRX answers RX with ? and stays keyed
+	rejectRX bool
+	// echo sends every command back as it comes in, ahead of the replies
+	// like an interface echoing what it transmits
+	echo bool
+}
+
+func newSimRadio() *simRadio {
+	return &simRadio{
+		model: "TM-D710",
+		memory: map[int]string{
+			1:  "ME 001,0145500000,0,0,0,0,0,0,08,08,000,00600000,0,0000000000,0,0",
+			12: "ME 012,0439150000,4,2,0,1,0,0,12,08,000,07600000,0,0000000000,0,0",
+		},
+		names: map[int]string{1: "MN 001,CALL", 12: "MN 012,CLUB"},
+		vfo: [2]string{
+			"FO 0,0145000000,0,0,0,0,0,0,08,08,000,00600000,0",
+			"FO 1,0433000000,4,0,0,0,0,0,08,08,000,05000000,0",
+		},
+	}
+}
+
+// reply answers one command, without its carriage return.
+func (s *simRadio) reply(cmd string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mnemonic, args := CommandMnemonic(cmd), ""
+	if i := strings.IndexByte(cmd, ' '); i >= 0 {
+		args = cmd[i+1:]
+	}
+	fields := strings.Split(args, ",")
+	n, err := strconv.Atoi(fields[0])
+	if args != "" && err != nil {
+		return "?"
+	}
+	band := n & 1
+	switch {
+	case mnemonic == "ID":
+		return "ID " + s.model
+	case mnemonic == "AE":
+		return "AE B1234567,K"
+	case mnemonic == "FV":
+		return "FV 0,1.00,2.10,A,1"
+	case mnemonic == "TN" && args == "":
+		return fmt.Sprintf(TNFormat, TNCAPRS, 0)
+	case mnemonic == "ME" && len(fields) == 1:
+		if line, ok := s.memory[n]; ok {
+			return line
+		}
+		return "N"
+	case mnemonic == "ME" && len(fields) == 2 && fields[1] == "C":
+		delete(s.memory, n)
+		delete(s.names, n)
+		return cmd
+	case mnemonic == "ME":
+		s.memory[n] = cmd
+		return cmd
+	case mnemonic == "MN" && len(fields) == 1:
+		if _, ok := s.memory[n]; !ok {
+			return "N"
+		}
+		if line, ok := s.names[n]; ok {
+			return line
+		}
+		return fmt.Sprintf("MN %03d,", n)
+	case mnemonic == "MN":
+		s.names[n] = cmd
+		return cmd
+	case mnemonic == "FO" && len(fields) == 1:
+		return s.vfo[band]
+	case mnemonic == "FO":
+		s.vfo[band] = cmd
+		return cmd
+	case mnemonic == "BC" && args == "":
+		return fmt.Sprintf(BCFormat, s.bc[0], s.bc[1])
+	case mnemonic == "BC" && len(fields) == 2:
+		s.bc[0], _ = strconv.Atoi(fields[0])
+		s.bc[1], _ = strconv.Atoi(fields[1])
+		return cmd
+	case mnemonic == "VM" && len(fields) == 1:
+		return fmt.Sprintf(VMFormat, band, s.mode[band])
+	case mnemonic == "VM":
+		s.mode[band], _ = strconv.Atoi(fields[1])
+		return cmd
+	case mnemonic == "MC" && len(fields) == 1:
+		return fmt.Sprintf(MCFormat, band, s.mc[band])
+	case mnemonic == "MC":
+		s.mc[band], _ = strconv.Atoi(fields[1])
+		return cmd
+	case mnemonic == "RX" && s.rejectRX:
+		return "?"
+	case mnemonic == "TX" || mnemonic == "RX":
+		s.tx = mnemonic == "TX"
+		return mnemonic
+	case mnemonic == "BY":
+		return fmt.Sprintf(BYFormat, band, 0)
+	case mnemonic == "SM":
+		return fmt.Sprintf(SMFormat, band, 0)
+	}
+	return "?"
+}
+
+// Serve answers the commands read from rw until it is closed.
+func (s *simRadio) Serve(rw io.ReadWriter) {
+	r := bufio.NewReader(rw)
+	for {
+		cmd, err := r.ReadString('\r')
+		if err != nil {
+			return
+		}
+		cmds := []string{cmd}
+		s.mu.Lock()
+		echo := s.echo
+		s.mu.Unlock()
+		for echo && r.Buffered() > 0 {
+			next, err := r.ReadString('\r')
+			if err != nil {
+				return
+			}
+			cmds = append(cmds, next)
+		}
+		var out string
+		if echo {
+			out = strings.Join(cmds, "")
+		}
+		for _, c := range cmds {
+			out += s.reply(strings.TrimSuffix(c, "\r")) + "\r"
+		}
+		if _, err := io.WriteString(rw, out); err != nil {
+			return
+		}
+	}
+}
+
+// harness is a built kenwoodutil binary wired to a simulated radio.
+type harness struct {
+	t     *testing.T
+	dir   string
+	bin   string
+	port  string
+	radio *simRadio
+}
+
+// buildBinary builds kenwoodutil into dir.
+func buildBinary(t *testing.T, dir string) string {
+	bin := filepath.Join(dir, "kenwoodutil")
+	if out, err := exec.Command("go", "build", "-o", bin, "./cmd/kenwoodutil").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	return bin
+}
+
+func newHarness(t *testing.T) *harness {
+	socat, err := exec.LookPath("socat")
+	if err != nil {
+		t.Skip("socat not installed")
+	}
+	h := &harness{t: t, dir: t.TempDir(), radio: newSimRadio()}
+	h.bin = buildBinary(t, h.dir)
+
+	h.port = filepath.Join(h.dir, "radio")
+	sim := filepath.Join(h.dir, "sim")
+	cmd := exec.Command(socat, "pty,raw,echo=0,link="+h.port, "pty,raw,echo=0,link="+sim)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("socat: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err1 := os.Stat(h.port)
+		_, err2 := os.Stat(sim)
+		if err1 == nil && err2 == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("socat did not create the pty pair")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	f, err := os.OpenFile(sim, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("opening simulator side: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	go h.radio.Serve(f)
+	return h
+}
+
+// run runs the binary with args against the simulated radio, failing the
+// test when it fails, and returns what it printed on standard output.
+func (h *harness) run(args ...string) string {
+	h.t.Helper()
+	global := []string{
+		"-port", h.port,
+		"-config", filepath.Join(h.dir, "config.json"),
+		"-models", filepath.Join(h.dir, "models.json"),
+		"-loglevel", "warn",
+	}
+	cmd := exec.Command(h.bin, append(global, args...)...)
+	cmd.Dir = h.dir
+	cmd.Env = append(os.Environ(), "HOME="+h.dir, "XDG_CACHE_HOME="+filepath.Join(h.dir, "cache"), "XDG_CONFIG_HOME="+filepath.Join(h.dir, "config"))
+	var stdout, stderr strings.Builder
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		h.t.Fatalf("kenwoodutil %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return stdout.String()
+}
+
+func TestIntegrationReadList(t *testing.T) {
+	h := newHarness(t)
+	h.run("read", "-settings=false", "-special=false", "-dtmf=false", "-aprs=false", "-o", "dump.json")
+	d, err := LoadDump(filepath.Join(h.dir, "dump.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Memory) != 2 || d.Memory[0].RXFrequency != 145500000 || d.Memory[1].Name != "CLUB" {
+		t.Fatalf("dump memory is %+v", d.Memory)
+	}
+	if d.Radio == nil || d.Radio.Model != "TM-D710" || d.Radio.Serial != "B1234567" || d.Radio.Firmware != "1.00,2.10,A,1" || d.Radio.DataBand != "A" {
+		t.Errorf("dump radio is %+v", d.Radio)
+	}
+	out := h.run("list", "dump.json")
+	if !strings.Contains(out, "439.1500 MHz") || !strings.Contains(out, "CLUB") {
+		t.Errorf("list printed\n%s", out)
+	}
+}
+
+func TestIntegrationWrite(t *testing.T) {
+	h := newHarness(t)
+	d := &Dump{Memory: []MemoryEntry{
+		{Number: 1, RXFrequency: 145500000, CTCSSFrequency: 8, ToneFrequency: 8, OffsetFrequency: 600000, Name: "CALL"},
+		{Number: 40, RXFrequency: 145600000, RXStepSize: 4, ShiftDirection: 2, ToneEnabled: 1, ToneFrequency: 12, CTCSSFrequency: 8, OffsetFrequency: 600000, Name: "RPT"},
+	}}
+	if err := d.Save(filepath.Join(h.dir, "plan.json")); err != nil {
+		t.Fatal(err)
+	}
+	h.run("write", "-settings=false", "-special=false", "-dtmf=false", "-aprs=false", "plan.json")
+
+	h.radio.mu.Lock()
+	defer h.radio.mu.Unlock()
+	if got := h.radio.memory[40]; !strings.HasPrefix(got, "ME 040,0145600000,") {
+		t.Errorf("channel 40 is %q", got)
+	}
+	if got := h.radio.names[40]; got != "MN 040,RPT" {
+		t.Errorf("channel 40 name is %q", got)
+	}
+}
+
+func TestIntegrationVFOSnapshot(t *testing.T) {
+	h := newHarness(t)
+	h.run("vfo", "-band", "B", "freq", "433.500")
+	h.radio.mu.Lock()
+	vfo := h.radio.vfo[1]
+	h.radio.mu.Unlock()
+	if !strings.HasPrefix(vfo, "FO 1,0433500000,") {
+		t.Fatalf("VFO B is %q", vfo)
+	}
+
+	var s StationSnapshot
+	if err := json.Unmarshal([]byte(h.run("snapshot", "-memory=false")), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Radio.Model != "TM-D710" || s.Firmware != "1.00,2.10,A,1" || len(s.Bands) != 2 || s.Bands[1].Frequency != "433.5000" {
+		t.Errorf("snapshot is %+v", s)
+	}
+}
+
+// TestIntegrationWriteStdin writes a plan piped in on standard input, first
+// to the simulated radio over TCP while recording the session, then to the
+// session played back.
+func TestIntegrationWriteStdin(t *testing.T) {
+	dir := t.TempDir()
+	bin := buildBinary(t, dir)
+	sim := newSimRadio()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go sim.Serve(c)
+		}
+	}()
+	d := &Dump{Memory: []MemoryEntry{
+		{Number: 40, RXFrequency: 145600000, RXStepSize: 4, ShiftDirection: 2, ToneEnabled: 1, ToneFrequency: 12, CTCSSFrequency: 8, OffsetFrequency: 600000, Name: "RPT"},
+	}}
+	if err := d.Save(filepath.Join(dir, "plan.json")); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := os.ReadFile(filepath.Join(dir, "plan.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(global ...string) string {
+		args := append(global, "-loglevel", "warn", "-config", filepath.Join(dir, "config.json"), "write", "-settings=false", "-special=false", "-dtmf=false", "-aprs=false", "-")
+		cmd := exec.Command(bin, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "HOME="+dir, "XDG_CACHE_HOME="+filepath.Join(dir, "cache"), "XDG_CONFIG_HOME="+filepath.Join(dir, "config"))
+		cmd.Stdin = strings.NewReader(string(plan))
+		var stdout, stderr strings.Builder
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("kenwoodutil %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+		}
+		return stdout.String()
+	}
+	session := filepath.Join(dir, "session.jsonl")
+	if out := write("-port", "tcp://"+l.Addr().String(), "-record", session); out != "" {
+		t.Errorf("write - printed\n%s", out)
+	}
+	sim.mu.Lock()
+	got := sim.memory[40]
+	sim.mu.Unlock()
+	if !strings.HasPrefix(got, "ME 040,0145600000,") {
+		t.Errorf("channel 40 is %q", got)
+	}
+	if out := write("-port", "replay://"+session); out != "" {
+		t.Errorf("write - played back printed\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "-")); err == nil {
+		t.Error("write - saved the dump to a file named -")
+	}
+}
+
+// TestIntegrationReadStdout reads to standard output with a dump piped in,
+// which is neither read nor kept in the trash.
+func TestIntegrationReadStdout(t *testing.T) {
+	dir := t.TempDir()
+	bin := buildBinary(t, dir)
+	sim := newSimRadio()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go sim.Serve(c)
+		}
+	}()
+	old := &Dump{Memory: []MemoryEntry{{Number: 99, RXFrequency: 145700000, Name: "OLD"}}}
+	if err := old.Save(filepath.Join(dir, "old.json")); err != nil {
+		t.Fatal(err)
+	}
+	in, err := os.Open(filepath.Join(dir, "old.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	args := []string{"-port", "tcp://" + l.Addr().String(), "-loglevel", "warn", "-config", filepath.Join(dir, "config.json"), "read", "-settings=false", "-special=false", "-dtmf=false", "-aprs=false", "-o", "-"}
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "HOME="+dir, "XDG_CACHE_HOME="+filepath.Join(dir, "cache"), "XDG_CONFIG_HOME="+filepath.Join(dir, "config"))
+	cmd.Stdin = in
+	var stdout, stderr strings.Builder
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("kenwoodutil %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	d, err := ParseDump([]byte(stdout.String()), "-")
+	if err != nil {
+		t.Fatalf("read -o - printed %v\n%s", err, stdout.String())
+	}
+	if len(d.Memory) != 2 || len(d.Trash) != 0 {
+		t.Errorf("read -o - has memory %+v and trash %+v", d.Memory, d.Trash)
+	}
+	if off, _ := in.Seek(0, io.SeekCurrent); off != 0 {
+		t.Errorf("read -o - read %d bytes of standard input", off)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cache", "kenwoodutil", "checkpoint-read.json")); err == nil {
+		t.Error("read -o - left a checkpoint")
+	}
+}
+
+// TestIntegrationReadChannelsPipelined reads channels with queries in flight
+// through an echoing interface, and reads a channel again after a pipelined
+// read failed halfway.
+func TestIntegrationReadChannelsPipelined(t *testing.T) {
+	sim := newSimRadio()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go sim.Serve(c)
+		}
+	}()
+	r, err := NewRadio("tcp://"+l.Addr().String(), 9600, DefaultSerialMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	sim.mu.Lock()
+	sim.echo = true
+	sim.mu.Unlock()
+	r.Echo = true
+	v, err := r.ReadChannels(0, 15, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != 16 || v[1].Name != "CALL" || v[12].Name != "CLUB" || v[5].RXFrequency != 0 {
+		t.Errorf("ReadChannels(0, 15, 8) through an echo = %+v", v)
+	}
+
+	sim.mu.Lock()
+	sim.echo = false
+	sim.memory[5] = "ME 007,0145500000,0,0,0,0,0,0,08,08,000,00600000,0,0000000000,0,0"
+	sim.mu.Unlock()
+	r.Echo = false
+	if _, err := r.ReadChannels(0, 15, 8); err == nil {
+		t.Fatal("ReadChannels did not fail on a reply for another channel")
+	}
+	m, err := r.ReadChannel(12)
+	if err != nil {
+		t.Fatalf("ReadChannel(12) after a failed pipelined read: %v", err)
+	}
+	if m.Number != 12 || m.Name != "CLUB" {
+		t.Errorf("ReadChannel(12) after a failed pipelined read = %+v", m)
+	}
+}
+
+// TestIntegrationConcurrentPTT keys and releases from several goroutines
+// while the TX watchdog fires, to be run with -race.
+func TestIntegrationConcurrentPTT(t *testing.T) {
+	sim := newSimRadio()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go sim.Serve(c)
+		}
+	}()
+	r, err := NewRadio("tcp://"+l.Addr().String(), 9600, DefaultSerialMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.MaxTX = 5 * time.Millisecond
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := r.SetPTT((i+j)%2 == 0); err != nil {
+					t.Error(err)
+					return
+				}
+				if j%5 == 0 {
+					time.Sleep(r.MaxTX)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := r.SetPTT(true); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * r.MaxTX)
+	sim.mu.Lock()
+	tx := sim.tx
+	sim.mu.Unlock()
+	if tx || r.Transmitting() {
+		t.Error("TX watchdog did not return the radio to receive")
+	}
+}
+
+// TestIntegrationPTTRejectedRX keeps the TX watchdog armed while the radio
+// does not take the RX command.
+func TestIntegrationPTTRejectedRX(t *testing.T) {
+	sim := newSimRadio()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go sim.Serve(c)
+		}
+	}()
+	r, err := NewRadio("tcp://"+l.Addr().String(), 9600, DefaultSerialMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.MaxTX = 50 * time.Millisecond
+
+	if err := r.SetPTT(true); err != nil {
+		t.Fatal(err)
+	}
+	sim.mu.Lock()
+	sim.rejectRX = true
+	sim.mu.Unlock()
+	if err := r.SetPTT(false); err == nil {
+		t.Fatal("SetPTT(false) did not fail on a rejected RX")
+	}
+	if !r.Transmitting() {
+		t.Error("Transmitting() = false after a rejected RX")
+	}
+	// the watchdog fires, is rejected too and tries again
+	time.Sleep(2 * r.MaxTX)
+	sim.mu.Lock()
+	sim.rejectRX = false
+	sim.mu.Unlock()
+	time.Sleep(txWatchdogRetry + r.MaxTX)
+	sim.mu.Lock()
+	tx := sim.tx
+	sim.mu.Unlock()
+	if tx || r.Transmitting() {
+		t.Error("TX watchdog did not return the radio to receive after a rejected RX")
+	}
+}
@@ -0,0 +1,75 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// KioskConfig sets up the kiosk command, which programs every radio plugged
+// in with a club plan, like at a table with a dedicated computer and no
+// screen.
+type KioskConfig struct {
+	// Plan is the dump written to every radio.
+	Plan string `json:",omitempty"`
+	// BackupDir is where radios are backed up before they are programmed,
+	// "kiosk-backups" by default.
+	BackupDir string `json:",omitempty"`
+	// Interval is how often ports are looked for, 2s by default.
+	Interval Duration `json:",omitempty"`
+	// OnSuccess and OnFailure list programs, with their arguments, run once
+	// a radio is done, like lighting a LED or sounding a buzzer. They get
+	// KENWOOD_PORT, KENWOOD_MODEL and on failure KENWOOD_ERROR in their
+	// environment.
+	OnSuccess [][]string `json:",omitempty"`
+	OnFailure [][]string `json:",omitempty"`
+}
+
+// KioskResult tells how programming a radio went.
+type KioskResult struct {
+	Backup   string
+	Written  int
+	Checksum string
+}
+
+// KioskProgram backs up the memory of r into backupDir, makes it the plan and
+// reads it back to verify it.
+func KioskProgram(r *Radio, plan *Dump, backupDir string) (res KioskResult, err error) {
+	span := r.startSpan("kiosk program", "model", r.Model)
+	defer func() { r.endSpan(span, err) }()
+
+	if err := r.ReadMemory(); err != nil {
+		return res, fmt.Errorf("error reading memory for backup: %w", err)
+	}
+	backup := &Dump{Radio: r.Info(), Memory: r.OccupedChannels()}
+	for _, m := range backup.Memory {
+		backup.RecordImport(m.Number, "radio "+r.Model)
+	}
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return res, fmt.Errorf("error creating backup directory: %w", err)
+	}
+	res.Backup = filepath.Join(backupDir, fmt.Sprintf("%s-%s.json", strings.ReplaceAll(r.Model, "/", "_"), time.Now().Format("20060102-150405")))
+	if err := backup.Save(res.Backup); err != nil {
+		return res, err
+	}
+	log.Info().Str("file", res.Backup).Int("channels", len(backup.Memory)).Msg("radio backed up")
+
+	s, err := r.ApplyLayout(plan.Memory)
+	if err != nil {
+		return res, fmt.Errorf("error writing plan: %w", err)
+	}
+	res.Written = s.Written
+
+	if err := r.ReadMemory(); err != nil {
+		return res, fmt.Errorf("error reading memory back: %w", err)
+	}
+	res.Checksum = MemoryChecksum(r.OccupedChannels())
+	if want := MemoryChecksum(plan.Memory); res.Checksum != want {
+		return res, fmt.Errorf("error verifying: memory checksum %s, expected %s, %d channels differ", res.Checksum, want, len(DiffMemory(plan.Memory, r.OccupedChannels())))
+	}
+	return res, nil
+}
@@ -0,0 +1,88 @@
+package kenwoodutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// Allocation is a frequency range the operator may transmit on, in MHz. A
+// single frequency leaves High out.
+type Allocation struct {
+	Name      string  `json:",omitempty"`
+	Low, High float64 `json:",omitempty"`
+}
+
+// Licence lists the allocations of a licence, for operators limited to some
+// frequencies rather than whole amateur bands, like on club or business
+// licences.
+type Licence struct {
+	Holder      string `json:",omitempty"`
+	Allocations []Allocation
+}
+
+// LoadLicence reads a licence file.
+func LoadLicence(path string) (*Licence, error) {
+	j, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading licence: %w", err)
+	}
+	l := &Licence{}
+	if err := json.Unmarshal(j, l); err != nil {
+		return nil, fmt.Errorf("error parsing licence %s: %w", path, err)
+	}
+	for _, a := range l.Allocations {
+		if a.Low <= 0 || a.High != 0 && a.High < a.Low {
+			return nil, fmt.Errorf("error in licence %s: allocation %q is not a frequency or a range of them", path, a.Name)
+		}
+	}
+	return l, nil
+}
+
+// Permits reports whether hz is in one of the allocations of l.
+func (l *Licence) Permits(hz uint32) bool {
+	mhz := float64(hz) / 1e6
+	for _, a := range l.Allocations {
+		high := a.High
+		if high == 0 {
+			high = a.Low
+		}
+		// half a hertz of slack for allocations not exact in binary
+		if mhz >= a.Low-5e-7 && mhz <= high+5e-7 {
+			return true
+		}
+	}
+	return false
+}
+
+// TransmitFrequency returns the frequency m transmits on.
+func (m MemoryEntry) TransmitFrequency() uint32 {
+	if tx := m.ShiftedTX(); tx != 0 {
+		return tx
+	}
+	return m.RXFrequency
+}
+
+// Unlicensed returns the channels of memory that transmit outside of l,
+// leaving out those a radio with capabilities c can not transmit on at
+// all.
+func (l *Licence) Unlicensed(memory []MemoryEntry, c Capabilities) (v []MemoryEntry) {
+	for _, m := range memory {
+		tx := m.TransmitFrequency()
+		if m.RXFrequency == 0 || !c.CanTransmit(tx) {
+			continue
+		}
+		if !l.Permits(tx) {
+			v = append(v, m)
+		}
+	}
+	return v
+}
+
+// licenceProblem is the validation problem of a channel transmitting
+// outside the licence.
+func licenceProblem(m MemoryEntry) string {
+	return fmt.Sprintf("transmit frequency %s MHz is not in the licence", units.FormatMHz(m.TransmitFrequency()))
+}
@@ -0,0 +1,279 @@
+package kenwoodutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// The validation server speaks the Language Server Protocol over stdio: it
+// keeps the dumps an editor has open and publishes their problems as
+// diagnostics on every change.
+
+const (
+	lspSeverityError   = 1
+	lspSyncFull        = 1
+	lspMethodNotFound  = -32601
+	lspInvalidParams   = -32602
+	lspDiagnosticsName = "kenwoodutil"
+)
+
+type lspMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+// Diagnostic is a problem of a dump at a range of its text, in LSP terms:
+// zero based lines and UTF-16 characters.
+type Diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+// DumpDiagnostics parses and validates the dump text and returns its
+// problems, placed at the channel they are about or where parsing stopped.
+func DumpDiagnostics(text []byte) []Diagnostic {
+	diag := func(from, to int64, msg string) []Diagnostic {
+		return []Diagnostic{{
+			Range:    lspRange{Start: lspPositionAt(text, from), End: lspPositionAt(text, to)},
+			Severity: lspSeverityError,
+			Source:   lspDiagnosticsName,
+			Message:  msg,
+		}}
+	}
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	var probe interface{}
+	if err := json.Unmarshal(text, &probe); errors.As(err, &syntax) {
+		return diag(syntax.Offset, syntax.Offset, syntax.Error())
+	} else if err != nil {
+		return diag(0, 0, err.Error())
+	}
+	if _, isDump := probe.(map[string]interface{}); isDump {
+		if err := json.Unmarshal(text, &Dump{}); errors.As(err, &typ) {
+			return diag(typ.Offset, typ.Offset, typ.Error())
+		}
+	}
+	d, err := ParseDump(text, "")
+	if err != nil {
+		return diag(0, 0, err.Error())
+	}
+	err = d.Validate()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	ranges := channelRanges(text, "Memory")
+	for n, r := range channelRanges(text, "Special") {
+		if _, ok := ranges[n]; !ok {
+			ranges[n] = r
+		}
+	}
+	var v []Diagnostic
+	for _, e := range errs {
+		r := ranges[e.Channel]
+		for _, p := range e.Problems {
+			v = append(v, diag(r[0], r[1], fmt.Sprintf("channel %03d: %s", e.Channel, p))...)
+		}
+	}
+	return v
+}
+
+// channelRanges returns the byte ranges of the channels in the list key of
+// the dump text, or in the whole text when it is a bare list, by channel
+// number.
+func channelRanges(text []byte, key string) map[uint16][2]int64 {
+	v := map[uint16][2]int64{}
+	dec := json.NewDecoder(bytes.NewReader(text))
+	tok, err := dec.Token()
+	if err != nil {
+		return v
+	}
+	if tok == json.Delim('{') {
+		for {
+			tok, err := dec.Token()
+			if err != nil || tok == json.Delim('}') {
+				return v
+			}
+			if tok == key {
+				break
+			}
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return v
+			}
+		}
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return v
+		}
+	} else if tok != json.Delim('[') || key != "Memory" {
+		return v
+	}
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return v
+		}
+		end := dec.InputOffset()
+		var m struct{ Number uint16 }
+		json.Unmarshal(raw, &m)
+		if _, seen := v[m.Number]; !seen {
+			v[m.Number] = [2]int64{end - int64(len(raw)), end}
+		}
+	}
+	return v
+}
+
+// lspPositionAt turns a byte offset of text into a line and a UTF-16
+// character.
+func lspPositionAt(text []byte, offset int64) lspPosition {
+	if offset > int64(len(text)) {
+		offset = int64(len(text))
+	}
+	var p lspPosition
+	for _, r := range string(text[:offset]) {
+		switch {
+		case r == '\n':
+			p.Line++
+			p.Character = 0
+		case r >= 0x10000:
+			p.Character += 2
+		default:
+			p.Character++
+		}
+	}
+	return p
+}
+
+// ServeLSP answers the LSP messages read from in on out until the client
+// sends exit or closes in.
+func ServeLSP(in io.Reader, out io.Writer) error {
+	tr := textproto.NewReader(bufio.NewReader(in))
+	send := func(m lspMessage) error {
+		m.JSONRPC = "2.0"
+		body, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("error encoding message: %w", err)
+		}
+		if _, err := fmt.Fprintf(out, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+			return fmt.Errorf("error writing message: %w", err)
+		}
+		return nil
+	}
+	publish := func(uri string, text []byte) error {
+		diags := []Diagnostic{}
+		if text != nil {
+			diags = append(diags, DumpDiagnostics(text)...)
+		}
+		params, _ := json.Marshal(map[string]interface{}{"uri": uri, "diagnostics": diags})
+		return send(lspMessage{Method: "textDocument/publishDiagnostics", Params: params})
+	}
+	docs := map[string][]byte{}
+	for {
+		header, err := tr.ReadMIMEHeader()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading message header: %w", err)
+		}
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil {
+			return fmt.Errorf("error in message header: bad Content-Length %q", header.Get("Content-Length"))
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(tr.R, body); err != nil {
+			return fmt.Errorf("error reading message: %w", err)
+		}
+		var m lspMessage
+		if err := json.Unmarshal(body, &m); err != nil {
+			return fmt.Errorf("error parsing message: %w", err)
+		}
+
+		var params struct {
+			TextDocument struct {
+				URI  string  `json:"uri"`
+				Text *string `json:"text"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+			Text *string `json:"text"`
+		}
+		if len(m.Params) > 0 {
+			if err := json.Unmarshal(m.Params, &params); err != nil && m.ID != nil {
+				if err := send(lspMessage{ID: m.ID, Error: &lspError{lspInvalidParams, err.Error()}}); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		uri := params.TextDocument.URI
+		switch m.Method {
+		case "initialize":
+			err = send(lspMessage{ID: m.ID, Result: map[string]interface{}{
+				"capabilities": map[string]interface{}{
+					"textDocumentSync": map[string]interface{}{"openClose": true, "change": lspSyncFull, "save": map[string]bool{"includeText": true}},
+				},
+				"serverInfo": map[string]string{"name": lspDiagnosticsName},
+			}})
+		case "shutdown":
+			err = send(lspMessage{ID: m.ID, Result: json.RawMessage("null")})
+		case "exit":
+			return nil
+		case "textDocument/didOpen":
+			if params.TextDocument.Text != nil {
+				docs[uri] = []byte(*params.TextDocument.Text)
+			}
+			err = publish(uri, docs[uri])
+		case "textDocument/didChange":
+			if n := len(params.ContentChanges); n > 0 {
+				docs[uri] = []byte(params.ContentChanges[n-1].Text)
+			}
+			err = publish(uri, docs[uri])
+		case "textDocument/didSave":
+			if params.Text != nil {
+				docs[uri] = []byte(*params.Text)
+			}
+			err = publish(uri, docs[uri])
+		case "textDocument/didClose":
+			delete(docs, uri)
+			err = publish(uri, nil)
+		default:
+			// notifications, like initialized, need no answer
+			if m.ID != nil && !strings.HasPrefix(m.Method, "$/") {
+				err = send(lspMessage{ID: m.ID, Error: &lspError{lspMethodNotFound, "method not found: " + m.Method}})
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// Macro is a named sequence of actions defined in the config file. Step
+// arguments may refer to parameters as ${name}.
+type Macro struct {
+	Description string            `json:",omitempty"`
+	Params      map[string]string `json:",omitempty"`
+	Steps       []MacroStep
+}
+
+// MacroStep runs action Do with Args. If If is set, the step only runs when
+// the condition holds, see evalCondition.
+type MacroStep struct {
+	If   string `json:",omitempty"`
+	Do   string
+	Args []string `json:",omitempty"`
+}
+
+// macroQueries maps queries usable in conditions to their argument count.
+var macroQueries = map[string]int{
+	"model": 0,
+	"busy":  1,
+	"band":  0,
+	"freq":  1,
+	"mode":  1,
+	"vfo":   1,
+}
+
+// query returns radio state as a string for macro conditions.
+func (r *Radio) query(name string, args []string) (string, error) {
+	nargs, ok := macroQueries[name]
+	if !ok {
+		return "", fmt.Errorf("unknown query %q", name)
+	}
+	if len(args) != nargs {
+		return "", fmt.Errorf("query %s takes %d arguments", name, nargs)
+	}
+	var band int
+	if nargs > 0 {
+		var err error
+		if band, err = units.ParseBand(args[0]); err != nil {
+			return "", err
+		}
+	}
+	switch name {
+	case "model":
+		return r.Model, nil
+	case "busy":
+		busy, err := r.GetBusy(band)
+		return strconv.FormatBool(busy), err
+	case "band":
+		control, _, err := r.GetBand()
+		return units.BandName(control), err
+	case "freq":
+		hz, err := r.GetFrequency(band)
+		return units.FormatMHz(hz), err
+	case "mode":
+		mode, err := r.GetMode(band)
+		return units.ModeName(mode), err
+	default:
+		mode, err := r.GetVFOMode(band)
+		return VFOModeName(mode), err
+	}
+}
+
+// evalCondition evaluates conditions like "model == TM-D710", "busy B",
+// "!busy B" or "freq A != 145.5000". A bare query holds when it is true.
+func (r *Radio) evalCondition(cond string) (bool, error) {
+	cond = strings.TrimSpace(cond)
+	negate := strings.HasPrefix(cond, "!")
+	cond = strings.TrimPrefix(cond, "!")
+
+	op, want := "", ""
+	for _, o := range []string{"==", "!="} {
+		if parts := strings.SplitN(cond, o, 2); len(parts) == 2 {
+			cond, op, want = parts[0], o, strings.TrimSpace(parts[1])
+			break
+		}
+	}
+	fields := strings.Fields(cond)
+	if len(fields) == 0 {
+		return false, fmt.Errorf("empty condition")
+	}
+	got, err := r.query(fields[0], fields[1:])
+	if err != nil {
+		return false, err
+	}
+
+	var holds bool
+	switch op {
+	case "==":
+		holds = strings.EqualFold(got, want)
+	case "!=":
+		holds = !strings.EqualFold(got, want)
+	default:
+		holds = got == "true"
+	}
+	return holds != negate, nil
+}
+
+type macroAction struct {
+	Args int
+	Run  func(r *Radio, args []string) error
+}
+
+var macroActions = map[string]macroAction{
+	"raw": {1, func(r *Radio, args []string) error {
+		_, err := r.Raw(args[0])
+		return err
+	}},
+	"freq": {2, func(r *Radio, args []string) error {
+		band, err := units.ParseBand(args[0])
+		if err != nil {
+			return err
+		}
+		hz, err := units.ParseMHz(args[1])
+		if err != nil {
+			return err
+		}
+		return r.SetFrequency(band, hz)
+	}},
+	"mode": {2, func(r *Radio, args []string) error {
+		band, err := units.ParseBand(args[0])
+		if err != nil {
+			return err
+		}
+		mode, err := units.ParseMode(args[1])
+		if err != nil {
+			return err
+		}
+		return r.SetMode(band, mode)
+	}},
+	"band": {1, func(r *Radio, args []string) error {
+		band, err := units.ParseBand(args[0])
+		if err != nil {
+			return err
+		}
+		return r.SelectBand(band)
+	}},
+	"memory": {2, func(r *Radio, args []string) error {
+		band, err := units.ParseBand(args[0])
+		if err != nil {
+			return err
+		}
+		ch, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("error parsing channel %q", args[1])
+		}
+		return r.SelectMemoryChannel(band, ch)
+	}},
+	"tnc": {2, func(r *Radio, args []string) error {
+		band, err := units.ParseBand(args[0])
+		if err != nil {
+			return err
+		}
+		mode, err := ParseTNCMode(args[1])
+		if err != nil {
+			return err
+		}
+		return r.SetTNC(mode, band)
+	}},
+	"clock-sync": {0, func(r *Radio, args []string) error {
+		return r.SetClock(time.Now())
+	}},
+	"verify": {1, func(r *Radio, args []string) error {
+		d, err := LoadDump(args[0])
+		if err != nil {
+			return err
+		}
+		if err := r.ReadMemory(); err != nil {
+			return err
+		}
+		got, want := MemoryChecksum(r.OccupedChannels()), MemoryChecksum(d.Memory)
+		if got != want {
+			return fmt.Errorf("error: radio memory checksum %s does not match %s of %s, %d channels differ", got, want, args[0], len(DiffMemory(d.Memory, r.OccupedChannels())))
+		}
+		log.Info().Str("checksum", got).Msg("radio memory matches the dump")
+		return nil
+	}},
+	"sleep": {1, func(r *Radio, args []string) error {
+		d, err := time.ParseDuration(args[0])
+		if err != nil {
+			return fmt.Errorf("error parsing duration %q", args[0])
+		}
+		time.Sleep(d)
+		return nil
+	}},
+}
+
+// RunMacro executes the steps of m, with params overriding the defaults
+// declared by the macro.
+func (r *Radio) RunMacro(m Macro, params map[string]string) error {
+	values := map[string]string{}
+	for k, v := range m.Params {
+		values[k] = v
+	}
+	for k, v := range params {
+		values[k] = v
+	}
+	var missing string
+	expand := func(s string) string {
+		return os.Expand(s, func(name string) string {
+			v, ok := values[name]
+			if !ok {
+				missing = name
+			}
+			return v
+		})
+	}
+
+	for i, step := range m.Steps {
+		action, ok := macroActions[step.Do]
+		if !ok {
+			return fmt.Errorf("error in macro step %d: unknown action %q", i+1, step.Do)
+		}
+		if len(step.Args) != action.Args {
+			return fmt.Errorf("error in macro step %d: %s takes %d arguments", i+1, step.Do, action.Args)
+		}
+		args := make([]string, len(step.Args))
+		for j, a := range step.Args {
+			args[j] = expand(a)
+		}
+		cond := expand(step.If)
+		if missing != "" {
+			return fmt.Errorf("error in macro step %d: parameter %q is not set", i+1, missing)
+		}
+		if cond != "" {
+			holds, err := r.evalCondition(cond)
+			if err != nil {
+				return fmt.Errorf("error in macro step %d condition: %w", i+1, err)
+			}
+			if !holds {
+				log.Debug().Int("step", i+1).Str("if", cond).Msg("macro step skipped")
+				continue
+			}
+		}
+		log.Debug().Int("step", i+1).Str("do", step.Do).Strs("args", args).Msg("macro")
+		if err := action.Run(r, args); err != nil {
+			return fmt.Errorf("error in macro step %d (%s): %w", i+1, step.Do, err)
+		}
+	}
+	return nil
+}
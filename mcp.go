@@ -0,0 +1,112 @@
+package kenwoodutil
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadHMKChannels reads the memory channels of a file exported by the
+// Kenwood Memory Control Programs (MCP-2A, MCP-6A), as .hmk or CSV. Those
+// are comma or tab separated sections, each with a "!!Ch,Rx Freq.,..."
+// header row and led by a "// Memory Channels" like comment. Only memory
+// channel sections are read.
+func ReadHMKChannels(r io.Reader) ([]MemoryEntry, error) {
+	var channels []MemoryEntry
+	var header []string
+	memory := true
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimRight(s.Text(), "\r")
+		switch {
+		case strings.TrimSpace(line) == "":
+			continue
+		case strings.HasPrefix(line, "//"):
+			memory = strings.Contains(strings.ToLower(line), "memory")
+			continue
+		}
+		record, err := hmkRecord(line)
+		if err != nil {
+			return nil, fmt.Errorf("error in MCP file line %d: %w", n, err)
+		}
+		if strings.HasPrefix(record[0], "!!") {
+			header = record
+			continue
+		}
+		if !memory || header == nil {
+			continue
+		}
+		fields := map[string]string{}
+		for i, h := range header {
+			if i < len(record) {
+				fields[strings.TrimPrefix(h, "!!")] = strings.TrimSpace(record[i])
+			}
+		}
+		if _, err := strconv.Atoi(fields["Ch"]); err != nil || fields["Rx Freq."] == "" {
+			continue
+		}
+		m, err := hmkChannel(fields)
+		if err != nil {
+			return nil, fmt.Errorf("error in MCP file line %d: %w", n, err)
+		}
+		channels = append(channels, m)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("error reading MCP file: %w", err)
+	}
+	if header == nil {
+		return nil, errors.New("error: no channel header in MCP file, expected a .hmk export")
+	}
+	return channels, nil
+}
+
+func hmkRecord(line string) ([]string, error) {
+	cr := csv.NewReader(strings.NewReader(line))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	if strings.Contains(line, "\t") {
+		cr.Comma = '\t'
+	}
+	return cr.Read()
+}
+
+// hmkChannel turns an MCP row into a channel, through the spreadsheet
+// fields.
+func hmkChannel(f map[string]string) (MemoryEntry, error) {
+	get := map[string]string{
+		"channel": f["Ch"],
+		"freq":    f["Rx Freq."],
+		"step":    f["Rx Step"],
+		"mode":    f["Mode"],
+		"name":    f["M.Name"],
+	}
+	switch f["Shift/Split"] {
+	case "+", "-":
+		get["offset"] = f["Shift/Split"] + f["Offset"]
+	case "S":
+		get["tx"], get["split"] = f["Tx Freq."], "yes"
+	}
+	switch f["T/CT/DCS"] {
+	case "T":
+		get["tone"] = f["TO Freq."]
+	case "CT":
+		get["ctcss"] = f["CT Freq."]
+	case "DCS":
+		get["dcs"] = f["DCS Code"]
+	}
+	m, err := CSVChannel(func(field string) string { return get[field] })
+	if err != nil {
+		return m, err
+	}
+	if strings.EqualFold(f["Rev."], "On") {
+		m.ReverseEnabled = 1
+	}
+	if strings.EqualFold(f["L.Out"], "On") {
+		m.LockOut = 1
+	}
+	return m, nil
+}
@@ -0,0 +1,63 @@
+package kenwoodutil
+
+import (
+	"strings"
+	"testing"
+)
+
+const hmkHeader = "!!Ch,Rx Freq.,Rx Step,Offset,T/CT/DCS,TO Freq.,CT Freq.,DCS Code,Shift/Split,Rev.,L.Out,Mode,Tx Freq.,Tx Step,M.Name"
+
+func TestReadHMKChannels(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		file string
+		want []MemoryEntry
+	}{
+		{"simplex", "// Memory Channels\n" + hmkHeader + "\n0,145.500000,12.5,0.600000,Off,88.5,88.5,023,,Off,Off,FM,145.500000,12.5,CALL\n",
+			[]MemoryEntry{{Number: 0, RXFrequency: 145500000, RXStepSize: 4, Name: "CALL"}}},
+		{"repeater with tone", "// Memory Channels\n" + hmkHeader + "\n12,439.150000,25,7.600000,T,94.8,88.5,023,-,Off,On,FM,431.550000,25,SR9A\n",
+			[]MemoryEntry{{Number: 12, RXFrequency: 439150000, RXStepSize: 7, ShiftDirection: 2, OffsetFrequency: 7600000, ToneEnabled: 1, ToneFrequency: 10, LockOut: 1, Name: "SR9A"}}},
+		{"tab separated", "// Memory Channels\n" + strings.ReplaceAll(hmkHeader, ",", "\t") + "\n999\t118.100000\t25\t0\tOff\t88.5\t88.5\t023\t\tOff\tOff\tAM\t118.100000\t25\tTWR\n",
+			[]MemoryEntry{{Number: 999, RXFrequency: 118100000, RXStepSize: 7, Mode: 1, Name: "TWR"}}},
+		{"CRLF and blank lines", "// Memory Channels\r\n\r\n" + hmkHeader + "\r\n1,145.525000,12.5,0,Off,88.5,88.5,023,,Off,Off,FM,145.525000,12.5,\r\n",
+			[]MemoryEntry{{Number: 1, RXFrequency: 145525000, RXStepSize: 4}}},
+		{"other sections skipped", "// Call Channels\n" + hmkHeader + "\nC0,145.000000,12.5,0,Off,88.5,88.5,023,,Off,Off,FM,145.000000,12.5,\n1,144.800000,12.5,0,Off,88.5,88.5,023,,Off,Off,FM,144.800000,12.5,\n",
+			nil},
+		{"rows without a channel number or frequency skipped", "// Memory Channels\n" + hmkHeader + "\n,145.500000,12.5,0,Off,88.5,88.5,023,,Off,Off,FM,,12.5,\n2,,12.5,0,Off,88.5,88.5,023,,Off,Off,FM,,12.5,\n",
+			nil},
+		{"short row", "// Memory Channels\n" + hmkHeader + "\n3,145.550000\n",
+			[]MemoryEntry{{Number: 3, RXFrequency: 145550000}}},
+	} {
+		got, err := ReadHMKChannels(strings.NewReader(tc.file))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %d channels %+v, want %d", tc.name, len(got), got, len(tc.want))
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: got %+v, want %+v", tc.name, got[i], tc.want[i])
+			}
+		}
+	}
+}
+
+func TestReadHMKChannelsErrors(t *testing.T) {
+	for _, file := range []string{
+		"",
+		"// Memory Channels\n0,145.500000,12.5\n",
+		"// Memory Channels\n" + hmkHeader + "\n0,145.5x,12.5,0,Off,88.5,88.5,023,,Off,Off,FM,,12.5,\n",
+		"// Memory Channels\n" + hmkHeader + "\n0,145.500000,11,0,Off,88.5,88.5,023,,Off,Off,FM,,12.5,\n",
+		"// Memory Channels\n" + hmkHeader + "\n0,145.500000,12.5,0,T,12.3,88.5,023,,Off,Off,FM,,12.5,\n",
+		"// Memory Channels\n" + hmkHeader + "\n0,145.500000,12.5,0,DCS,88.5,88.5,999,,Off,Off,FM,,12.5,\n",
+		"// Memory Channels\n" + hmkHeader + "\n0,145.500000,12.5,0,Off,88.5,88.5,023,,Off,Off,USB,,12.5,\n",
+		"// Memory Channels\n" + hmkHeader + "\n70000,145.500000,12.5,0,Off,88.5,88.5,023,,Off,Off,FM,,12.5,\n",
+	} {
+		if got, err := ReadHMKChannels(strings.NewReader(file)); err == nil {
+			t.Errorf("ReadHMKChannels(%q) succeeded, got %+v", file, got)
+		}
+	}
+}
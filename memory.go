@@ -0,0 +1,146 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// MemoryEntry is a channel as the radio keeps it. Split channels transmit on
+// TXFrequency, an odd split, instead of shifting by OffsetFrequency.
+type MemoryEntry struct {
+	Number          uint16      `json:",omitempty"`
+	RXFrequency     uint32      `json:",omitempty"`
+	RXStepSize      uint8       `json:",omitempty"`
+	ShiftDirection  uint8       `json:",omitempty"`
+	ReverseEnabled  uint8       `json:",omitempty"`
+	ToneEnabled     uint8       `json:",omitempty"`
+	CTCSSEnabled    uint8       `json:",omitempty"`
+	DCSEnabled      uint8       `json:",omitempty"`
+	ToneFrequency   uint16      `json:",omitempty"`
+	CTCSSFrequency  uint16      `json:",omitempty"`
+	DCSFrequency    uint16      `json:",omitempty"`
+	OffsetFrequency uint32      `json:",omitempty"`
+	Mode            uint8       `json:",omitempty"`
+	TXFrequency     uint32      `json:",omitempty"`
+	TXStepSize      uint8       `json:",omitempty"`
+	Split           bool        `json:",omitempty"`
+	LockOut         uint8       `json:",omitempty"`
+	Power           PowerLevel  `json:",omitempty"`
+	Name            string      `json:",omitempty"`
+	Kind            ChannelKind `json:",omitempty"`
+}
+
+const (
+	MNFormat        = "MN %03d,%s"
+	MEClearFormat   = "ME %03d,C"
+	IDCommandFormat = "ID\r"
+	MECommandFormat = "ME %03d\r"
+	MNCommandFormat = "MN %03d\r"
+	IDFormat        = "ID %s"
+	AECommandFormat = "AE\r"
+	FVCommandFormat = "FV 0\r"
+)
+
+func (m *MemoryEntry) ReadNameLine(line string) error {
+	if line != "N\r" {
+		line = strings.TrimSuffix(line, "\r")
+		items := strings.Split(line, ",")
+		if !strings.HasPrefix(items[0], "MN") {
+			return parseError("name line", line, "")
+		}
+		if (len(items) < 2) || (items[1] == "") {
+			m.Name = ""
+			return nil
+		}
+		m.Name = items[1]
+	}
+	return nil
+}
+
+func (m *MemoryEntry) ReadChannelLine(line string) error {
+	if line != "N\r" {
+		return m.UnmarshalME(line)
+	}
+	return nil
+}
+
+// Canonicalize brings a channel read from the radio to the single
+// representation used in dumps, so that equal channels compare equal.
+func (m *MemoryEntry) Canonicalize() {
+	m.Name = strings.TrimSpace(m.Name)
+	m.RXStepSize = units.CanonicalStep(m.RXFrequency, m.RXStepSize)
+	if m.TXFrequency == 0 {
+		m.TXStepSize = 0
+	} else {
+		m.TXStepSize = units.CanonicalStep(m.TXFrequency, m.TXStepSize)
+	}
+}
+
+// Normalize canonicalizes m and clears fields that have no effect on how the
+// radio behaves, like an offset on a simplex channel or the frequency of a
+// disabled tone.
+func (m *MemoryEntry) Normalize() {
+	m.Canonicalize()
+	if m.ShiftDirection == 0 {
+		m.OffsetFrequency = 0
+	}
+	if !m.Split {
+		m.TXFrequency, m.TXStepSize = 0, 0
+	}
+	if m.ToneEnabled == 0 {
+		m.ToneFrequency = 0
+	}
+	if m.CTCSSEnabled == 0 {
+		m.CTCSSFrequency = 0
+	}
+	if m.DCSEnabled == 0 {
+		m.DCSFrequency = 0
+	}
+}
+
+// ShiftedTX returns the transmit frequency of a repeater or split channel,
+// 0 for simplex ones.
+func (m MemoryEntry) ShiftedTX() uint32 {
+	if m.Split {
+		return m.TXFrequency
+	}
+	switch m.ShiftDirection {
+	case 1:
+		return m.RXFrequency + m.OffsetFrequency
+	case 2:
+		return m.RXFrequency - m.OffsetFrequency
+	}
+	return 0
+}
+
+// Equal reports whether m and other program the radio the same way.
+func (m MemoryEntry) Equal(other MemoryEntry) bool {
+	m.Normalize()
+	other.Normalize()
+	return m == other
+}
+
+// WriteNameLine encodes the name of m as an MN line. The name is expected to
+// fit the model already, see Capabilities.FitName, only what would break
+// the line is replaced.
+func (m *MemoryEntry) WriteNameLine() (s string) {
+	return fmt.Sprintf(MNFormat, m.Number, nameLineReplacer.Replace(m.Name))
+}
+
+var nameLineReplacer = strings.NewReplacer(",", ".", "\r", " ", "\n", " ")
+
+func (m *MemoryEntry) ClearChannelLine() (s string) {
+	return fmt.Sprintf(MEClearFormat, m.Number)
+}
+
+// WriteChannelLine encodes m as an ME or CC line. The TX frequency is only
+// sent for split channels, a stale one would make the radio transmit there.
+func (m *MemoryEntry) WriteChannelLine() (s string) {
+	e := *m
+	if !e.Split {
+		e.TXFrequency = 0
+	}
+	return e.MarshalME()
+}
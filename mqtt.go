@@ -0,0 +1,227 @@
+package kenwoodutil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// MQTT control packet types, of MQTT 3.1.1. Only QoS 0 is used.
+const (
+	mqttConnect     = 1
+	mqttConnAck     = 2
+	mqttPublish     = 3
+	mqttSubscribe   = 8
+	mqttPingReq     = 12
+	mqttDisconnect  = 14
+	mqttKeepAlive   = 60 * time.Second
+	mqttDialTimeout = 10 * time.Second
+)
+
+// mqttClient is a minimal MQTT 3.1.1 client, publishing and subscribing at
+// QoS 0.
+type mqttClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+	mu   sync.Mutex
+	done chan struct{}
+}
+
+// mqttMessage is a message to publish, or the will of the connection.
+type mqttMessage struct {
+	Topic   string
+	Payload string
+	Retain  bool
+}
+
+func mqttString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+func mqttPacket(kind, flags byte, body []byte) []byte {
+	p := []byte{kind<<4 | flags}
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		p = append(p, digit)
+		if n == 0 {
+			break
+		}
+	}
+	return append(p, body...)
+}
+
+// dialMQTT connects to broker, a host:port, and keeps the connection alive
+// until Close.
+func dialMQTT(broker, clientID, username, password string, will *mqttMessage) (*mqttClient, error) {
+	conn, err := net.DialTimeout("tcp", broker, mqttDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to MQTT broker: %w", err)
+	}
+	c := &mqttClient{conn: conn, r: bufio.NewReader(conn), done: make(chan struct{})}
+
+	flags := byte(0x02) // clean session
+	body := mqttString(nil, "MQTT")
+	body = append(body, 4)
+	if will != nil {
+		flags |= 0x04
+		if will.Retain {
+			flags |= 0x20
+		}
+	}
+	if username != "" {
+		flags |= 0x80
+	}
+	if password != "" {
+		flags |= 0x40
+	}
+	body = append(body, flags, byte(mqttKeepAlive/time.Second>>8), byte(mqttKeepAlive/time.Second))
+	body = mqttString(body, clientID)
+	if will != nil {
+		body = mqttString(body, will.Topic)
+		body = mqttString(body, will.Payload)
+	}
+	if username != "" {
+		body = mqttString(body, username)
+	}
+	if password != "" {
+		body = mqttString(body, password)
+	}
+	if err := c.write(mqttPacket(mqttConnect, 0, body)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Now().Add(mqttDialTimeout))
+	kind, _, ack, err := c.read()
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error connecting to MQTT broker: %w", err)
+	}
+	if kind != mqttConnAck || len(ack) < 2 {
+		conn.Close()
+		return nil, errors.New("error connecting to MQTT broker: no CONNACK")
+	}
+	if ack[1] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("error connecting to MQTT broker: refused with code %d", ack[1])
+	}
+	go c.ping()
+	return c, nil
+}
+
+func (c *mqttClient) write(p []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.conn.Write(p); err != nil {
+		return fmt.Errorf("error writing to MQTT broker: %w", err)
+	}
+	return nil
+}
+
+func (c *mqttClient) read() (kind, flags byte, body []byte, err error) {
+	h, err := c.r.ReadByte()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	n, shift := 0, 0
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		n |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, 0, nil, errors.New("malformed MQTT packet length")
+		}
+	}
+	body = make([]byte, n)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, 0, nil, err
+	}
+	return h >> 4, h & 0x0f, body, nil
+}
+
+func (c *mqttClient) ping() {
+	t := time.NewTicker(mqttKeepAlive / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-t.C:
+			if err := c.write(mqttPacket(mqttPingReq, 0, nil)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *mqttClient) Publish(m mqttMessage) error {
+	var flags byte
+	if m.Retain {
+		flags = 0x01
+	}
+	body := mqttString(nil, m.Topic)
+	return c.write(mqttPacket(mqttPublish, flags, append(body, m.Payload...)))
+}
+
+func (c *mqttClient) Subscribe(topics ...string) error {
+	body := []byte{0, 1}
+	for _, t := range topics {
+		body = append(mqttString(body, t), 0)
+	}
+	return c.write(mqttPacket(mqttSubscribe, 0x02, body))
+}
+
+// Receive calls fn with every message published on the subscribed topics
+// until the connection ends.
+func (c *mqttClient) Receive(fn func(topic, payload string)) error {
+	for {
+		kind, flags, body, err := c.read()
+		if err != nil {
+			select {
+			case <-c.done:
+				return nil
+			default:
+			}
+			return fmt.Errorf("error reading from MQTT broker: %w", err)
+		}
+		if kind != mqttPublish || len(body) < 2 {
+			continue
+		}
+		n := int(binary.BigEndian.Uint16(body))
+		if len(body) < 2+n {
+			continue
+		}
+		payload := body[2+n:]
+		if flags&0x06 != 0 {
+			// a packet identifier follows the topic above QoS 0
+			if len(payload) < 2 {
+				continue
+			}
+			payload = payload[2:]
+		}
+		fn(string(body[2:2+n]), string(payload))
+	}
+}
+
+// Close disconnects cleanly, so that the broker does not publish the will.
+func (c *mqttClient) Close() error {
+	close(c.done)
+	c.write(mqttPacket(mqttDisconnect, 0, nil))
+	return c.conn.Close()
+}
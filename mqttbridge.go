@@ -0,0 +1,291 @@
+package kenwoodutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// MQTTConfig sets up the mqtt command, bridging the radio to a home
+// automation broker.
+type MQTTConfig struct {
+	// Broker is the host:port of the broker, "localhost:1883" by default.
+	Broker   string `json:",omitempty"`
+	ClientID string `json:",omitempty"`
+	Username string `json:",omitempty"`
+	Password string `json:",omitempty"`
+	// Topic is what state and command topics start with, "kenwood" by
+	// default.
+	Topic string `json:",omitempty"`
+	// Interval is how often the radio is polled, 2s by default.
+	Interval Duration `json:",omitempty"`
+	// Discovery is the Home Assistant discovery prefix, usually
+	// "homeassistant". Nothing is announced when it is empty.
+	Discovery string `json:",omitempty"`
+	// MaxTX enables the PTT command topic, with the transmitter released
+	// after MaxTX at the latest. PTT commands are refused without it.
+	MaxTX Duration `json:",omitempty"`
+}
+
+// MQTTBridge publishes what both bands are tuned to and whether their
+// squelch is open, retained, under Topic/A and Topic/B, and tunes the radio
+// on messages to the matching /set topics:
+//
+//	kenwood/A/frequency/set  146.520
+//	kenwood/A/channel/set    42
+//	kenwood/ptt/set          ON|OFF
+type MQTTBridge struct {
+	Radio  *Radio
+	Config MQTTConfig
+
+	client *mqttClient
+	mu     sync.Mutex
+	state  map[string]string
+}
+
+// NewMQTTBridge fills in the defaults of c.
+func NewMQTTBridge(r *Radio, c MQTTConfig) *MQTTBridge {
+	if c.Broker == "" {
+		c.Broker = "localhost:1883"
+	}
+	if c.ClientID == "" {
+		c.ClientID = "kenwoodutil-" + strings.ToLower(r.Model)
+	}
+	if c.Topic == "" {
+		c.Topic = "kenwood"
+	}
+	c.Topic = strings.TrimSuffix(c.Topic, "/")
+	if c.Interval <= 0 {
+		c.Interval = Duration(2 * time.Second)
+	}
+	return &MQTTBridge{Radio: r, Config: c, state: map[string]string{}}
+}
+
+func (b *MQTTBridge) topic(parts ...string) string {
+	return b.Config.Topic + "/" + strings.Join(parts, "/")
+}
+
+// Connect connects to the broker, announces the bridge and subscribes to
+// the command topics.
+func (b *MQTTBridge) Connect() error {
+	status := b.topic("status")
+	c, err := dialMQTT(b.Config.Broker, b.Config.ClientID, b.Config.Username, b.Config.Password, &mqttMessage{Topic: status, Payload: "offline", Retain: true})
+	if err != nil {
+		return err
+	}
+	b.client = c
+	b.mu.Lock()
+	b.state = map[string]string{}
+	b.mu.Unlock()
+	if err := b.subscribe(); err != nil {
+		c.Close()
+		return err
+	}
+	return nil
+}
+
+func (b *MQTTBridge) subscribe() error {
+	if b.Config.Discovery != "" {
+		if err := b.announce(); err != nil {
+			return err
+		}
+	}
+	topics := []string{b.topic("+", "frequency", "set"), b.topic("+", "channel", "set")}
+	if b.Config.MaxTX > 0 {
+		topics = append(topics, b.topic("ptt", "set"))
+	}
+	if err := b.client.Subscribe(topics...); err != nil {
+		return err
+	}
+	return b.client.Publish(mqttMessage{Topic: b.topic("status"), Payload: "online", Retain: true})
+}
+
+// Run handles commands and polls the radio until stop is closed or the
+// connection to the broker is lost.
+func (b *MQTTBridge) Run(stop <-chan struct{}) error {
+	done := make(chan error, 1)
+	go func() { done <- b.client.Receive(b.handle) }()
+	for {
+		if err := b.Poll(); err != nil {
+			log.Warn().Err(err).Msg("poll failed")
+		}
+		select {
+		case err := <-done:
+			return err
+		case <-stop:
+			b.client.Publish(mqttMessage{Topic: b.topic("status"), Payload: "offline", Retain: true})
+			return b.client.Close()
+		case <-time.After(time.Duration(b.Config.Interval)):
+		}
+	}
+}
+
+func onOff(on bool) string {
+	if on {
+		return "ON"
+	}
+	return "OFF"
+}
+
+// Poll samples both bands and publishes the state that changed.
+func (b *MQTTBridge) Poll() error {
+	state := map[string]string{b.topic("ptt"): onOff(b.Radio.Transmitting())}
+	for band := 0; band < 2; band++ {
+		s, err := b.Radio.Sample(band)
+		if err != nil {
+			return err
+		}
+		name := units.BandName(band)
+		state[b.topic(name, "frequency")] = units.FormatMHz(s.Frequency)
+		state[b.topic(name, "mode")] = VFOModeName(s.Mode)
+		state[b.topic(name, "channel")] = ""
+		state[b.topic(name, "name")] = ""
+		if s.Mode == MemoryMode {
+			state[b.topic(name, "channel")] = strconv.Itoa(s.Channel)
+			state[b.topic(name, "name")] = s.Name
+		}
+		state[b.topic(name, "squelch")] = onOff(s.Busy)
+		state[b.topic(name, "smeter")] = strconv.Itoa(s.SMeter)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for topic, v := range state {
+		if old, ok := b.state[topic]; ok && old == v {
+			continue
+		}
+		if err := b.client.Publish(mqttMessage{Topic: topic, Payload: v, Retain: true}); err != nil {
+			return err
+		}
+		b.state[topic] = v
+	}
+	return nil
+}
+
+func (b *MQTTBridge) handle(topic, payload string) {
+	payload = strings.TrimSpace(payload)
+	parts := strings.Split(strings.TrimPrefix(topic, b.Config.Topic+"/"), "/")
+	err := b.command(parts, payload)
+	if err != nil {
+		log.Error().Err(err).Str("topic", topic).Str("payload", payload).Msg("MQTT command failed")
+		return
+	}
+	log.Info().Str("topic", topic).Str("payload", payload).Msg("MQTT command")
+	if err := b.Poll(); err != nil {
+		log.Warn().Err(err).Msg("poll failed")
+	}
+}
+
+func (b *MQTTBridge) command(parts []string, payload string) error {
+	if len(parts) == 2 && parts[0] == "ptt" && parts[1] == "set" {
+		if b.Config.MaxTX <= 0 {
+			return ErrNoTXWatchdog
+		}
+		b.Radio.MaxTX = time.Duration(b.Config.MaxTX)
+		switch strings.ToUpper(payload) {
+		case "ON":
+			return b.Radio.SetPTT(true)
+		case "OFF":
+			return b.Radio.SetPTT(false)
+		}
+		return fmt.Errorf("error: PTT takes ON or OFF, not %q", payload)
+	}
+	if len(parts) != 3 || parts[2] != "set" {
+		return fmt.Errorf("error: unknown command topic")
+	}
+	band, err := units.ParseBand(parts[0])
+	if err != nil {
+		return err
+	}
+	switch parts[1] {
+	case "frequency":
+		hz, err := units.ParseMHz(payload)
+		if err != nil {
+			return err
+		}
+		if err := b.Radio.SetVFOMode(band, VFOMode); err != nil {
+			return err
+		}
+		return b.Radio.SetFrequency(band, hz)
+	case "channel":
+		ch, err := strconv.Atoi(payload)
+		if err != nil {
+			return fmt.Errorf("error parsing channel %q: %w", payload, err)
+		}
+		return b.Radio.SelectMemoryChannel(band, ch)
+	}
+	return fmt.Errorf("error: unknown command topic")
+}
+
+// announce publishes Home Assistant discovery configs for the state and
+// command topics, all grouped under one device.
+func (b *MQTTBridge) announce() error {
+	node := strings.NewReplacer("/", "_", "+", "_", "#", "_").Replace(b.Config.Topic)
+	device := map[string]interface{}{
+		"identifiers":  []string{node},
+		"name":         "Kenwood " + b.Radio.Model,
+		"manufacturer": "Kenwood",
+		"model":        b.Radio.Model,
+	}
+	announce := func(component, id string, config map[string]interface{}) error {
+		config["unique_id"] = node + "_" + id
+		config["object_id"] = node + "_" + id
+		config["availability_topic"] = b.topic("status")
+		config["device"] = device
+		j, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+		return b.client.Publish(mqttMessage{Topic: b.Config.Discovery + "/" + component + "/" + node + "/" + id + "/config", Payload: string(j), Retain: true})
+	}
+	for band := 0; band < 2; band++ {
+		name := units.BandName(band)
+		id := strings.ToLower(name)
+		entities := []struct {
+			component, id string
+			config        map[string]interface{}
+		}{
+			{"text", "frequency", map[string]interface{}{
+				"name": "Band " + name + " frequency", "icon": "mdi:radio-tower",
+				"state_topic": b.topic(name, "frequency"), "command_topic": b.topic(name, "frequency", "set"),
+				"pattern": `\d{1,4}\.\d{1,6}`,
+			}},
+			{"number", "channel", map[string]interface{}{
+				"name": "Band " + name + " memory channel", "icon": "mdi:numeric",
+				"state_topic": b.topic(name, "channel"), "command_topic": b.topic(name, "channel", "set"),
+				"min": 0, "max": b.Radio.MemorySize() - 1, "mode": "box",
+			}},
+			{"sensor", "name", map[string]interface{}{
+				"name": "Band " + name + " channel name", "state_topic": b.topic(name, "name"),
+			}},
+			{"sensor", "mode", map[string]interface{}{
+				"name": "Band " + name + " mode", "state_topic": b.topic(name, "mode"),
+			}},
+			{"binary_sensor", "squelch", map[string]interface{}{
+				"name": "Band " + name + " squelch open", "device_class": "sound",
+				"state_topic": b.topic(name, "squelch"),
+			}},
+			{"sensor", "smeter", map[string]interface{}{
+				"name": "Band " + name + " S-meter", "state_topic": b.topic(name, "smeter"),
+				"state_class": "measurement",
+			}},
+		}
+		for _, e := range entities {
+			if err := announce(e.component, id+"_"+e.id, e.config); err != nil {
+				return err
+			}
+		}
+	}
+	ptt := map[string]interface{}{"name": "PTT", "icon": "mdi:microphone", "state_topic": b.topic("ptt")}
+	component := "binary_sensor"
+	if b.Config.MaxTX > 0 {
+		component = "switch"
+		ptt["command_topic"] = b.topic("ptt", "set")
+	}
+	return announce(component, "ptt", ptt)
+}
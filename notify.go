@@ -0,0 +1,225 @@
+package kenwoodutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotifyConfig lists where to report that a long operation, like a write,
+// finished, so that nobody has to wait by the radio for it.
+type NotifyConfig struct {
+	// Webhook is a URL the Notification is posted to as JSON.
+	Webhook  string          `json:",omitempty"`
+	Telegram *TelegramConfig `json:",omitempty"`
+	Email    *EmailConfig    `json:",omitempty"`
+	// Commands are the commands notified about, read, write, restore,
+	// clone, reorganize and pipeline by default.
+	Commands []string `json:",omitempty"`
+	// MinDuration leaves out commands done quicker, 1m by default.
+	MinDuration Duration `json:",omitempty"`
+}
+
+type TelegramConfig struct {
+	Token  string
+	ChatID string
+}
+
+// EmailConfig sends mail through an SMTP server, with STARTTLS when the
+// server offers it.
+type EmailConfig struct {
+	// Server is host:port.
+	Server   string
+	Username string `json:",omitempty"`
+	Password string `json:",omitempty"`
+	From     string
+	To       []string
+}
+
+const notifyTimeout = 30 * time.Second
+
+var defaultNotifyCommands = []string{"read", "write", "restore", "clone", "reorganize", "pipeline"}
+
+// Notification tells how a command went.
+type Notification struct {
+	Command  string
+	Success  bool
+	Error    string `json:",omitempty"`
+	Started  time.Time
+	Finished time.Time
+	// Summary is what the command reported on the way.
+	Summary []string `json:",omitempty"`
+}
+
+// Text is n as a message for people.
+func (n Notification) Text() string {
+	var b strings.Builder
+	status := "done"
+	if !n.Success {
+		status = "FAILED"
+	}
+	fmt.Fprintf(&b, "kenwoodutil %s %s after %s\n", n.Command, status, n.Finished.Sub(n.Started).Round(time.Second))
+	if n.Error != "" {
+		fmt.Fprintf(&b, "error: %s\n", n.Error)
+	}
+	for _, line := range n.Summary {
+		fmt.Fprintln(&b, line)
+	}
+	return b.String()
+}
+
+// Wants reports whether c notifies about command having run for d.
+func (c NotifyConfig) Wants(command string, d time.Duration) bool {
+	if c.Webhook == "" && c.Telegram == nil && c.Email == nil {
+		return false
+	}
+	min := time.Duration(c.MinDuration)
+	if min == 0 {
+		min = time.Minute
+	}
+	if d < min {
+		return false
+	}
+	commands := c.Commands
+	if len(commands) == 0 {
+		commands = defaultNotifyCommands
+	}
+	for _, name := range commands {
+		if name == command {
+			return true
+		}
+	}
+	return false
+}
+
+// Send sends n to every destination of c, trying all of them even when
+// some fail.
+func (c NotifyConfig) Send(n Notification) error {
+	var errs []string
+	if c.Webhook != "" {
+		if err := postWebhook(c.Webhook, n); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if c.Telegram != nil {
+		if err := c.Telegram.send(n.Text()); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if c.Email != nil {
+		if err := c.Email.send(n); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func postWebhook(u string, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("error encoding notification: %w", err)
+	}
+	client := &http.Client{Timeout: notifyTimeout}
+	resp, err := client.Post(u, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error calling webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("error calling webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+func (t *TelegramConfig) send(text string) error {
+	client := &http.Client{Timeout: notifyTimeout}
+	resp, err := client.PostForm("https://api.telegram.org/bot"+t.Token+"/sendMessage", url.Values{"chat_id": {t.ChatID}, "text": {text}})
+	if err != nil {
+		// the error quotes the URL, token included
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("error sending Telegram message: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("error sending Telegram message: %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *EmailConfig) send(n Notification) error {
+	host, _, err := net.SplitHostPort(e.Server)
+	if err != nil {
+		return fmt.Errorf("error in mail server %q: %w", e.Server, err)
+	}
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	text := n.Text()
+	subject := strings.SplitN(text, "\n", 2)[0]
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n",
+		e.From, strings.Join(e.To, ", "), subject, n.Finished.Format(time.RFC1123Z))
+	msg.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	if err := smtp.SendMail(e.Server, auth, e.From, e.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+	return nil
+}
+
+// SummaryLog keeps the info and worse lines of the JSON log, to send them
+// along with a notification.
+type SummaryLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (s *SummaryLog) Write(p []byte) (int, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return len(p), nil
+	}
+	switch fields["level"] {
+	case "info", "warn", "error", "fatal":
+	default:
+		return len(p), nil
+	}
+	line := fmt.Sprint(fields["message"])
+	var keys []string
+	for k := range fields {
+		switch k {
+		case "level", "time", "message":
+		default:
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		line += fmt.Sprintf(" %s=%v", k, fields[k])
+	}
+	s.mu.Lock()
+	s.lines = append(s.lines, line)
+	s.mu.Unlock()
+	return len(p), nil
+}
+
+func (s *SummaryLog) Lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.lines...)
+}
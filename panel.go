@@ -0,0 +1,77 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	LKFormat        = "LK %1d"
+	LKCommandFormat = "LK\r"
+)
+
+// MaxBrightness is the brightest display level, 0 turns the backlight off.
+const MaxBrightness = 8
+
+// GetLock reports whether the keys and knobs of the radio are locked.
+func (r *Radio) GetLock() (bool, error) {
+	line, err := r.WriteReadString(LKCommandFormat)
+	if err != nil {
+		return false, fmt.Errorf("error reading key lock: %w", err)
+	}
+	var on int
+	if _, err := fmt.Sscanf(line, LKFormat, &on); err != nil {
+		return false, parseError("key lock", line, "")
+	}
+	return on == 1, nil
+}
+
+// SetLock locks the keys and knobs of the radio, or unlocks them. The PC
+// port keeps working either way.
+func (r *Radio) SetLock(on bool) error {
+	v := 0
+	if on {
+		v = 1
+	}
+	if _, err := r.WriteReadString(fmt.Sprintf(LKFormat, v) + "\r"); err != nil {
+		return fmt.Errorf("error setting key lock: %w", err)
+	}
+	return nil
+}
+
+// Display is the backlight setup of the radio, kept in its menu settings.
+type Display struct {
+	Brightness     int
+	AutoBrightness bool
+}
+
+// GetDisplay reads the backlight setup from the menu settings.
+func (r *Radio) GetDisplay() (d Display, err error) {
+	s, err := r.ReadSettings()
+	if err != nil {
+		return d, err
+	}
+	level, ok := s["MU.brightness_level"]
+	if !ok {
+		return d, fmt.Errorf("error: %s does not report its display brightness", r.Model)
+	}
+	if d.Brightness, err = strconv.Atoi(level); err != nil {
+		return d, parseError("brightness level", level, "")
+	}
+	d.AutoBrightness = s["MU.auto_brightness"] == "1"
+	return d, nil
+}
+
+// SetDisplay changes the backlight setup, leaving the other menu settings
+// as they are.
+func (r *Radio) SetDisplay(d Display) error {
+	if d.Brightness < 0 || d.Brightness > MaxBrightness {
+		return fmt.Errorf("error: brightness %d is not between 0 and %d", d.Brightness, MaxBrightness)
+	}
+	auto := "0"
+	if d.AutoBrightness {
+		auto = "1"
+	}
+	_, err := r.ApplySettings(Settings{"MU.brightness_level": strconv.Itoa(d.Brightness), "MU.auto_brightness": auto})
+	return err
+}
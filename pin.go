@@ -0,0 +1,38 @@
+package kenwoodutil
+
+import "fmt"
+
+// Pins returns the pinned channels of the dump.
+func (d *Dump) Pins() map[uint16]bool {
+	pins := map[uint16]bool{}
+	for n, m := range d.Meta {
+		if m.Pinned {
+			pins[n] = true
+		}
+	}
+	return pins
+}
+
+// SetPinned pins or unpins a channel. Only channels in the dump can be
+// pinned.
+func (d *Dump) SetPinned(number uint16, pinned bool) error {
+	if _, ok := d.Channel(number); !ok && pinned {
+		return fmt.Errorf("error pinning channel %03d: %w", number, ErrEmptyChannel)
+	}
+	if !pinned && d.Meta[number] == nil {
+		return nil
+	}
+	d.ChannelMeta(number).Pinned = pinned
+	return nil
+}
+
+// CheckPinned refuses changes to any of channels that is pinned.
+func (d *Dump) CheckPinned(channels []uint16) error {
+	pins := d.Pins()
+	for _, n := range channels {
+		if pins[n] {
+			return fmt.Errorf("error changing channel %03d: %w", n, ErrPinned)
+		}
+	}
+	return nil
+}
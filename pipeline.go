@@ -0,0 +1,123 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// pipelineChunk is how many channels are read in one pipelined run; a
+// failed run is read again channel by channel.
+const pipelineChunk = 50
+
+// ReadChannels reads channels first..last keeping up to depth ME and MN
+// queries in flight instead of waiting for every reply. Replies are matched
+// to queries by order and checked against the channel number they carry.
+// After an error the replies still in flight are dropped before the next
+// command.
+func (r *Radio) ReadChannels(first, last, depth int) (v []MemoryEntry, err error) {
+	span := r.startSpan("read channels", "first", fmt.Sprint(first), "last", fmt.Sprint(last))
+	defer func() { r.endSpan(span, err) }()
+	if depth < 1 {
+		depth = 1
+	}
+
+	var queries []string
+	for ch := first; ch <= last; ch++ {
+		queries = append(queries, fmt.Sprintf(MECommandFormat, ch), fmt.Sprintf(MNCommandFormat, ch))
+	}
+	replies := make([]string, len(queries))
+	sentAt := make([]time.Time, len(queries))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stale {
+		if err := r.drain(); err != nil {
+			return nil, err
+		}
+		r.stale = false
+	}
+	sent, echoed := 0, 0
+	for got := range queries {
+		for ; sent < len(queries) && sent-got < depth; sent++ {
+			sentAt[sent] = time.Now()
+			if err := r.WriteString(queries[sent]); err != nil {
+				r.stale = true
+				return nil, err
+			}
+		}
+		// an interface echoing what is sent echoes the queries in flight
+		// in the order they went out, between the replies
+		line, err := r.ReadString()
+		for err == nil && r.Echo && echoed < sent && line == queries[echoed] {
+			echoed++
+			line, err = r.ReadString()
+		}
+		if err == nil {
+			err = checkPipelinedReply(queries[got], line)
+		}
+		r.emit(EventCommand, strings.TrimSuffix(queries[got], "\r"), strings.TrimSuffix(line, "\r"), sentAt[got], 0, err)
+		if err != nil {
+			r.stale = true
+			return nil, err
+		}
+		replies[got] = line
+	}
+
+	for i := 0; i < len(replies); i += 2 {
+		m, err := parseChannel(first+i/2, replies[i], replies[i+1])
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, m)
+	}
+	return v, nil
+}
+
+// checkPipelinedReply makes sure line answers query, so that a lost or
+// extra reply does not shift every following channel.
+func checkPipelinedReply(query, line string) error {
+	if strings.HasPrefix(line, "?") {
+		return fmt.Errorf("error writing \"%s\" to radio: %w", query, ErrRadioNAK)
+	}
+	if !validReply(query, line) {
+		return fmt.Errorf("error writing \"%s\" to radio: got %q: %w", query, line, ErrGarbage)
+	}
+	if line != "N\r" && !strings.HasPrefix(line, strings.TrimSuffix(query, "\r")+",") {
+		return fmt.Errorf("error writing \"%s\" to radio: reply %q is for another channel: %w", query, line, ErrGarbage)
+	}
+	return nil
+}
+
+func (r *Radio) readMemoryPipelined(from int, done func(channel int)) error {
+	log.Debug().Int("depth", r.Tuning.PipelineDepth).Msg("reading memory pipelined")
+	for first := from; first < len(r.Memory); first += pipelineChunk {
+		last := first + pipelineChunk - 1
+		if last >= len(r.Memory) {
+			last = len(r.Memory) - 1
+		}
+		channels, err := r.ReadChannels(first, last, r.Tuning.PipelineDepth)
+		if err == nil {
+			copy(r.Memory[first:], channels)
+			for i := first; done != nil && i <= last; i++ {
+				done(i)
+			}
+			continue
+		}
+		log.Warn().Err(err).Int("first", first).Msg("pipelined read failed, reading channel by channel")
+		if err := r.Resync(); err != nil {
+			return fmt.Errorf("error reading memory: %w", err)
+		}
+		for i := first; i <= last; i++ {
+			if r.Memory[i], err = r.readChannelRetrying(i); err != nil {
+				return fmt.Errorf("error reading memory: %w", err)
+			}
+			if done != nil {
+				done(i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package kenwoodutil
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	PMFormat        = "PM %1d"
+	PMCommandFormat = "PM\r"
+	PMProfiles      = 5
+)
+
+// PMProfile is the state captured by one programmable memory of the
+// TM-D710/TM-V71: VFOs of both bands, band control and menu settings.
+type PMProfile struct {
+	Number      int
+	VFO         [2]VFO
+	ControlBand int
+	PTTBand     int
+	Settings    Settings `json:",omitempty"`
+}
+
+func (r *Radio) GetPM() (pm int, err error) {
+	line, err := r.WriteReadString(PMCommandFormat)
+	if err != nil {
+		return 0, fmt.Errorf("error reading programmable memory: %w", err)
+	}
+	if _, err := fmt.Sscanf(line, PMFormat, &pm); err != nil {
+		return 0, parseError("programmable memory line", line, "")
+	}
+	return pm, nil
+}
+
+func (r *Radio) SelectPM(pm int) error {
+	if _, err := r.WriteReadString(fmt.Sprintf(PMFormat, pm) + "\r"); err != nil {
+		return fmt.Errorf("error selecting programmable memory %d: %w", pm, err)
+	}
+	return nil
+}
+
+func (r *Radio) readPMProfile(pm int) (p PMProfile, err error) {
+	if err := r.SelectPM(pm); err != nil {
+		return p, err
+	}
+	p.Number = pm
+	for band := 0; band < 2; band++ {
+		if p.VFO[band], err = r.GetVFO(band); err != nil {
+			return p, err
+		}
+	}
+	if p.ControlBand, p.PTTBand, err = r.GetBand(); err != nil {
+		return p, err
+	}
+	if p.Settings, err = r.ReadSettings(); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+func (r *Radio) writePMProfile(p PMProfile) error {
+	if err := r.SelectPM(p.Number); err != nil {
+		return err
+	}
+	for _, v := range p.VFO {
+		if err := r.SetVFO(v); err != nil {
+			return err
+		}
+	}
+	if err := r.SetBands(p.ControlBand, p.PTTBand); err != nil {
+		return err
+	}
+	_, err := r.ApplySettings(p.Settings)
+	return err
+}
+
+// ReadPMProfiles reads all programmable memories and reselects the one that
+// was active before.
+func (r *Radio) ReadPMProfiles() (v []PMProfile, err error) {
+	span := r.startSpan("read programmable memories")
+	defer func() { r.endSpan(span, err) }()
+	active, err := r.GetPM()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if serr := r.SelectPM(active); serr != nil && err == nil {
+			err = serr
+		}
+	}()
+	for pm := 1; pm <= PMProfiles; pm++ {
+		log.Info().Int("pm", pm).Msg("reading programmable memory")
+		p, err := r.readPMProfile(pm)
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, p)
+	}
+	return v, nil
+}
+
+// WritePMProfiles restores programmable memories, relying on the radio
+// storing changes into the selected PM, and reselects the one that was
+// active before.
+func (r *Radio) WritePMProfiles(profiles []PMProfile) (err error) {
+	span := r.startSpan("write programmable memories")
+	defer func() { r.endSpan(span, err) }()
+	active, err := r.GetPM()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if serr := r.SelectPM(active); serr != nil && err == nil {
+			err = serr
+		}
+	}()
+	for _, p := range profiles {
+		if p.Number < 1 || p.Number > PMProfiles {
+			return fmt.Errorf("error restoring programmable memory: invalid profile number %d", p.Number)
+		}
+		log.Info().Int("pm", p.Number).Msg("restoring programmable memory")
+		if err := r.writePMProfile(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
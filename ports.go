@@ -0,0 +1,86 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+var ProbeBaudRates = []int{9600, 19200, 38400, 57600}
+
+func ListPorts() ([]*enumerator.PortDetails, error) {
+	ports, err := enumerator.GetDetailedPortsList()
+	if err == nil {
+		return ports, nil
+	}
+	log.Debug().Err(err).Msg("detailed port enumeration failed, falling back to plain list")
+	names, err := serial.GetPortsList()
+	if err != nil {
+		return nil, fmt.Errorf("error enumerating serial ports: %w", err)
+	}
+	ports = nil
+	for _, n := range names {
+		ports = append(ports, &enumerator.PortDetails{Name: n})
+	}
+	return ports, nil
+}
+
+func ProbePort(path string, baudrate int, mode SerialMode) (model string, err error) {
+	p, err := OpenPort(path, baudrate, mode)
+	if err != nil {
+		return "", err
+	}
+	defer p.Close()
+	if err := p.SetReadTimeout(100 * time.Millisecond); err != nil {
+		return "", fmt.Errorf("error setting read timeout on %s: %w", path, err)
+	}
+	p.ResetInputBuffer()
+	if _, err := p.Write([]byte(IDCommandFormat)); err != nil {
+		return "", fmt.Errorf("error writing to %s: %w", path, err)
+	}
+
+	var reply []byte
+	buf := make([]byte, 64)
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		n, err := p.Read(buf)
+		if err != nil {
+			return "", fmt.Errorf("error reading from %s: %w", path, err)
+		}
+		reply = append(reply, buf[:n]...)
+		for _, line := range strings.Split(string(reply), "\r") {
+			if _, err := fmt.Sscanf(line, IDFormat, &model); err == nil {
+				return model, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no Kenwood answered on %s at %d baud", path, baudrate)
+}
+
+func DetectRadio(mode SerialMode) (path string, baudrate int, model string, err error) {
+	ports, err := ListPorts()
+	if err != nil {
+		return "", 0, "", err
+	}
+	// USB adapters are by far the most likely candidates, try them first
+	sort.SliceStable(ports, func(i, j int) bool {
+		return ports[i].IsUSB && !ports[j].IsUSB
+	})
+	for _, p := range ports {
+		for _, baud := range ProbeBaudRates {
+			log.Debug().Str("port", p.Name).Int("baud", baud).Msg("probing")
+			model, err := ProbePort(p.Name, baud, mode)
+			if err == nil {
+				return p.Name, baud, model, nil
+			}
+			log.Debug().Err(err).Msg("probe")
+		}
+	}
+	return "", 0, "", fmt.Errorf("no radio found on any of %d serial ports", len(ports))
+}
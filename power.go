@@ -0,0 +1,95 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+const (
+	PCFormat        = "PC %1d,%1d"
+	PCCommandFormat = "PC %1d\r"
+	PSFormat        = "PS %1d"
+	PSCommandFormat = "PS\r"
+)
+
+// PowerLevel is a transmit power level. Channels of models without a power
+// level per channel leave it PowerUnset. On the wire it is one less.
+type PowerLevel uint8
+
+const (
+	PowerUnset PowerLevel = iota
+	PowerHigh
+	PowerMid
+	PowerLow
+)
+
+var powerLevelNames = []string{"", "high", "mid", "low"}
+
+func (p PowerLevel) String() string {
+	if int(p) < len(powerLevelNames) {
+		return powerLevelNames[p]
+	}
+	return fmt.Sprintf("PowerLevel(%d)", p)
+}
+
+func ParsePowerLevel(s string) (PowerLevel, error) {
+	switch strings.ToLower(s) {
+	case "high", "h", "hi":
+		return PowerHigh, nil
+	case "mid", "m", "medium":
+		return PowerMid, nil
+	case "low", "l", "lo":
+		return PowerLow, nil
+	}
+	return PowerUnset, fmt.Errorf("error: unknown power level %q, expected high, mid or low", s)
+}
+
+func (r *Radio) GetPower(band int) (PowerLevel, error) {
+	v, err := r.queryInt(PCCommandFormat, PCFormat, band)
+	if err != nil {
+		return PowerUnset, fmt.Errorf("error reading power level of band %s: %w", units.BandName(band), err)
+	}
+	if v < 0 || v >= len(powerLevelNames)-1 {
+		return PowerUnset, parseError("power level", fmt.Sprint(v), "")
+	}
+	return PowerLevel(v + 1), nil
+}
+
+func (r *Radio) SetPower(band int, p PowerLevel) error {
+	if p == PowerUnset || int(p) >= len(powerLevelNames) {
+		return fmt.Errorf("error: no power level to set")
+	}
+	if _, err := r.WriteReadString(fmt.Sprintf(PCFormat, band, int(p)-1) + "\r"); err != nil {
+		return fmt.Errorf("error setting power level of band %s to %s: %w", units.BandName(band), p, err)
+	}
+	return nil
+}
+
+// PowerOn reports whether the radio is switched on. A radio that is off
+// does not answer at all on most models.
+func (r *Radio) PowerOn() (bool, error) {
+	line, err := r.WriteReadString(PSCommandFormat)
+	if err != nil {
+		return false, fmt.Errorf("error reading power state: %w", err)
+	}
+	var on int
+	if _, err := fmt.Sscanf(line, PSFormat, &on); err != nil {
+		return false, parseError("power state", line, "")
+	}
+	return on == 1, nil
+}
+
+// SetPowerOn switches the radio on or off. Only some models can be switched
+// on remotely, and only when their PC port stays powered.
+func (r *Radio) SetPowerOn(on bool) error {
+	v := 0
+	if on {
+		v = 1
+	}
+	if _, err := r.WriteReadString(fmt.Sprintf(PSFormat, v) + "\r"); err != nil {
+		return fmt.Errorf("error switching radio power: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package kenwoodutil
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProtocolCommand documents a command of the PC port protocol. Query, Set
+// and Reply name the comma separated parameters of the query, of the set
+// command and of the reply; a command without Set is read only.
+type ProtocolCommand struct {
+	Mnemonic    string
+	Description string
+	Query       []string `json:",omitempty"`
+	Set         []string `json:",omitempty"`
+	Reply       []string
+	// Probe is a query telling whether a radio knows the command, empty
+	// for commands changing what the radio does, like TX
+	Probe string `json:",omitempty"`
+	// Models the command is known on, by model or family name; empty for
+	// every model
+	Models []string `json:",omitempty"`
+	// Timeout replaces ReadTimeout for commands the radio is slow to answer
+	Timeout time.Duration `json:",omitempty"`
+}
+
+type protocolSpec struct {
+	ProtocolCommand
+	Timeout string
+}
+
+//go:embed protocol.json
+var builtinProtocol []byte
+
+var protocolTable []ProtocolCommand
+
+func init() {
+	var specs []protocolSpec
+	if err := json.Unmarshal(builtinProtocol, &specs); err != nil {
+		panic(fmt.Sprintf("built in protocol.json: %v", err))
+	}
+	for _, s := range specs {
+		c := s.ProtocolCommand
+		if s.Timeout != "" {
+			t, err := time.ParseDuration(s.Timeout)
+			if err != nil {
+				panic(fmt.Sprintf("built in protocol.json: %s: %v", c.Mnemonic, err))
+			}
+			c.Timeout = t
+		}
+		protocolTable = append(protocolTable, c)
+	}
+	sort.Slice(protocolTable, func(i, j int) bool { return protocolTable[i].Mnemonic < protocolTable[j].Mnemonic })
+}
+
+// LookupCommand returns the catalog entry of mnemonic.
+func LookupCommand(mnemonic string) (ProtocolCommand, bool) {
+	for _, c := range protocolTable {
+		if c.Mnemonic == mnemonic {
+			return c, true
+		}
+	}
+	return ProtocolCommand{}, false
+}
+
+// ProtocolCommands returns the commands known on model whose mnemonic
+// starts with prefix. An empty model returns those of every model.
+func ProtocolCommands(model, prefix string) []ProtocolCommand {
+	var v []ProtocolCommand
+	for _, c := range protocolTable {
+		if strings.HasPrefix(c.Mnemonic, strings.ToUpper(prefix)) && (model == "" || c.Supports(model)) {
+			v = append(v, c)
+		}
+	}
+	return v
+}
+
+// Supports reports whether c is known on model.
+func (c ProtocolCommand) Supports(model string) bool {
+	if len(c.Models) == 0 {
+		return true
+	}
+	family := CapabilitiesFor(model).Family
+	for _, m := range c.Models {
+		if strings.HasPrefix(model, m) || m == family {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage returns the query form of c, like "MC band", followed by the set
+// form, like "MC band,channel", if it has one.
+func (c ProtocolCommand) Usage() []string {
+	form := func(params []string) string {
+		if len(params) == 0 {
+			return c.Mnemonic
+		}
+		return c.Mnemonic + " " + strings.Join(params, ",")
+	}
+	v := []string{form(c.Query)}
+	if c.Set != nil {
+		v = append(v, form(c.Set))
+	}
+	return v
+}
+
+// commandTimeout is the reply timeout of command.
+func commandTimeout(command string) time.Duration {
+	if c, ok := LookupCommand(CommandMnemonic(command)); ok && c.Timeout > 0 {
+		return c.Timeout
+	}
+	return ReadTimeout
+}
@@ -0,0 +1,65 @@
+package kenwoodutil
+
+import (
+	"os"
+	"os/user"
+	"time"
+)
+
+type Provenance struct {
+	ImportedFrom string     `json:",omitempty"`
+	EditedBy     string     `json:",omitempty"`
+	Edited       *time.Time `json:",omitempty"`
+	LastWrite    *time.Time `json:",omitempty"`
+}
+
+// ChannelMeta is the sidecar metadata the tool keeps about a channel next
+// to what the radio itself stores.
+type ChannelMeta struct {
+	Provenance Provenance
+	// Pinned channels are left alone by imports and bulk edits unless
+	// forced.
+	Pinned bool `json:",omitempty"`
+	// Heard is set on channels stored by a band survey.
+	Heard *Heard `json:",omitempty"`
+	// Tags are labels like SOTA to select channels by, as export -only
+	// does.
+	Tags []string `json:",omitempty"`
+}
+
+func (d *Dump) ChannelMeta(number uint16) *ChannelMeta {
+	if d.Meta == nil {
+		d.Meta = map[uint16]*ChannelMeta{}
+	}
+	m, ok := d.Meta[number]
+	if !ok {
+		m = &ChannelMeta{}
+		d.Meta[number] = m
+	}
+	return m
+}
+
+func (d *Dump) RecordImport(number uint16, source string) {
+	d.ChannelMeta(number).Provenance.ImportedFrom = source
+}
+
+func (d *Dump) RecordEdit(number uint16) {
+	now := time.Now()
+	p := &d.ChannelMeta(number).Provenance
+	p.EditedBy = currentUser()
+	p.Edited = &now
+}
+
+func (d *Dump) RecordWrite(number uint16, t time.Time) {
+	d.ChannelMeta(number).Provenance.LastWrite = &t
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,94 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TXCommandFormat = "TX\r"
+	RXCommandFormat = "RX\r"
+)
+
+var ErrNoTXWatchdog = errors.New("refusing to transmit without MaxTX watchdog set")
+
+// txWatchdogRetry is how soon the TX watchdog tries again when the radio
+// did not take the RX command.
+const txWatchdogRetry = time.Second
+
+// SetPTT keys or unkeys the transmitter on the PTT band. Keying up requires
+// MaxTX to be set; the radio is put back to receive after MaxTX even if
+// nobody calls SetPTT(false). Keying up again restarts the watchdog. The
+// watchdog stays armed until the radio confirms receive, and is armed when
+// the TX command times out, as the radio may have keyed anyway.
+func (r *Radio) SetPTT(on bool) error {
+	r.pttMu.Lock()
+	defer r.pttMu.Unlock()
+	if !on {
+		if _, err := r.WriteReadString(RXCommandFormat); err != nil {
+			if r.txTimer == nil && r.MaxTX > 0 {
+				r.armTXWatchdog(r.MaxTX)
+			}
+			return fmt.Errorf("error returning to receive: %w", err)
+		}
+		if r.txTimer != nil {
+			r.txTimer.Stop()
+			r.txTimer = nil
+		}
+		atomic.StoreInt32(&r.transmitting, 0)
+		log.Info().Msg("PTT released")
+		return nil
+	}
+
+	if r.MaxTX <= 0 {
+		return ErrNoTXWatchdog
+	}
+	if _, err := r.WriteReadString(TXCommandFormat); err != nil {
+		if errors.Is(err, ErrTimeout) {
+			r.armTXWatchdog(r.MaxTX)
+			atomic.StoreInt32(&r.transmitting, 1)
+		}
+		return fmt.Errorf("error keying transmitter: %w", err)
+	}
+	r.armTXWatchdog(r.MaxTX)
+	atomic.StoreInt32(&r.transmitting, 1)
+	log.Warn().Dur("max", r.MaxTX).Msg("PTT pressed, transmitting")
+	return nil
+}
+
+// armTXWatchdog puts the radio back to receive after d, replacing the
+// watchdog running. It tries again until the radio takes the RX command.
+// r.pttMu must be held.
+func (r *Radio) armTXWatchdog(d time.Duration) {
+	if r.txTimer != nil {
+		r.txTimer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		r.pttMu.Lock()
+		defer r.pttMu.Unlock()
+		// released or keyed up again while the timer fired
+		if r.txTimer != timer {
+			return
+		}
+		r.txTimer = nil
+		log.Warn().Dur("max", r.MaxTX).Msg("TX watchdog expired")
+		if _, err := r.WriteReadString(RXCommandFormat); err != nil {
+			log.Error().Err(err).Msg("TX watchdog could not return radio to receive, trying again")
+			r.armTXWatchdog(txWatchdogRetry)
+			return
+		}
+		atomic.StoreInt32(&r.transmitting, 0)
+	})
+	r.txTimer = timer
+}
+
+// Transmitting reports whether the transmitter was keyed with SetPTT and
+// not released yet.
+func (r *Radio) Transmitting() bool {
+	return atomic.LoadInt32(&r.transmitting) == 1
+}
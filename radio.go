@@ -0,0 +1,672 @@
+package kenwoodutil
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// Radio is a connection to a radio. Its methods may be called from several
+// goroutines: commands go to the radio one at a time and every one gets its
+// own reply. Memory is not guarded and belongs to the caller.
+type Radio struct {
+	Port     Port
+	PortPath string
+	BaudRate int
+	Serial   SerialMode
+	PortRW   *bufio.ReadWriter
+	Model    string
+	Memory   []MemoryEntry
+	Echo     bool
+	Tuning   Tuning
+	MaxTX    time.Duration
+	Tracer   *Tracer
+	// Recorder, when set, records the traffic with the radio.
+	Recorder *SessionRecorder
+	// Hooks get every command sent, its reply, latency and retries. They
+	// are to be set before the radio is used.
+	Hooks []ProtocolHook
+	// BackupDir, when set, is where channels are backed up before they are
+	// written or cleared, see Backup.
+	BackupDir string
+	// Licence, when set, refuses writing channels that transmit outside of
+	// it.
+	Licence *Licence
+	// Support, when set, tells which commands the radio answered to
+	// ProbeSupport. Without it the command catalog is trusted.
+	Support Support
+
+	// mu queues commands, readTimeout is the reply timeout the port is set
+	// to and stale is set after a reply did not come in time, so that it is
+	// not taken for the reply to the next command.
+	mu           sync.Mutex
+	readTimeout  time.Duration
+	stale        bool
+	transmitting int32
+
+	// pttMu serializes keying and releasing and guards txTimer, the TX
+	// watchdog of the current transmission.
+	pttMu   sync.Mutex
+	txTimer *time.Timer
+
+	// stats has its own lock, to be read while a command waits for its
+	// reply.
+	statsMu sync.Mutex
+	stats   CommandStats
+
+	// spanMu guards span, the innermost operation of the radio being
+	// traced, the parent of the spans of the commands it sends.
+	spanMu sync.Mutex
+	span   *Span
+
+	backup *radioBackup
+	// byFrequency indexes the occupied channels of Memory by RX frequency,
+	// built by ByFrequency and dropped by reindex when Memory changes.
+	byFrequency map[uint32][]uint16
+}
+
+func (r *Radio) Connect() error {
+	var err error
+	r.Port, err = OpenPort(r.PortPath, r.BaudRate, r.Serial)
+	if err != nil {
+		return err
+	}
+	if r.Recorder != nil {
+		r.Port = r.Recorder.Wrap(r.Port, r.BaudRate)
+	}
+	if err := r.Port.SetReadTimeout(ReadTimeout); err != nil {
+		return fmt.Errorf("error setting read timeout: %w", err)
+	}
+	r.readTimeout = ReadTimeout
+	r.PortRW = bufio.NewReadWriter(
+		bufio.NewReader(timeoutReader{r.Port}),
+		bufio.NewWriter(r.Port),
+	)
+	return nil
+}
+
+// Close closes the port. Commands waiting for their turn fail once it is
+// closed.
+func (r *Radio) Close() error {
+	// nothing can put the radio back to receive once the port is closed
+	r.pttMu.Lock()
+	if r.txTimer != nil {
+		r.txTimer.Stop()
+		r.txTimer = nil
+	}
+	r.pttMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Port.Close()
+}
+
+func (r *Radio) WriteString(command string) error {
+	if r.Tuning.Pacing > 0 {
+		time.Sleep(r.Tuning.Pacing)
+	}
+	_, err := r.PortRW.WriteString(command)
+	if err != nil {
+		return fmt.Errorf("error writing string %s to radio: %w", command, err)
+	}
+	err = r.PortRW.Flush()
+	if err != nil {
+		return fmt.Errorf("error flushing serial IO while writing string %s to radio: %w", command, err)
+	}
+	return nil
+}
+
+func (r *Radio) ReadString() (string, error) {
+	str, err := r.PortRW.ReadString('\r')
+	if err != nil {
+		return "", fmt.Errorf("error reading from radio: %w", err)
+	}
+	return str, nil
+}
+
+// WriteReadString sends command and returns the reply to it. Concurrent
+// calls wait for their turn.
+func (r *Radio) WriteReadString(command string) (line string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.exec(command)
+}
+
+// exec is WriteReadString with r.mu held.
+func (r *Radio) exec(command string) (line string, err error) {
+	span := r.Tracer.start(r.operation(), CommandMnemonic(command), otlpKindClient, []string{"command", strings.TrimSuffix(command, "\r")})
+	start := time.Now()
+	defer func() {
+		span.SetAttr("reply", strings.TrimSuffix(line, "\r"))
+		span.End(err)
+		r.emit(EventCommand, strings.TrimSuffix(command, "\r"), strings.TrimSuffix(line, "\r"), start, 0, err)
+		if errors.Is(err, ErrTimeout) {
+			r.stale = true
+		}
+		r.count(err)
+	}()
+	if r.stale {
+		if err := r.drain(); err != nil {
+			return "", err
+		}
+		r.stale = false
+	}
+	timeout := commandTimeout(command)
+	if timeout != r.readTimeout {
+		if err := r.Port.SetReadTimeout(timeout); err != nil {
+			return "", fmt.Errorf("error setting read timeout: %w", err)
+		}
+		r.readTimeout = timeout
+	}
+	err = r.WriteString(command)
+	if err != nil {
+		return "", fmt.Errorf("error writing to radio: %w", err)
+	}
+	if r.Echo {
+		echo, err := r.ReadString()
+		if err != nil {
+			return "", fmt.Errorf("error reading echo from radio: %w", err)
+		}
+		if echo != command {
+			log.Warn().Str("sent", command).Str("echo", echo).Msg("echo does not match sent command")
+		}
+	}
+	line, err = r.ReadString()
+	if err != nil {
+		return "", fmt.Errorf("error reading from radio: %w", err)
+	}
+	if strings.HasPrefix(line, "?") {
+		return "", fmt.Errorf("error writing \"%s\" to radio: %w", command, ErrRadioNAK)
+	}
+	if !validReply(command, line) {
+		return "", fmt.Errorf("error writing \"%s\" to radio: got %q: %w", command, line, ErrGarbage)
+	}
+	return line, nil
+}
+
+// CommandStats counts the commands sent to a radio and how they failed.
+type CommandStats struct {
+	Commands uint64
+	Timeouts uint64
+	NAKs     uint64
+	Garbage  uint64
+	Errors   uint64
+	Resyncs  uint64
+}
+
+func (r *Radio) count(err error) {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	r.stats.Commands++
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrTimeout):
+		r.stats.Timeouts++
+	case errors.Is(err, ErrRadioNAK):
+		r.stats.NAKs++
+	case errors.Is(err, ErrGarbage):
+		r.stats.Garbage++
+	default:
+		r.stats.Errors++
+	}
+}
+
+// Stats returns the command counts since the radio was opened.
+func (r *Radio) Stats() CommandStats {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	return r.stats
+}
+
+func (r *Radio) Raw(command string) (string, error) {
+	line, err := r.WriteReadString(strings.TrimRight(command, "\r\n") + "\r")
+	return strings.TrimSuffix(line, "\r"), err
+}
+
+func validReply(command, line string) bool {
+	for i := 0; i < len(line); i++ {
+		if (line[i] < 0x20 || line[i] > 0x7e) && line[i] != '\r' {
+			return false
+		}
+	}
+	if line == "N\r" {
+		return true
+	}
+	return strings.HasPrefix(line, CommandMnemonic(command))
+}
+
+func CommandMnemonic(command string) string {
+	return strings.TrimSuffix(strings.SplitN(command, " ", 2)[0], "\r")
+}
+
+// recoverable reports whether err left the conversation with the radio out
+// of step, so that a Resync and a retry may help.
+func recoverable(err error) bool {
+	return errors.Is(err, ErrGarbage) || errors.Is(err, ErrTimeout)
+}
+
+// drain drops whatever the radio sent that was not read yet.
+func (r *Radio) drain() error {
+	if err := r.Port.ResetInputBuffer(); err != nil {
+		return fmt.Errorf("error flushing serial port: %w", err)
+	}
+	r.PortRW.Reader.Reset(timeoutReader{r.Port})
+	r.PortRW.Writer.Reset(r.Port)
+	return nil
+}
+
+func (r *Radio) Resync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	log.Warn().Msg("lost step with radio, resynchronizing")
+	r.statsMu.Lock()
+	r.stats.Resyncs++
+	r.statsMu.Unlock()
+	for attempt := 0; attempt < 3; attempt++ {
+		r.emit(EventResync, "", "", time.Now(), attempt+1, nil)
+		if err := r.drain(); err != nil {
+			return err
+		}
+		line, err := r.exec(IDCommandFormat)
+		if err != nil {
+			continue
+		}
+		if r.Model == "" || line == fmt.Sprintf(IDFormat, r.Model)+"\r" {
+			log.Info().Msg("conversation with radio is healthy again")
+			return nil
+		}
+	}
+	return fmt.Errorf("error resynchronizing with radio: no sane ID reply after 3 attempts")
+}
+
+func (r *Radio) Identify() error {
+	line, err := r.WriteReadString(IDCommandFormat)
+	if err != nil {
+		return fmt.Errorf("error while reading ident sequence from radio: %w", err)
+	}
+	if line == IDCommandFormat && !r.Echo {
+		log.Info().Msg("interface echoes transmitted characters, suppressing echo")
+		r.Echo = true
+		line, err = r.ReadString()
+		if err != nil {
+			return fmt.Errorf("error while reading ident sequence from radio: %w", err)
+		}
+	}
+	_, err = fmt.Sscanf(line, IDFormat, &r.Model)
+	if err != nil {
+		return fmt.Errorf("error while parsing identification sequence from radio: %w", err)
+	}
+	return nil
+}
+
+// ReadSerial returns the serial number the radio answers AE with, like
+// "AE B1234567,K".
+func (r *Radio) ReadSerial() (string, error) {
+	line, err := r.WriteReadString(AECommandFormat)
+	if err != nil {
+		return "", fmt.Errorf("error reading serial number: %w", err)
+	}
+	if !strings.HasPrefix(line, "AE ") {
+		return "", parseError("serial number", line, "expected AE")
+	}
+	return strings.SplitN(strings.TrimPrefix(line, "AE "), ",", 2)[0], nil
+}
+
+// ReadFirmware returns the firmware versions the radio answers FV with,
+// like "1.00,2.10,A,1".
+func (r *Radio) ReadFirmware() (string, error) {
+	line, err := r.WriteReadString(FVCommandFormat)
+	if err != nil {
+		return "", fmt.Errorf("error reading firmware version: %w", err)
+	}
+	if !strings.HasPrefix(line, "FV 0,") {
+		return "", parseError("firmware version", line, "expected FV 0")
+	}
+	return strings.TrimPrefix(line, "FV 0,"), nil
+}
+
+// Info describes the radio for dumps. What the radio does not answer is
+// left out, models without any of the commands are described by model
+// alone.
+func (r *Radio) Info() *RadioInfo {
+	c := r.Capabilities()
+	info := &RadioInfo{Model: r.Model, GPS: c.HasGPS}
+	serial, err := r.ReadSerial()
+	if err != nil {
+		log.Debug().Err(err).Msg("no serial number")
+	}
+	info.Serial = serial
+	if info.Firmware, err = r.ReadFirmware(); err != nil {
+		log.Debug().Err(err).Msg("no firmware version")
+	}
+	if c.HasTNC {
+		if _, band, err := r.GetTNC(); err != nil {
+			log.Debug().Err(err).Msg("no TNC")
+		} else {
+			info.TNC, info.DataBand = true, units.BandName(band)
+		}
+	}
+	return info
+}
+
+func (r *Radio) ReadChannel(channel int) (m MemoryEntry, e error) {
+	chline, err := r.WriteReadString(
+		fmt.Sprintf(MECommandFormat, channel),
+	)
+	if err != nil {
+		return MemoryEntry{}, fmt.Errorf("error while reading channel: %w", err)
+	}
+	nameline, err := r.WriteReadString(
+		fmt.Sprintf(MNCommandFormat, channel),
+	)
+	if err != nil {
+		return MemoryEntry{}, fmt.Errorf("error while reading channel name: %w", err)
+	}
+	return parseChannel(channel, chline, nameline)
+}
+
+// parseChannel builds a channel from its ME and MN replies.
+func parseChannel(channel int, chline, nameline string) (m MemoryEntry, err error) {
+	err = m.ReadChannelLine(chline)
+	if err != nil {
+		return MemoryEntry{}, fmt.Errorf("error reading channel %d: %w", channel, err)
+	}
+	err = m.ReadNameLine(nameline)
+	if err != nil {
+		return MemoryEntry{}, fmt.Errorf("error reading channel %d name: %w", channel, err)
+	}
+	m.Canonicalize()
+	return m, nil
+}
+
+type WriteSummary struct {
+	Written      int
+	NamesSkipped []uint16
+}
+
+func (r *Radio) Capabilities() Capabilities {
+	return CapabilitiesFor(r.Model)
+}
+
+func (r *Radio) WriteChannel(channel int) (nameSkipped bool, err error) {
+	ch, ok := r.Lookup(channel)
+	if !ok {
+		return false, fmt.Errorf("error writing channel %d: %w", channel, ErrEmptyChannel)
+	}
+	if !r.Capabilities().ChannelPower {
+		ch.Power = PowerUnset
+	}
+	if err := r.Backup([]int{channel}); err != nil {
+		return false, err
+	}
+
+	_, err = r.WriteReadString(ch.ClearChannelLine() + "\r")
+	if err != nil {
+		return false, fmt.Errorf("error clearing channel %d before write: %w", channel, err)
+	}
+	chline := ch.WriteChannelLine() + "\r"
+	_, err = r.WriteReadString(chline)
+	if err != nil {
+		return false, fmt.Errorf("error writing channel %d data to radio: %w", channel, err)
+	}
+	/*
+		if !strings.HasPrefix(str, "ME") {
+			return fmt.Errorf("error writing channel %d data to radio: did not got ME answer", channel)
+		}
+	*/
+
+	if name := r.Capabilities().FitName(ch.Name); name != ch.Name {
+		log.Debug().Int("channel", channel).Str("name", ch.Name).Str("written as", name).Msg("name altered to fit the radio")
+		ch.Name = name
+	}
+	if !r.Capabilities().SupportsName(ch.Kind) {
+		log.Info().Int("channel", channel).Str("kind", ch.Kind.String()).Msg("model does not support names on this channel, skipping name")
+		return true, nil
+	}
+	nameline := ch.WriteNameLine() + "\r"
+	_, err = r.WriteReadString(nameline)
+	if errors.Is(err, ErrRadioNAK) {
+		log.Warn().Int("channel", channel).Msg("radio refused channel name, skipping name")
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error writing channel %d name to radio: %w", channel, err)
+	}
+	/*
+		if !strings.HasPrefix(str, "MN") {
+			return fmt.Errorf("error writing channel %d data to radio: did not got MN answer", channel)
+		}
+	*/
+
+	return false, nil
+}
+
+func (r *Radio) WritePlan() (v []string) {
+	caps := r.Capabilities()
+	for _, m := range r.OccupedChannels() {
+		if !caps.ChannelPower {
+			m.Power = PowerUnset
+		}
+		v = append(v, m.ClearChannelLine(), m.WriteChannelLine())
+		if caps.SupportsName(m.Kind) {
+			m.Name = caps.FitName(m.Name)
+			v = append(v, m.WriteNameLine())
+		}
+	}
+	return v
+}
+
+// readChannelRetrying reads a channel, resynchronizing and retrying once if
+// the conversation went out of step.
+func (r *Radio) readChannelRetrying(channel int) (MemoryEntry, error) {
+	m, err := r.ReadChannel(channel)
+	if recoverable(err) {
+		r.emit(EventRetry, strings.TrimSuffix(fmt.Sprintf(MECommandFormat, channel), "\r"), "", time.Now(), 1, err)
+		if err := r.Resync(); err != nil {
+			return MemoryEntry{}, err
+		}
+		m, err = r.ReadChannel(channel)
+	}
+	return m, err
+}
+
+func (r *Radio) ReadMemory() error {
+	return r.ReadMemoryFrom(0, nil)
+}
+
+// ReadMemoryFrom reads channels first to the last one into r.Memory, calling done, if
+// set, once a channel is read.
+func (r *Radio) ReadMemoryFrom(first int, done func(channel int)) (err error) {
+	span := r.startSpan("read memory", "first", fmt.Sprint(first))
+	defer func() { r.endSpan(span, err) }()
+	defer r.reindex()
+	if first != 0 {
+		if err := r.CheckChannel(first); err != nil {
+			return err
+		}
+	}
+	r.sizeMemory()
+	if r.Tuning.PipelineDepth > 1 {
+		return r.readMemoryPipelined(first, done)
+	}
+	for i := first; i < len(r.Memory); i++ {
+		r.Memory[i], err = r.readChannelRetrying(i)
+		if err != nil {
+			return fmt.Errorf("error reading memory: %w", err)
+		}
+		if done != nil {
+			done(i)
+		}
+	}
+	return nil
+}
+
+// MemorySize is the number of regular memory channels of the radio.
+func (r *Radio) MemorySize() int {
+	return r.Capabilities().MemorySize()
+}
+
+// sizeMemory makes r.Memory hold exactly the channels of the model, so that
+// it can be indexed by channel number.
+func (r *Radio) sizeMemory() {
+	if size := r.MemorySize(); len(r.Memory) != size {
+		memory := make([]MemoryEntry, size)
+		copy(memory, r.Memory)
+		r.Memory = memory
+		r.reindex()
+	}
+}
+
+// reindex drops the frequency index, for the next ByFrequency to build it
+// again from r.Memory. Anything changing r.Memory other than SetChannel,
+// SetMemory and the read methods calls it.
+func (r *Radio) reindex() {
+	r.byFrequency = nil
+}
+
+// Lookup returns channel from r.Memory, where it is at its number, if it is
+// occupied.
+func (r *Radio) Lookup(channel int) (MemoryEntry, bool) {
+	if channel < 0 || channel >= len(r.Memory) {
+		return MemoryEntry{}, false
+	}
+	m := r.Memory[channel]
+	if m.Number != uint16(channel) || m.RXFrequency == 0 {
+		return MemoryEntry{}, false
+	}
+	return m, true
+}
+
+// ByFrequency returns the channels of r.Memory receiving on hz, by number.
+func (r *Radio) ByFrequency(hz uint32) []MemoryEntry {
+	if r.byFrequency == nil {
+		r.byFrequency = map[uint32][]uint16{}
+		for i, m := range r.Memory {
+			if m.RXFrequency != 0 && int(m.Number) == i {
+				r.byFrequency[m.RXFrequency] = append(r.byFrequency[m.RXFrequency], m.Number)
+			}
+		}
+	}
+	var v []MemoryEntry
+	for _, n := range r.byFrequency[hz] {
+		v = append(v, r.Memory[n])
+	}
+	return v
+}
+
+// CheckChannel returns ErrNoSuchChannel for channels past the memory of the
+// model. r.Memory can be indexed with the others.
+func (r *Radio) CheckChannel(channel int) error {
+	r.sizeMemory()
+	if channel < 0 || channel >= len(r.Memory) {
+		return fmt.Errorf("error: channel %d is not in the %03d-%03d memory of %s: %w", channel, 0, len(r.Memory)-1, r.Model, ErrNoSuchChannel)
+	}
+	return nil
+}
+
+// SetChannel puts m into r.Memory at its number.
+func (r *Radio) SetChannel(m MemoryEntry) error {
+	if err := r.CheckChannel(int(m.Number)); err != nil {
+		return err
+	}
+	r.Memory[m.Number] = m
+	r.reindex()
+	return nil
+}
+
+// SetMemory replaces r.Memory with channels, like the ones of a dump, each
+// at its number. Two channels of one number are refused with
+// ErrDuplicateChannel, see Dump.ResolveDuplicates.
+func (r *Radio) SetMemory(channels []MemoryEntry) error {
+	r.Memory = nil
+	r.sizeMemory()
+	seen := map[uint16]bool{}
+	for _, m := range channels {
+		if seen[m.Number] {
+			return fmt.Errorf("error: channel %03d: %w", m.Number, ErrDuplicateChannel)
+		}
+		seen[m.Number] = true
+		if err := r.SetChannel(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Radio) OccupedChannels() (v []MemoryEntry) {
+	for _, m := range r.Memory {
+		if m.RXFrequency != 0 {
+			v = append(v, m)
+		}
+	}
+	return v
+}
+
+func (r *Radio) WriteMemory() (s WriteSummary, err error) {
+	return r.WriteMemoryFrom(0, nil)
+}
+
+// WriteMemoryFrom writes the occupied channels of r.Memory numbered first or
+// above, calling done, if set, once a channel is written.
+func (r *Radio) WriteMemoryFrom(first int, done func(channel int)) (s WriteSummary, err error) {
+	span := r.startSpan("write memory", "first", fmt.Sprint(first))
+	defer func() { r.endSpan(span, err) }()
+	if err := r.ValidateMemory(); err != nil {
+		return s, fmt.Errorf("refusing to write memory: %w", err)
+	}
+	var channels []int
+	var written []MemoryEntry
+	for _, m := range r.OccupedChannels() {
+		if int(m.Number) >= first {
+			channels = append(channels, int(m.Number))
+			written = append(written, m)
+		}
+	}
+	WarnNameChanges(r.Capabilities().NameChanges(written))
+	if err := r.Backup(channels); err != nil {
+		return s, err
+	}
+	for _, m := range r.OccupedChannels() {
+		if int(m.Number) < first {
+			continue
+		}
+		nameSkipped, err := r.WriteChannel(int(m.Number))
+		if recoverable(err) {
+			if err := r.Resync(); err != nil {
+				return s, fmt.Errorf("error writing channel %d to radio: %w", m.Number, err)
+			}
+			nameSkipped, err = r.WriteChannel(int(m.Number))
+		}
+		if err != nil {
+			return s, fmt.Errorf("error writing channel %d to radio: %w", m.Number, err)
+		}
+		s.Written++
+		if nameSkipped {
+			s.NamesSkipped = append(s.NamesSkipped, m.Number)
+		}
+		if done != nil {
+			done(int(m.Number))
+		}
+	}
+	return s, nil
+}
+
+func NewRadio(portpath string, baudrate int, mode SerialMode) (*Radio, error) {
+	var err error
+	r := &Radio{
+		PortPath: portpath,
+		BaudRate: baudrate,
+		Serial:   mode,
+		Memory:   make([]MemoryEntry, DefaultMemoryChannels),
+	}
+	err = r.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create new radio: %w", err)
+	}
+	return r, nil
+}
@@ -0,0 +1,100 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// redactJitterSteps is how many channel steps Redact moves a frequency at
+// most.
+const redactJitterSteps = 10
+
+// Redact strips what tells about the owner of the dump, keeping its
+// structure: channel names become placeholders, DTMF codes are zeroed,
+// callsign and beacon text, bookmark notes and provenance are dropped. With a non-nil rng frequencies are also moved by a
+// few channel steps, keeping split and offsets of every channel intact.
+func (d *Dump) Redact(rng *rand.Rand) {
+	for i := range d.Memory {
+		redactChannel(&d.Memory[i], rng)
+	}
+	for i := range d.Special {
+		redactChannel(&d.Special[i], rng)
+	}
+	for i := range d.Inbox {
+		d.Inbox[i].Note = ""
+		redactChannel(&d.Inbox[i].Channel, rng)
+	}
+	for i := range d.Profiles {
+		for j := range d.Profiles[i].VFO {
+			v := &d.Profiles[i].VFO[j]
+			v.Frequency = jitter(v.Frequency, jitterDelta(v.StepSize, rng))
+		}
+	}
+	for i := range d.DTMF {
+		e := &d.DTMF[i]
+		e.Code = strings.Repeat("0", len(e.Code))
+		if e.Name != "" {
+			e.Name = fmt.Sprintf("DTMF%d", e.Number)
+		}
+	}
+	if d.APRS != nil {
+		d.APRS = &APRSConfig{Callsign: "N0CALL", Path: d.APRS.Path, Symbol: d.APRS.Symbol}
+	}
+	d.Meta = nil
+}
+
+func redactChannel(m *MemoryEntry, rng *rand.Rand) {
+	if m.Name != "" {
+		m.Name = fmt.Sprintf("CH%03d", m.Number)
+	}
+	delta := jitterDelta(m.RXStepSize, rng)
+	m.RXFrequency = jitter(m.RXFrequency, delta)
+	m.TXFrequency = jitter(m.TXFrequency, delta)
+}
+
+// jitterDelta picks a shift of a non-zero number of steps, or none when rng
+// is nil.
+func jitterDelta(step uint8, rng *rand.Rand) int64 {
+	hz, ok := units.StepHz(step)
+	if rng == nil || !ok {
+		return 0
+	}
+	n := rng.Intn(redactJitterSteps) + 1
+	if rng.Intn(2) == 0 {
+		n = -n
+	}
+	return int64(n) * int64(hz)
+}
+
+func jitter(hz uint32, delta int64) uint32 {
+	if hz == 0 {
+		return 0
+	}
+	v := int64(hz) + delta
+	if v <= 0 {
+		v = int64(hz) - delta
+	}
+	return uint32(v)
+}
+
+var transcriptSecrets = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`MN (\d{3}),[^\r"\\]*`), "MN $1,NAME"},
+	{regexp.MustCompile(`DM (\d),[^\r"\\]*`), "DM $1,CODE,NAME"},
+	{regexp.MustCompile(`(MYC|STAT) [^\r"\\]*`), "$1 REDACTED"},
+}
+
+// RedactTranscript masks channel names and DTMF memories in a log of radio
+// traffic.
+func RedactTranscript(s string) string {
+	for _, r := range transcriptSecrets {
+		s = r.re.ReplaceAllString(s, r.repl)
+	}
+	return s
+}
@@ -0,0 +1,161 @@
+package kenwoodutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LogConfig lists remote destinations for the structured log of long running
+// modes, for installations where nobody reads local output.
+type LogConfig struct {
+	// Syslog is udp://host:port or tcp://host:port.
+	Syslog string `json:",omitempty"`
+	// Loki is the base URL of a Loki server, like http://host:3100.
+	Loki   string            `json:",omitempty"`
+	Labels map[string]string `json:",omitempty"`
+}
+
+const (
+	lokiPushPath     = "/loki/api/v1/push"
+	lokiFlushPeriod  = 2 * time.Second
+	lokiMaxBatch     = 100
+	defaultLogTag    = "kenwoodutil"
+	remoteLogTimeout = 5 * time.Second
+)
+
+// RemoteLogWriters opens a writer for every destination in c. Every write
+// is expected to be one JSON log line.
+func RemoteLogWriters(c LogConfig) ([]io.WriteCloser, error) {
+	var v []io.WriteCloser
+	if c.Syslog != "" {
+		u, err := url.Parse(c.Syslog)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("error parsing syslog address %q, want udp://host:port or tcp://host:port", c.Syslog)
+		}
+		w, err := dialSyslog(u.Scheme, u.Host, defaultLogTag)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to syslog %s: %w", c.Syslog, err)
+		}
+		v = append(v, w)
+	}
+	if c.Loki != "" {
+		w, err := newLokiWriter(c.Loki, c.Labels)
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, w)
+	}
+	return v, nil
+}
+
+type lokiWriter struct {
+	url    string
+	labels map[string]string
+	client *http.Client
+
+	mu      sync.Mutex
+	pending [][2]string
+	flush   chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newLokiWriter(base string, labels map[string]string) (*lokiWriter, error) {
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("error parsing Loki URL %q", base)
+	}
+	l := map[string]string{"job": defaultLogTag}
+	if host, err := os.Hostname(); err == nil {
+		l["host"] = host
+	}
+	for k, v := range labels {
+		l[k] = v
+	}
+	w := &lokiWriter{
+		url:     u.ResolveReference(&url.URL{Path: lokiPushPath}).String(),
+		labels:  l,
+		client:  &http.Client{Timeout: remoteLogTimeout},
+		flush:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *lokiWriter) Write(p []byte) (int, error) {
+	line := string(bytes.TrimRight(p, "\n"))
+	w.mu.Lock()
+	w.pending = append(w.pending, [2]string{strconv.FormatInt(time.Now().UnixNano(), 10), line})
+	full := len(w.pending) >= lokiMaxBatch
+	w.mu.Unlock()
+	if full {
+		select {
+		case w.flush <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+func (w *lokiWriter) run() {
+	defer close(w.stopped)
+	tick := time.NewTicker(lokiFlushPeriod)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+		case <-w.flush:
+		case <-w.done:
+			w.push()
+			return
+		}
+		w.push()
+	}
+}
+
+// push sends pending lines. Failures are reported on stderr and the lines
+// dropped, a log shipper must never block the radio work.
+func (w *lokiWriter) push() {
+	w.mu.Lock()
+	values := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+	if len(values) == 0 {
+		return
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"streams": []interface{}{
+			map[string]interface{}{"stream": w.labels, "values": values},
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error encoding Loki push: %v\n", err)
+		return
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error pushing logs to Loki: %v\n", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		fmt.Fprintf(os.Stderr, "error pushing logs to Loki: %s\n", resp.Status)
+	}
+}
+
+// Close sends whatever is still pending.
+func (w *lokiWriter) Close() error {
+	close(w.done)
+	<-w.stopped
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package kenwoodutil
+
+import (
+	"io"
+	"log/syslog"
+
+	"github.com/rs/zerolog"
+)
+
+type syslogWriter struct {
+	zerolog.LevelWriter
+	w *syslog.Writer
+}
+
+func (s syslogWriter) Close() error { return s.w.Close() }
+
+func dialSyslog(network, addr, tag string) (io.WriteCloser, error) {
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return syslogWriter{zerolog.SyslogLevelWriter(w), w}, nil
+}
@@ -0,0 +1,39 @@
+package kenwoodutil
+
+import (
+	"regexp"
+	"sort"
+)
+
+// Rename is a channel name change.
+type Rename struct {
+	Number   uint16
+	Old, New string
+}
+
+var sedGroup = regexp.MustCompile(`\\(\d)`)
+
+// ExpandReplacement turns sed style \1 group references into the ${1} form
+// regexp expects.
+func ExpandReplacement(repl string) string {
+	return sedGroup.ReplaceAllString(repl, "$${$1}")
+}
+
+// RenameChannels returns the name changes of applying re and repl, in
+// regexp.Expand syntax, to the names of channels. Only names re matches
+// fully are renamed, so that a pattern does not hit part of a name by
+// accident.
+func RenameChannels(channels []MemoryEntry, re *regexp.Regexp, repl string) (v []Rename) {
+	full := regexp.MustCompile(`^(?:` + re.String() + `)$`)
+	for _, m := range channels {
+		if m.RXFrequency == 0 || !full.MatchString(m.Name) {
+			continue
+		}
+		name := full.ReplaceAllString(m.Name, repl)
+		if name != m.Name {
+			v = append(v, Rename{Number: m.Number, Old: m.Name, New: name})
+		}
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].Number < v[j].Number })
+	return v
+}
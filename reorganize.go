@@ -0,0 +1,138 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CompactMapping maps occupied channels to consecutive numbers from start,
+// keeping their order.
+func CompactMapping(memory []MemoryEntry, start int) map[uint16]uint16 {
+	mapping := map[uint16]uint16{}
+	for _, m := range memory {
+		if m.RXFrequency != 0 {
+			mapping[m.Number] = uint16(start + len(mapping))
+		}
+	}
+	return mapping
+}
+
+// SortMapping maps occupied channels ordered by "freq", "name" or "number"
+// to consecutive numbers from start.
+func SortMapping(memory []MemoryEntry, by string, start int) (map[uint16]uint16, error) {
+	var v []MemoryEntry
+	for _, m := range memory {
+		if m.RXFrequency != 0 {
+			v = append(v, m)
+		}
+	}
+	var less func(a, b MemoryEntry) bool
+	switch by {
+	case "freq":
+		less = func(a, b MemoryEntry) bool { return a.RXFrequency < b.RXFrequency }
+	case "name":
+		less = func(a, b MemoryEntry) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case "number":
+		less = func(a, b MemoryEntry) bool { return a.Number < b.Number }
+	default:
+		return nil, fmt.Errorf("error sorting channels: unknown key %q, expected freq, name or number", by)
+	}
+	sort.SliceStable(v, func(i, j int) bool { return less(v[i], v[j]) })
+	mapping := map[uint16]uint16{}
+	for i, m := range v {
+		mapping[m.Number] = uint16(start + i)
+	}
+	return mapping, nil
+}
+
+// Renumber moves channels according to mapping from old to new numbers.
+// Channels not in mapping keep their numbers.
+func Renumber(memory []MemoryEntry, mapping map[uint16]uint16) ([]MemoryEntry, error) {
+	var v []MemoryEntry
+	used := map[uint16]uint16{}
+	for _, m := range memory {
+		if m.RXFrequency == 0 {
+			continue
+		}
+		old := m.Number
+		if n, ok := mapping[old]; ok {
+			m.Number = n
+		}
+		if prev, ok := used[m.Number]; ok {
+			return nil, fmt.Errorf("error renumbering: channels %03d and %03d would both become %03d", prev, old, m.Number)
+		}
+		used[m.Number] = old
+		v = append(v, m)
+	}
+	return v, nil
+}
+
+func (r *Radio) ClearChannel(channel int) error {
+	if err := r.Backup([]int{channel}); err != nil {
+		return err
+	}
+	m := MemoryEntry{Number: uint16(channel)}
+	if _, err := r.WriteReadString(m.ClearChannelLine() + "\r"); err != nil {
+		return fmt.Errorf("error clearing channel %d: %w", channel, err)
+	}
+	return nil
+}
+
+// ApplyLayout writes layout to the radio, touching only the channels that
+// differ from r.Memory and clearing the ones left empty.
+func (r *Radio) ApplyLayout(layout []MemoryEntry) (s WriteSummary, err error) {
+	span := r.startSpan("apply layout")
+	defer func() { r.endSpan(span, err) }()
+	r.sizeMemory()
+	slots := make([]MemoryEntry, len(r.Memory))
+	seen := map[uint16]bool{}
+	for _, m := range layout {
+		if int(m.Number) >= len(slots) {
+			return s, fmt.Errorf("error applying layout: channel %d out of memory range", m.Number)
+		}
+		if seen[m.Number] {
+			return s, fmt.Errorf("error applying layout: channel %03d: %w", m.Number, ErrDuplicateChannel)
+		}
+		seen[m.Number] = true
+		slots[m.Number] = m
+	}
+	// r.Memory is left as it was when the layout is refused
+	var occupied []MemoryEntry
+	for _, m := range slots {
+		if m.RXFrequency != 0 {
+			occupied = append(occupied, m)
+		}
+	}
+	if err := r.validateChannels(occupied); err != nil {
+		return s, fmt.Errorf("refusing to write layout: %w", err)
+	}
+	diffs := DiffMemory(r.Memory, slots)
+	r.Memory = slots
+	r.reindex()
+	var written []MemoryEntry
+	for _, d := range diffs {
+		written = append(written, r.Memory[d.Number])
+	}
+	WarnNameChanges(r.Capabilities().NameChanges(written))
+	if err := r.Backup(diffChannels(diffs)); err != nil {
+		return s, err
+	}
+	for _, d := range diffs {
+		if d.Kind == ChannelRemoved {
+			if err := r.ClearChannel(int(d.Number)); err != nil {
+				return s, err
+			}
+			continue
+		}
+		nameSkipped, err := r.WriteChannel(int(d.Number))
+		if err != nil {
+			return s, err
+		}
+		s.Written++
+		if nameSkipped {
+			s.NamesSkipped = append(s.NamesSkipped, d.Number)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,234 @@
+package kenwoodutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+const (
+	repeaterBookNA  = "https://www.repeaterbook.com/api/export.php"
+	repeaterBookROW = "https://www.repeaterbook.com/api/exportROW.php"
+	userAgent       = "kenwoodutil (https://github.com/skrzyp/kenwoodutil)"
+)
+
+type Repeater struct {
+	Callsign    string
+	City        string
+	Frequency   uint32
+	Input       uint32
+	Tone        float64
+	ToneSquelch bool
+	Lat, Lon    float64
+}
+
+type repeaterBookResult struct {
+	Callsign  string `json:"Callsign"`
+	City      string `json:"Nearest City"`
+	Frequency string `json:"Frequency"`
+	Input     string `json:"Input Freq"`
+	PL        string `json:"PL"`
+	TSQ       string `json:"TSQ"`
+	Lat       string `json:"Lat"`
+	Long      string `json:"Long"`
+	FMAnalog  string `json:"FM Analog"`
+	Status    string `json:"Operational Status"`
+}
+
+// FetchRepeaterBook downloads the operational analog FM repeaters of
+// country from the RepeaterBook API.
+func FetchRepeaterBook(country string) ([]Repeater, error) {
+	base := repeaterBookROW
+	switch strings.ToLower(country) {
+	case "united states", "canada", "mexico":
+		base = repeaterBookNA
+	}
+	req, err := http.NewRequest("GET", base+"?"+url.Values{"country": {country}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error querying RepeaterBook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error querying RepeaterBook: %s", resp.Status)
+	}
+
+	var body struct {
+		Results []repeaterBookResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("error parsing RepeaterBook reply: %w", err)
+	}
+
+	var v []Repeater
+	for _, res := range body.Results {
+		if res.FMAnalog != "Yes" || (res.Status != "" && res.Status != "On-air") {
+			continue
+		}
+		rp := Repeater{Callsign: res.Callsign, City: res.City}
+		if rp.Frequency, err = units.ParseMHz(res.Frequency); err != nil {
+			continue
+		}
+		if rp.Input, err = units.ParseMHz(res.Input); err != nil {
+			rp.Input = rp.Frequency
+		}
+		rp.Tone, _ = strconv.ParseFloat(res.PL, 64)
+		if tsq, err := strconv.ParseFloat(res.TSQ, 64); err == nil {
+			rp.Tone, rp.ToneSquelch = tsq, true
+		}
+		rp.Lat, _ = strconv.ParseFloat(res.Lat, 64)
+		rp.Lon, _ = strconv.ParseFloat(res.Long, 64)
+		v = append(v, rp)
+	}
+	return v, nil
+}
+
+// Distance returns the great circle distance to lat, lon in kilometres.
+func (rp Repeater) Distance(lat, lon float64) float64 {
+	const earthRadius = 6371.0
+	rad := math.Pi / 180
+	dlat := (rp.Lat - lat) * rad
+	dlon := (rp.Lon - lon) * rad
+	a := math.Sin(dlat/2)*math.Sin(dlat/2) +
+		math.Cos(lat*rad)*math.Cos(rp.Lat*rad)*math.Sin(dlon/2)*math.Sin(dlon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+func (rp Repeater) MemoryEntry() MemoryEntry {
+	m := MemoryEntry{
+		RXFrequency: rp.Frequency,
+		RXStepSize:  units.CanonicalStep(rp.Frequency, 0),
+		Name:        rp.Callsign,
+	}
+	if len(m.Name) > units.MaxNameLength {
+		m.Name = m.Name[:units.MaxNameLength]
+	}
+	switch {
+	case rp.Input > rp.Frequency:
+		m.ShiftDirection, m.OffsetFrequency = 1, rp.Input-rp.Frequency
+	case rp.Input < rp.Frequency:
+		m.ShiftDirection, m.OffsetFrequency = 2, rp.Frequency-rp.Input
+	}
+	if idx, err := units.ToneIndex(rp.Tone); err == nil {
+		if rp.ToneSquelch {
+			m.CTCSSEnabled, m.CTCSSFrequency = 1, uint16(idx)
+		} else {
+			m.ToneEnabled, m.ToneFrequency = 1, uint16(idx)
+		}
+	}
+	return m
+}
+
+// SameRepeater reports whether a and b reach the same repeater, regardless
+// of channel number and name.
+func SameRepeater(a, b MemoryEntry) bool {
+	a.Number, b.Number = 0, 0
+	a.Name, b.Name = "", ""
+	a.LockOut, b.LockOut = 0, 0
+	return a.Equal(b)
+}
+
+// RepeaterCorrection is a tone or offset change a channel needs to reach
+// its repeater as a repeater database has it now.
+type RepeaterCorrection struct {
+	Channel  MemoryEntry
+	Repeater Repeater
+	Fixed    MemoryEntry
+}
+
+// Changes lists the fields the correction changes.
+func (c RepeaterCorrection) Changes() []FieldChange {
+	return fieldChanges(c.Channel, c.Fixed)
+}
+
+// RepeaterCorrections checks the repeater channels of memory against
+// repeaters. A channel is matched by its frequency, and its name when the
+// frequency is not enough, to the callsign. Tones and offsets the database
+// leaves out are not corrected.
+func RepeaterCorrections(memory []MemoryEntry, repeaters []Repeater) (v []RepeaterCorrection) {
+	for _, m := range memory {
+		if m.RXFrequency == 0 || m.ShiftDirection == 0 && !m.Split {
+			continue
+		}
+		rp, ok := matchRepeater(m, repeaters)
+		if !ok {
+			continue
+		}
+		db := rp.MemoryEntry()
+		fixed := m
+		if rp.Input != 0 && rp.Input != rp.Frequency {
+			fixed.Split, fixed.TXFrequency, fixed.TXStepSize = false, 0, 0
+			fixed.ShiftDirection, fixed.OffsetFrequency = db.ShiftDirection, db.OffsetFrequency
+		}
+		if db.ToneEnabled == 1 || db.CTCSSEnabled == 1 {
+			fixed.ToneEnabled, fixed.ToneFrequency = db.ToneEnabled, db.ToneFrequency
+			fixed.CTCSSEnabled, fixed.CTCSSFrequency = db.CTCSSEnabled, db.CTCSSFrequency
+			fixed.DCSEnabled = 0
+			if fixed.ToneEnabled == 0 {
+				fixed.ToneFrequency = m.ToneFrequency
+			}
+			if fixed.CTCSSEnabled == 0 {
+				fixed.CTCSSFrequency = m.CTCSSFrequency
+			}
+		}
+		if !fixed.Equal(m) {
+			v = append(v, RepeaterCorrection{Channel: m, Repeater: rp, Fixed: fixed})
+		}
+	}
+	return v
+}
+
+// matchRepeater finds the repeater m is for: the only one on its frequency,
+// or the one whose callsign the name of m starts with.
+func matchRepeater(m MemoryEntry, repeaters []Repeater) (Repeater, bool) {
+	var same []Repeater
+	for _, rp := range repeaters {
+		if rp.Frequency == m.RXFrequency {
+			same = append(same, rp)
+		}
+	}
+	name := strings.ToUpper(strings.TrimSpace(m.Name))
+	if name != "" {
+		for _, rp := range same {
+			call := strings.ToUpper(rp.Callsign)
+			if strings.HasPrefix(call, name) || strings.HasPrefix(name, call) {
+				return rp, true
+			}
+		}
+	}
+	if len(same) == 1 {
+		return same[0], true
+	}
+	return Repeater{}, false
+}
+
+// Transmittable drops the repeater and split channels that model cannot
+// transmit on. An empty model keeps them all.
+func Transmittable(channels []MemoryEntry, model string) []MemoryEntry {
+	if model == "" {
+		return channels
+	}
+	c := CapabilitiesFor(model)
+	var v []MemoryEntry
+	for _, m := range channels {
+		if tx := m.ShiftedTX(); tx != 0 && !c.CanTransmit(tx) {
+			log.Warn().Str("name", m.Name).Str("tx", units.FormatMHz(tx)).Msgf("%s does not transmit there, skipping", c.Model)
+			continue
+		}
+		v = append(v, m)
+	}
+	return v
+}
@@ -0,0 +1,126 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+const (
+	SCFormat = "SC %1d,%1d"
+
+	scanResumeSetting = "MU.scan_resume"
+)
+
+// scanResumeModes are the scan resume modes by their scanResumeSetting
+// value.
+var scanResumeModes = []string{"time", "carrier", "seek"}
+
+// SetScan starts or stops scanning on band.
+func (r *Radio) SetScan(band int, on bool) error {
+	v := 0
+	if on {
+		v = 1
+	}
+	if _, err := r.WriteReadString(fmt.Sprintf(SCFormat, band, v) + "\r"); err != nil {
+		return fmt.Errorf("error switching scan on band %s: %w", units.BandName(band), err)
+	}
+	return nil
+}
+
+// SetSetting changes a single setting, leaving the others as they are.
+func (r *Radio) SetSetting(key, value string) error {
+	s, err := r.ReadSettings()
+	if err != nil {
+		return err
+	}
+	if _, ok := s[key]; !ok {
+		return fmt.Errorf("error: %s does not report setting %s", r.Model, key)
+	}
+	_, err = r.ApplySettings(Settings{key: value})
+	return err
+}
+
+func (r *Radio) ScanResume() (string, error) {
+	s, err := r.ReadSettings()
+	if err != nil {
+		return "", err
+	}
+	v, ok := s[scanResumeSetting]
+	if !ok {
+		return "", fmt.Errorf("error: %s does not report its scan resume mode", r.Model)
+	}
+	for i, mode := range scanResumeModes {
+		if v == fmt.Sprint(i) {
+			return mode, nil
+		}
+	}
+	return "", parseError("scan resume mode", v, "")
+}
+
+// SetScanResume sets how scanning resumes after stopping on a signal:
+// after some time, once the carrier drops, or not at all (seek).
+func (r *Radio) SetScanResume(mode string) error {
+	for i, m := range scanResumeModes {
+		if strings.EqualFold(m, mode) {
+			return r.SetSetting(scanResumeSetting, fmt.Sprint(i))
+		}
+	}
+	return fmt.Errorf("error: unknown scan resume mode %q, expected one of %s", mode, strings.Join(scanResumeModes, ", "))
+}
+
+// SetLockout sets or clears the scan lockout of channels, writing only the
+// ones that change. Empty channels are skipped.
+func (r *Radio) SetLockout(channels []int, lockout bool) (changed []int, err error) {
+	span := r.startSpan("set lockout")
+	defer func() { r.endSpan(span, err) }()
+	var want uint8
+	if lockout {
+		want = 1
+	}
+	for _, ch := range channels {
+		m, err := r.readChannelRetrying(ch)
+		if errors.Is(err, ErrRadioNAK) || (err == nil && m.RXFrequency == 0) {
+			log.Warn().Int("channel", ch).Msg("channel is empty, skipping")
+			continue
+		}
+		if err != nil {
+			return changed, err
+		}
+		if m.LockOut == want {
+			continue
+		}
+		m.LockOut = want
+		if err := r.SetChannel(m); err != nil {
+			return changed, err
+		}
+		if _, err := r.WriteChannel(ch); err != nil {
+			return changed, err
+		}
+		changed = append(changed, ch)
+	}
+	return changed, nil
+}
+
+// SetLockout sets or clears the scan lockout of channels in the dump,
+// returning the ones that changed.
+func (d *Dump) SetLockout(channels []int, lockout bool) (changed []int) {
+	var want uint8
+	if lockout {
+		want = 1
+	}
+	for _, ch := range channels {
+		for i := range d.Memory {
+			m := &d.Memory[i]
+			if int(m.Number) == ch && m.RXFrequency != 0 && m.LockOut != want {
+				m.LockOut = want
+				changed = append(changed, ch)
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,86 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"time"
+)
+
+// ScheduledProfile is a WatchdogRule applied once a day when its time
+// window starts, like a quieter backlight and beep at night. The window
+// From-To is in local "15:04" time and may wrap past midnight.
+type ScheduledProfile struct {
+	WatchdogRule
+	From string
+	To   string
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("error: %q is not a time of day like 22:00", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Active reports whether t falls in the window of the profile. A window
+// starting and ending at the same time lasts all day.
+func (p ScheduledProfile) Active(t time.Time) (bool, error) {
+	from, err := parseClock(p.From)
+	if err != nil {
+		return false, fmt.Errorf("error in profile %q: %w", p.Name, err)
+	}
+	to, err := parseClock(p.To)
+	if err != nil {
+		return false, fmt.Errorf("error in profile %q: %w", p.Name, err)
+	}
+	now := t.Hour()*60 + t.Minute()
+	if from <= to {
+		return from == to || (now >= from && now < to), nil
+	}
+	return now >= from || now < to, nil
+}
+
+// Scheduler applies scheduled profiles as their windows start. Between
+// starts the radio is left alone, so that the operator can change it.
+type Scheduler struct {
+	Profiles []ScheduledProfile
+	applied  map[int]bool
+}
+
+// Check parses every profile window.
+func (s *Scheduler) Check() error {
+	for _, p := range s.Profiles {
+		if _, err := p.Active(time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply applies the profiles that became active since the last call, all
+// active ones on the first call, returning the names of those applied. A
+// profile that failed to apply is tried again on the next call.
+func (s *Scheduler) Apply(r *Radio, now time.Time) (applied []string, err error) {
+	if s.applied == nil {
+		s.applied = map[int]bool{}
+	}
+	for i, p := range s.Profiles {
+		active, err := p.Active(now)
+		if err != nil {
+			return applied, err
+		}
+		if !active {
+			s.applied[i] = false
+			continue
+		}
+		if s.applied[i] {
+			continue
+		}
+		if _, err := r.Enforce(p.WatchdogRule); err != nil {
+			return applied, fmt.Errorf("error applying profile %q: %w", p.Name, err)
+		}
+		s.applied[i] = true
+		applied = append(applied, p.Name)
+	}
+	return applied, nil
+}
@@ -0,0 +1,158 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// SerialMode holds the serial line settings other than the baud rate. Some
+// programming cables draw power for their level converter from DTR or RTS,
+// or only pass data with flow control handshaking.
+type SerialMode struct {
+	DataBits int
+	Parity   serial.Parity
+	StopBits serial.StopBits
+	// DTR and RTS are the line states set right after opening the port,
+	// nil leaves them as the driver sets them.
+	DTR, RTS *bool
+	// FlowControl is FlowNone or FlowRTSCTS.
+	FlowControl string
+}
+
+const (
+	FlowNone   = "none"
+	FlowRTSCTS = "rtscts"
+)
+
+// DefaultSerialMode is the 8N1 without handshaking Kenwood radios use.
+var DefaultSerialMode = SerialMode{DataBits: 8, Parity: serial.NoParity, StopBits: serial.OneStopBit, FlowControl: FlowNone}
+
+// ctsTimeout is how long a write waits for the cable to raise CTS.
+const ctsTimeout = 2 * time.Second
+
+var parityNames = map[string]serial.Parity{
+	"none":  serial.NoParity,
+	"odd":   serial.OddParity,
+	"even":  serial.EvenParity,
+	"mark":  serial.MarkParity,
+	"space": serial.SpaceParity,
+}
+
+var stopBitNames = map[string]serial.StopBits{
+	"1":   serial.OneStopBit,
+	"1.5": serial.OnePointFiveStopBits,
+	"2":   serial.TwoStopBits,
+}
+
+// ParseSerialMode builds a SerialMode out of its textual form, like
+// command line flags. dtr and rts are "on", "off" or empty to leave the
+// line alone.
+func ParseSerialMode(dataBits int, parity, stopBits, dtr, rts, flow string) (SerialMode, error) {
+	m := DefaultSerialMode
+	if dataBits < 5 || dataBits > 8 {
+		return m, fmt.Errorf("error: %d data bits, expected 5 to 8", dataBits)
+	}
+	m.DataBits = dataBits
+	var ok bool
+	if m.Parity, ok = parityNames[strings.ToLower(parity)]; !ok {
+		return m, fmt.Errorf("error: unknown parity %q, expected none, odd, even, mark or space", parity)
+	}
+	if m.StopBits, ok = stopBitNames[stopBits]; !ok {
+		return m, fmt.Errorf("error: %q stop bits, expected 1, 1.5 or 2", stopBits)
+	}
+	line := func(name, v string) (*bool, error) {
+		switch strings.ToLower(v) {
+		case "":
+			return nil, nil
+		case "on", "1", "high":
+			b := true
+			return &b, nil
+		case "off", "0", "low":
+			b := false
+			return &b, nil
+		}
+		return nil, fmt.Errorf("error: unknown %s state %q, expected on or off", name, v)
+	}
+	var err error
+	if m.DTR, err = line("DTR", dtr); err != nil {
+		return m, err
+	}
+	if m.RTS, err = line("RTS", rts); err != nil {
+		return m, err
+	}
+	switch strings.ToLower(flow) {
+	case FlowNone, "":
+		m.FlowControl = FlowNone
+	case FlowRTSCTS, "hardware":
+		m.FlowControl = FlowRTSCTS
+		if m.RTS != nil && !*m.RTS {
+			return m, fmt.Errorf("error: RTS/CTS flow control needs RTS on")
+		}
+	default:
+		return m, fmt.Errorf("error: unknown flow control %q, expected none or rtscts", flow)
+	}
+	return m, nil
+}
+
+// open opens the serial port at path with the mode and sets up its control
+// lines.
+func (m SerialMode) open(path string, baudrate int) (Port, error) {
+	p, err := serial.Open(path, &serial.Mode{
+		BaudRate: baudrate,
+		DataBits: m.DataBits,
+		Parity:   m.Parity,
+		StopBits: m.StopBits,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error opening serial port: %w", err)
+	}
+	rts := m.RTS
+	if m.FlowControl == FlowRTSCTS {
+		on := true
+		rts = &on
+	}
+	if m.DTR != nil {
+		if err := p.SetDTR(*m.DTR); err != nil {
+			p.Close()
+			return nil, fmt.Errorf("error setting DTR on %s: %w", path, err)
+		}
+	}
+	if rts != nil {
+		if err := p.SetRTS(*rts); err != nil {
+			p.Close()
+			return nil, fmt.Errorf("error setting RTS on %s: %w", path, err)
+		}
+	}
+	if m.FlowControl == FlowRTSCTS {
+		return &ctsPort{Port: p}, nil
+	}
+	return p, nil
+}
+
+// ctsPort does RTS/CTS handshaking in software, which go.bug.st/serial does
+// not offer: RTS is kept on and writes wait for the cable to raise CTS.
+// Radio commands are short enough to fit the cable buffer once CTS is up.
+type ctsPort struct {
+	serial.Port
+}
+
+func (p *ctsPort) Write(b []byte) (int, error) {
+	deadline := time.Now().Add(ctsTimeout)
+	for {
+		bits, err := p.Port.GetModemStatusBits()
+		if err != nil {
+			return 0, fmt.Errorf("error reading CTS: %w", err)
+		}
+		if bits.CTS {
+			break
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("error: cable did not raise CTS within %s: %w", ctsTimeout, ErrTimeout)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return p.Port.Write(b)
+}
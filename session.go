@@ -0,0 +1,202 @@
+package kenwoodutil
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const replayScheme = "replay://"
+
+// ErrReplayDiverged is returned when a replayed session is sent something
+// else than what was sent when it was recorded.
+var ErrReplayDiverged = errors.New("replayed session diverged from the recording")
+
+// SessionEvent is a chunk of serial traffic, as recorded in session files
+// one JSON object per line. Dir is "open" when the port was (re)opened, at
+// the baud rate in Data, "send" or "recv".
+type SessionEvent struct {
+	Time time.Time
+	Dir  string
+	Data string `json:",omitempty"`
+}
+
+// SessionRecorder writes the traffic of ports it wraps to a session file.
+type SessionRecorder struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	failed bool
+}
+
+func NewSessionRecorder(w io.Writer) *SessionRecorder {
+	return &SessionRecorder{enc: json.NewEncoder(w)}
+}
+
+func (s *SessionRecorder) record(dir, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.enc.Encode(SessionEvent{Time: time.Now().UTC(), Dir: dir, Data: data}); err != nil && !s.failed {
+		s.failed = true
+		log.Warn().Err(err).Msg("session recording failed, the session file is incomplete")
+	}
+}
+
+// Wrap records the traffic of p, opened at baud.
+func (s *SessionRecorder) Wrap(p Port, baud int) Port {
+	s.record("open", fmt.Sprint(baud))
+	return &recordingPort{Port: p, rec: s}
+}
+
+type recordingPort struct {
+	Port
+	rec *SessionRecorder
+}
+
+func (p *recordingPort) Read(b []byte) (int, error) {
+	n, err := p.Port.Read(b)
+	if n > 0 {
+		p.rec.record("recv", string(b[:n]))
+	}
+	return n, err
+}
+
+func (p *recordingPort) Write(b []byte) (int, error) {
+	n, err := p.Port.Write(b)
+	if n > 0 {
+		p.rec.record("send", string(b[:n]))
+	}
+	return n, err
+}
+
+// RecordSession records the traffic with the radio from now on, also
+// across reconnections.
+func (r *Radio) RecordSession(s *SessionRecorder) {
+	r.Recorder = s
+	r.Port = s.Wrap(r.Port, r.BaudRate)
+	r.PortRW.Reader.Reset(timeoutReader{r.Port})
+	r.PortRW.Writer.Reset(r.Port)
+}
+
+// ReadSession reads the events of a session file.
+func ReadSession(path string) ([]SessionEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading session: %w", err)
+	}
+	defer f.Close()
+	var events []SessionEvent
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		var e SessionEvent
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("error in session %s line %d: %w", path, line, err)
+		}
+		events = append(events, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("error reading session: %w", err)
+	}
+	return events, nil
+}
+
+// replayPort plays back a recorded session. What was sent must be sent
+// again, byte for byte; what was received becomes readable once everything
+// sent before it was. Nothing to read is a read timeout, returned at once.
+type replayPort struct {
+	mu   sync.Mutex
+	sent string
+	recv []replayChunk
+	// written is how much of sent was sent again.
+	written int
+}
+
+type replayChunk struct {
+	after int
+	data  string
+}
+
+var (
+	replaysMu sync.Mutex
+	replays   = map[string]*replayPort{}
+)
+
+// openReplay opens a session file as a port. Opening it again in the same
+// process goes on where it was, as the radio would after a reconnection.
+func openReplay(path string) (Port, error) {
+	replaysMu.Lock()
+	defer replaysMu.Unlock()
+	if p, ok := replays[path]; ok {
+		return p, nil
+	}
+	events, err := ReadSession(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &replayPort{}
+	var sent strings.Builder
+	for _, e := range events {
+		switch e.Dir {
+		case "send":
+			sent.WriteString(e.Data)
+		case "recv":
+			p.recv = append(p.recv, replayChunk{after: sent.Len(), data: e.Data})
+		}
+	}
+	p.sent = sent.String()
+	replays[path] = p
+	return p, nil
+}
+
+func (p *replayPort) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rest := p.sent[p.written:]
+	if rest == "" {
+		return 0, fmt.Errorf("error replaying session: sent %q after the end of the recording: %w", b, ErrReplayDiverged)
+	}
+	if !strings.HasPrefix(rest, string(b)) {
+		want := rest
+		if len(want) > len(b) {
+			want = want[:len(b)]
+		}
+		return 0, fmt.Errorf("error replaying session: sent %q, recorded %q: %w", b, want, ErrReplayDiverged)
+	}
+	p.written += len(b)
+	return len(b), nil
+}
+
+func (p *replayPort) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.recv) == 0 || p.recv[0].after > p.written {
+		return 0, nil
+	}
+	n := copy(b, p.recv[0].data)
+	if p.recv[0].data = p.recv[0].data[n:]; p.recv[0].data == "" {
+		p.recv = p.recv[1:]
+	}
+	return n, nil
+}
+
+// ResetInputBuffer does nothing: input dropped when the session was
+// recorded was never read, so it is not in the recording.
+func (p *replayPort) ResetInputBuffer() error {
+	return nil
+}
+
+func (p *replayPort) SetReadTimeout(t time.Duration) error {
+	return nil
+}
+
+func (p *replayPort) Close() error {
+	return nil
+}
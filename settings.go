@@ -0,0 +1,166 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Settings holds radio configuration keyed by "<command>.<field>", e.g.
+// "MU.auto_power_off", with values exactly as the radio reports them.
+type Settings map[string]string
+
+type settingsCommand struct {
+	Mnemonic string
+	Fields   []string
+}
+
+// muFields names the MU (menu) fields of the TM-D710/TM-V71 in order.
+// Fields past the end of the list are keyed by their index.
+var muFields = []string{
+	"beep", "beep_volume", "ext_speaker_mode", "announce", "language",
+	"voice_volume", "voice_speed", "playback_repeat", "playback_repeat_interval",
+	"continuous_recording", "vhf_aip", "uhf_aip", "smeter_sql_hang_up_time",
+	"mute_hang_up_time", "beat_shift", "timeout_timer", "recall_method",
+	"echolink_speed", "dtmf_hold", "dtmf_speed", "dtmf_pause", "dtmf_key_lock",
+	"auto_repeater_offset", "tone_1750_tx_hold", "brightness_level",
+	"auto_brightness", "backlight_color", "pf1_key", "pf2_key", "mic_pf1_key",
+	"mic_pf2_key", "mic_pf3_key", "mic_pf4_key", "mic_key_lock", "scan_resume",
+	"auto_power_off", "ext_data_band", "ext_data_speed", "sqc_source",
+	"auto_pm_store", "display_partition_bar", "pc_port_speed",
+}
+
+var settingsCommands = []settingsCommand{
+	{"MU", muFields},
+}
+
+func (c settingsCommand) key(i int) string {
+	if i < len(c.Fields) {
+		return c.Mnemonic + "." + c.Fields[i]
+	}
+	return c.Mnemonic + "." + strconv.Itoa(i)
+}
+
+func (c settingsCommand) parse(line string, s Settings) error {
+	payload := strings.TrimPrefix(strings.TrimSuffix(line, "\r"), c.Mnemonic+" ")
+	if payload == line || payload == "" {
+		return parseError(c.Mnemonic+" line", line, "")
+	}
+	for i, v := range strings.Split(payload, ",") {
+		s[c.key(i)] = v
+	}
+	return nil
+}
+
+// line rebuilds the set command for c, or returns "" if s has none of its
+// fields.
+func (c settingsCommand) line(s Settings) string {
+	var values []string
+	for i := 0; ; i++ {
+		v, ok := s[c.key(i)]
+		if !ok {
+			break
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return c.Mnemonic + " " + strings.Join(values, ",")
+}
+
+func (s Settings) Lines() (v []string) {
+	for _, c := range settingsCommands {
+		if l := c.line(s); l != "" {
+			v = append(v, l)
+		}
+	}
+	return v
+}
+
+func (r *Radio) ReadSettings() (s Settings, err error) {
+	span := r.startSpan("read settings")
+	defer func() { r.endSpan(span, err) }()
+	s = Settings{}
+	for _, c := range settingsCommands {
+		line, err := r.WriteReadString(c.Mnemonic + "\r")
+		if errors.Is(err, ErrRadioNAK) {
+			log.Warn().Str("command", c.Mnemonic).Msg("radio does not support settings command, skipping")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+		if err := c.parse(line, s); err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// WriteSettings programs s, keeping the PC port at the speed of the
+// current connection whatever s says.
+func (r *Radio) WriteSettings(s Settings) (err error) {
+	span := r.startSpan("write settings")
+	defer func() { r.endSpan(span, err) }()
+	s = r.keepPortSpeed(s)
+	for _, l := range s.Lines() {
+		if _, err := r.WriteReadString(l + "\r"); err != nil {
+			return fmt.Errorf("error writing settings: %w", err)
+		}
+	}
+	return nil
+}
+
+// SettingChange is a setting changed by ApplySettings.
+type SettingChange struct {
+	Key      string
+	Old, New string
+}
+
+// ApplySettings programs the settings of s that differ from those of the
+// radio and returns them. Commands without a difference are not sent, and
+// settings the radio does not report are skipped.
+func (r *Radio) ApplySettings(s Settings) (changes []SettingChange, err error) {
+	span := r.startSpan("apply settings")
+	defer func() { r.endSpan(span, err) }()
+	current, err := r.ReadSettings()
+	if err != nil {
+		return nil, err
+	}
+	s = r.keepPortSpeed(s)
+	skipped := 0
+	for k := range s {
+		if _, ok := current[k]; !ok {
+			skipped++
+		}
+	}
+	if skipped > 0 {
+		log.Warn().Int("settings", skipped).Msgf("%s does not report some settings, skipping them", r.Model)
+	}
+	for _, c := range settingsCommands {
+		var changed []SettingChange
+		for i := 0; ; i++ {
+			k := c.key(i)
+			old, ok := current[k]
+			if !ok {
+				break
+			}
+			if v, ok := s[k]; ok && v != old {
+				changed = append(changed, SettingChange{k, old, v})
+				current[k] = v
+			}
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		if _, err := r.WriteReadString(c.line(current) + "\r"); err != nil {
+			return changes, fmt.Errorf("error writing settings: %w", err)
+		}
+		changes = append(changes, changed...)
+	}
+	return changes, nil
+}
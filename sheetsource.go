@@ -0,0 +1,223 @@
+package kenwoodutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RemoteSheet is a spreadsheet downloaded from a URL, like a published
+// Google Sheet.
+type RemoteSheet struct {
+	URL     string
+	Data    []byte
+	SHA256  string
+	Fetched time.Time
+	// Cached is set when Data comes from the cache, because the sheet did
+	// not change or could not be downloaded.
+	Cached bool
+	// Changed is set when the sheet differs from the last downloaded copy.
+	Changed bool
+}
+
+type sheetCacheEntry struct {
+	URL          string
+	ETag         string
+	LastModified string
+	SHA256       string
+	Fetched      time.Time
+}
+
+func IsSheetURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+func sheetCachePaths(url string) (meta, data string, err error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", "", fmt.Errorf("error locating cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(url))
+	base := filepath.Join(dir, "kenwoodutil", "sheets", hex.EncodeToString(sum[:8]))
+	return base + ".json", base + ".data", nil
+}
+
+func loadSheetCache(url string) (*sheetCacheEntry, []byte) {
+	metaPath, dataPath, err := sheetCachePaths(url)
+	if err != nil {
+		return nil, nil
+	}
+	j, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil, nil
+	}
+	var e sheetCacheEntry
+	if err := json.Unmarshal(j, &e); err != nil || e.URL != url {
+		return nil, nil
+	}
+	data, err := os.ReadFile(dataPath)
+	if err != nil {
+		return nil, nil
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != e.SHA256 {
+		log.Warn().Str("url", url).Msg("cached sheet is corrupt, ignoring it")
+		return nil, nil
+	}
+	return &e, data
+}
+
+func saveSheetCache(e *sheetCacheEntry, data []byte) error {
+	metaPath, dataPath, err := sheetCachePaths(e.URL)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(metaPath), 0755); err != nil {
+		return fmt.Errorf("error creating cache directory: %w", err)
+	}
+	j, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling sheet cache: %w", err)
+	}
+	if err := WriteFileAtomic(dataPath, data, 0644, false); err != nil {
+		return fmt.Errorf("error writing sheet cache: %w", err)
+	}
+	if err := WriteFileAtomic(metaPath, j, 0644, false); err != nil {
+		return fmt.Errorf("error writing sheet cache: %w", err)
+	}
+	return nil
+}
+
+// FetchSheet downloads the spreadsheet at url, reusing the cached copy when
+// the server reports it unchanged. When the download fails the cached copy
+// is returned with a warning. With offline set only the cache is used.
+func FetchSheet(url string, offline bool) (*RemoteSheet, error) {
+	cached, cachedData := loadSheetCache(url)
+	fromCache := func() *RemoteSheet {
+		return &RemoteSheet{URL: url, Data: cachedData, SHA256: cached.SHA256, Fetched: cached.Fetched, Cached: true}
+	}
+	if offline {
+		if cached == nil {
+			return nil, fmt.Errorf("error: %s is not in the sheet cache", url)
+		}
+		return fromCache(), nil
+	}
+
+	data, e, err := downloadSheet(url, cached)
+	if err != nil {
+		if cached == nil {
+			return nil, err
+		}
+		log.Warn().Err(err).Time("fetched", cached.Fetched).Msg("using cached copy of the sheet")
+		return fromCache(), nil
+	}
+	if data == nil {
+		return fromCache(), nil
+	}
+	if err := saveSheetCache(e, data); err != nil {
+		log.Warn().Err(err).Msg("not caching sheet")
+	}
+	s := &RemoteSheet{URL: url, Data: data, SHA256: e.SHA256, Fetched: e.Fetched}
+	s.Changed = cached != nil && cached.SHA256 != e.SHA256
+	return s, nil
+}
+
+// downloadSheet fetches url, sending the validators of the cached copy. It
+// returns no data when the server answers that the copy is current.
+func downloadSheet(url string, cached *sheetCacheEntry) ([]byte, *sheetCacheEntry, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error downloading sheet: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error downloading sheet: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return nil, nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("error downloading sheet: %s", resp.Status)
+	}
+	// a sheet that is not shared redirects to a sign in page
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return nil, nil, errors.New("error downloading sheet: got a web page, is the sheet published to the web?")
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error downloading sheet: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	e := &sheetCacheEntry{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		SHA256:       hex.EncodeToString(sum[:]),
+		Fetched:      time.Now(),
+	}
+	return data, e, nil
+}
+
+// ReadSheetChannels reads channels from a CSV or xlsx spreadsheet file or
+// URL.
+func ReadSheetChannels(format, path string, m CSVMapping, fill FillFunc, offline bool) ([]MemoryEntry, bool, error) {
+	var r interface {
+		io.Reader
+		io.ReaderAt
+	}
+	var size int64
+	if IsSheetURL(path) {
+		sheet, err := FetchSheet(path, offline)
+		if err != nil {
+			return nil, false, err
+		}
+		log.Info().Str("sha256", sheet.SHA256).Bool("cached", sheet.Cached).Bool("changed", sheet.Changed).Time("fetched", sheet.Fetched).Msg("Sheet downloaded.")
+		r, size = bytes.NewReader(sheet.Data), int64(len(sheet.Data))
+	} else if path == StdioPath {
+		data, err := ReadFileOrStdin(path)
+		if err != nil {
+			return nil, false, fmt.Errorf("error reading spreadsheet: %w", err)
+		}
+		r, size = bytes.NewReader(data), int64(len(data))
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, false, fmt.Errorf("error opening spreadsheet: %w", err)
+		}
+		defer f.Close()
+		st, err := f.Stat()
+		if err != nil {
+			return nil, false, fmt.Errorf("error opening spreadsheet: %w", err)
+		}
+		r, size = f, st.Size()
+	}
+	if format == "csv" {
+		return ReadCSVChannels(r, m, fill)
+	}
+	rows, err := ReadXLSXRows(r, size)
+	if err != nil {
+		return nil, false, err
+	}
+	return SheetChannels(rows, m, fill)
+}
@@ -0,0 +1,80 @@
+package kenwoodutil
+
+import (
+	"time"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// BandSnapshot is what a band is tuned to.
+type BandSnapshot struct {
+	Band       string
+	Mode       string
+	Channel    *int   `json:",omitempty"`
+	Name       string `json:",omitempty"`
+	Frequency  string
+	Modulation string
+}
+
+// StationSnapshot describes the radio as it is at Time, for logbooks and
+// station documentation. The checksums are those a dump read from the radio
+// would be saved with, and are left out when memory was not read.
+type StationSnapshot struct {
+	Time        time.Time
+	Tool        string
+	Radio       RadioInfo
+	Firmware    string `json:",omitempty"`
+	ControlBand string
+	PTTBand     string
+	Bands       []BandSnapshot
+	Checksums   *DumpChecksums `json:",omitempty"`
+}
+
+// Snapshot reads what both bands are tuned to, and with memory set the
+// memory channels, special channels and settings to checksum them.
+func (r *Radio) Snapshot(memory bool) (s *StationSnapshot, err error) {
+	span := r.startSpan("snapshot")
+	defer func() { r.endSpan(span, err) }()
+	s = &StationSnapshot{Time: time.Now().UTC(), Tool: "kenwoodutil " + ToolVersion, Radio: *r.Info()}
+	s.Firmware = s.Radio.Firmware
+	control, ptt, err := r.GetBand()
+	if err != nil {
+		return nil, err
+	}
+	s.ControlBand, s.PTTBand = units.BandName(control), units.BandName(ptt)
+	for band := 0; band < 2; band++ {
+		st, err := r.BandStatus(band)
+		if err != nil {
+			return nil, err
+		}
+		b := BandSnapshot{
+			Band:       units.BandName(band),
+			Mode:       VFOModeName(st.Mode),
+			Name:       st.Name,
+			Frequency:  units.FormatMHz(st.Frequency),
+			Modulation: units.ModeName(st.Modulation),
+		}
+		if st.Mode == MemoryMode {
+			ch := st.Channel
+			b.Channel = &ch
+		}
+		s.Bands = append(s.Bands, b)
+	}
+	if !memory {
+		return s, nil
+	}
+	d := &Dump{}
+	if err := r.ReadMemory(); err != nil {
+		return nil, err
+	}
+	d.Memory = r.OccupedChannels()
+	if d.Special, err = r.ReadSpecialChannels(); err != nil {
+		return nil, err
+	}
+	if d.Settings, err = r.ReadSettings(); err != nil {
+		return nil, err
+	}
+	c := d.checksums()
+	s.Checksums = &c
+	return s, nil
+}
@@ -0,0 +1,103 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	CCCommandFormat = "CC %1d\r"
+
+	// Program scan edges L0-L9 and U0-U9 are addressed by ME as 1000-1019.
+	ScanEdgeBase = 1000
+	ScanEdges    = 20
+)
+
+// Label returns the name the radio display uses for the channel.
+func (m *MemoryEntry) Label() string {
+	switch {
+	case m.Kind == CallChannel:
+		return fmt.Sprintf("C%d", m.Number)
+	case m.Kind == ScanEdgeChannel && m.Number < ScanEdgeBase+ScanEdges/2:
+		return fmt.Sprintf("L%d", m.Number-ScanEdgeBase)
+	case m.Kind == ScanEdgeChannel:
+		return fmt.Sprintf("U%d", m.Number-ScanEdgeBase-ScanEdges/2)
+	}
+	return fmt.Sprintf("%03d", m.Number)
+}
+
+func (r *Radio) ReadCallChannel(band int) (m MemoryEntry, err error) {
+	line, err := r.WriteReadString(fmt.Sprintf(CCCommandFormat, band))
+	if err != nil {
+		return MemoryEntry{}, fmt.Errorf("error reading call channel: %w", err)
+	}
+	m.Kind = CallChannel
+	if err := m.ReadChannelLine(line); err != nil {
+		return MemoryEntry{}, err
+	}
+	m.Canonicalize()
+	return m, nil
+}
+
+// ReadSpecialChannels reads call channels of both bands and program scan
+// edges. Channels the radio does not know about are skipped.
+func (r *Radio) ReadSpecialChannels() (v []MemoryEntry, err error) {
+	span := r.startSpan("read special channels")
+	defer func() { r.endSpan(span, err) }()
+	for band := 0; band < 2; band++ {
+		m, err := r.ReadCallChannel(band)
+		if errors.Is(err, ErrRadioNAK) {
+			log.Warn().Int("band", band).Msg("radio has no call channel, skipping")
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, m)
+	}
+	for ch := ScanEdgeBase; ch < ScanEdgeBase+ScanEdges; ch++ {
+		// scan edges carry no names, so only the ME line is read
+		line, err := r.WriteReadString(fmt.Sprintf(MECommandFormat, ch))
+		if errors.Is(err, ErrRadioNAK) {
+			log.Warn().Msg("radio has no program scan edges, skipping")
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading scan edge: %w", err)
+		}
+		m := MemoryEntry{Kind: ScanEdgeChannel}
+		if err := m.ReadChannelLine(line); err != nil {
+			return nil, err
+		}
+		if m.RXFrequency != 0 {
+			m.Canonicalize()
+			v = append(v, m)
+		}
+	}
+	return v, nil
+}
+
+func (r *Radio) WriteSpecialChannel(m MemoryEntry) error {
+	switch m.Kind {
+	case CallChannel, ScanEdgeChannel:
+	default:
+		return fmt.Errorf("error: %s channel %s is not a special channel", m.Kind, m.Label())
+	}
+	if _, err := r.WriteReadString(m.WriteChannelLine() + "\r"); err != nil {
+		return fmt.Errorf("error writing %s channel %s: %w", m.Kind, m.Label(), err)
+	}
+	return nil
+}
+
+func (r *Radio) WriteSpecialChannels(channels []MemoryEntry) (err error) {
+	span := r.startSpan("write special channels")
+	defer func() { r.endSpan(span, err) }()
+	for _, m := range channels {
+		if err := r.WriteSpecialChannel(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
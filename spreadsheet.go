@@ -0,0 +1,375 @@
+package kenwoodutil
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// CSVMapping maps channel fields to the spreadsheet column headers holding
+// them. Fields not mapped are looked up in a column of their own name.
+type CSVMapping map[string]string
+
+// CSVFields are the channel fields a spreadsheet column can map to.
+var CSVFields = []string{"channel", "freq", "tx", "split", "offset", "tone", "ctcss", "dcs", "mode", "step", "lockout", "name"}
+
+// ParseCSVMapping parses "freq=Frequency MHz,name=Label".
+func ParseCSVMapping(s string) (CSVMapping, error) {
+	m := CSVMapping{}
+	if strings.TrimSpace(s) == "" {
+		return m, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("error parsing column mapping %q: expected field=Column", part)
+		}
+		m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return m, m.check()
+}
+
+// LoadCSVMapping reads a mapping from a JSON file like
+// {"freq": "Frequency MHz", "name": "Label"}.
+func LoadCSVMapping(path string) (CSVMapping, error) {
+	j, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading column mapping: %w", err)
+	}
+	m := CSVMapping{}
+	if err := json.Unmarshal(j, &m); err != nil {
+		return nil, fmt.Errorf("error parsing column mapping %s: %w", path, err)
+	}
+	return m, m.check()
+}
+
+func (m CSVMapping) check() error {
+	for field := range m {
+		known := false
+		for _, f := range CSVFields {
+			known = known || f == field
+		}
+		if !known {
+			return fmt.Errorf("error: unknown channel field %q in column mapping, expected one of %s", field, strings.Join(CSVFields, ", "))
+		}
+	}
+	return nil
+}
+
+func (m CSVMapping) column(field string) string {
+	if c, ok := m[field]; ok {
+		return c
+	}
+	return field
+}
+
+// FillFunc fills in fields of an imported channel. given tells which
+// channel fields the source had, named as spreadsheet fields.
+type FillFunc func(m *MemoryEntry, given func(field string) bool) error
+
+// ReadCSVChannels reads channels from a CSV spreadsheet with a header row.
+func ReadCSVChannels(r io.Reader, mapping CSVMapping, fill FillFunc) (channels []MemoryEntry, hasNumbers bool, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading spreadsheet: %w", err)
+	}
+	return SheetChannels(rows, mapping, fill)
+}
+
+// SheetChannels reads channels from spreadsheet rows, the first of which
+// is the header. Rows without a frequency are skipped. Channel numbers are
+// only set when the channel field is mapped to a column; hasNumbers tells
+// if it is. fill, if not nil, completes every channel read.
+func SheetChannels(rows [][]string, mapping CSVMapping, fill FillFunc) (channels []MemoryEntry, hasNumbers bool, err error) {
+	if len(rows) == 0 {
+		return nil, false, fmt.Errorf("error: spreadsheet is empty")
+	}
+	header := rows[0]
+	index := map[string]int{}
+	for _, field := range CSVFields {
+		want := mapping.column(field)
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), want) {
+				index[field] = i
+			}
+		}
+	}
+	if _, ok := index["freq"]; !ok {
+		return nil, false, fmt.Errorf("error: no %q column in spreadsheet, map freq to the frequency column", mapping.column("freq"))
+	}
+	_, hasNumbers = index["channel"]
+
+	for i, record := range rows[1:] {
+		get := func(field string) string {
+			if i, ok := index[field]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if get("freq") == "" {
+			continue
+		}
+		m, err := CSVChannel(get)
+		if err == nil && fill != nil {
+			err = fill(&m, func(field string) bool { return get(field) != "" })
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("error in spreadsheet row %d: %w", i+2, err)
+		}
+		channels = append(channels, m)
+	}
+	return channels, hasNumbers, nil
+}
+
+// SheetMHz formats at full precision, units.FormatMHz would drop 6.25 kHz steps.
+func SheetMHz(hz uint32) string {
+	return strconv.FormatFloat(float64(hz)/1e6, 'f', -1, 64)
+}
+
+// SheetColumns are the columns SheetRows can write. Headers are the field
+// names SheetChannels reads, so exports import back without a mapping.
+var SheetColumns = map[string]struct {
+	Header string
+	Value  func(m MemoryEntry) string
+}{
+	"ch": {"channel", func(m MemoryEntry) string { return fmt.Sprintf("%03d", m.Number) }},
+	"rx": {"freq", func(m MemoryEntry) string { return SheetMHz(m.RXFrequency) }},
+	"tx": {"tx", func(m MemoryEntry) string {
+		if tx := m.ShiftedTX(); tx != 0 {
+			return SheetMHz(tx)
+		}
+		return ""
+	}},
+	"split": {"split", func(m MemoryEntry) string {
+		if m.Split {
+			return "yes"
+		}
+		return ""
+	}},
+	"offset": {"offset", func(m MemoryEntry) string {
+		switch m.ShiftDirection {
+		case 1:
+			return "+" + SheetMHz(m.OffsetFrequency)
+		case 2:
+			return "-" + SheetMHz(m.OffsetFrequency)
+		}
+		return ""
+	}},
+	"tone": {"tone", func(m MemoryEntry) string {
+		if m.ToneEnabled == 1 {
+			return fmt.Sprintf("%.1f", units.ToneHz(m.ToneFrequency))
+		}
+		return ""
+	}},
+	"ctcss": {"ctcss", func(m MemoryEntry) string {
+		if m.CTCSSEnabled == 1 {
+			return fmt.Sprintf("%.1f", units.ToneHz(m.CTCSSFrequency))
+		}
+		return ""
+	}},
+	"dcs": {"dcs", func(m MemoryEntry) string {
+		if m.DCSEnabled == 1 && int(m.DCSFrequency) < len(units.DCSCodes) {
+			return fmt.Sprintf("%03d", units.DCSCodes[m.DCSFrequency])
+		}
+		return ""
+	}},
+	"mode": {"mode", func(m MemoryEntry) string { return units.ModeName(m.Mode) }},
+	"name": {"name", func(m MemoryEntry) string { return m.Name }},
+	"step": {"step", func(m MemoryEntry) string {
+		if hz, ok := units.StepHz(m.RXStepSize); ok {
+			return strconv.FormatFloat(float64(hz)/1e3, 'f', -1, 64)
+		}
+		return ""
+	}},
+	"lockout": {"lockout", func(m MemoryEntry) string {
+		if m.LockOut == 1 {
+			return "yes"
+		}
+		return ""
+	}},
+}
+
+// DefaultSheetColumns is the full channel layout, which imports back
+// losslessly but for the step of a split transmit frequency, taken to fit
+// it. The offset is there to read, tx wins over it on import.
+var DefaultSheetColumns = []string{"ch", "rx", "tx", "split", "offset", "tone", "ctcss", "dcs", "mode", "step", "lockout", "name"}
+
+// ParseSheetColumns parses a column list like "ch,name,rx,tx,tone,mode".
+func ParseSheetColumns(s string) ([]string, error) {
+	var columns []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		switch c {
+		case "channel":
+			c = "ch"
+		case "freq":
+			c = "rx"
+		}
+		if _, ok := SheetColumns[c]; !ok {
+			var known []string
+			for k := range SheetColumns {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("error: unknown column %q, expected some of %s", c, strings.Join(known, ", "))
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// SheetRows lays out channels as spreadsheet rows of columns, with a
+// header row first.
+func SheetRows(channels []MemoryEntry, columns []string) [][]string {
+	return SharedSheetRows(channels, columns, nil)
+}
+
+// SharedSheetRows is SheetRows with the frequencies and names of private
+// channels replaced by placeholders, for sheets to be shared.
+func SharedSheetRows(channels []MemoryEntry, columns []string, private map[uint16]bool) [][]string {
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = SheetColumns[c].Header
+	}
+	rows := [][]string{header}
+	for _, m := range channels {
+		if m.RXFrequency == 0 {
+			continue
+		}
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			row[i] = SheetColumns[c].Value(m)
+			if !private[m.Number] || row[i] == "" {
+				continue
+			}
+			switch c {
+			case "rx", "tx":
+				row[i] = PrivateTag
+			case "name":
+				row[i] = fmt.Sprintf("CH%03d", m.Number)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// sheetYesNo parses a yes or no column, empty for no.
+func sheetYesNo(field, v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "yes", "on", "1":
+		return true, nil
+	case "no", "off", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("error parsing %s %q: expected yes or no", field, v)
+}
+
+// maxOffset is the widest repeater shift the radio takes, transmit
+// frequencies further away make split channels.
+const maxOffset = 29950000
+
+func CSVChannel(get func(field string) string) (m MemoryEntry, err error) {
+	if m.RXFrequency, err = units.ParseMHz(get("freq")); err != nil {
+		return m, err
+	}
+	m.RXStepSize = units.CanonicalStep(m.RXFrequency, 0)
+	if v := get("step"); v != "" {
+		khz, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return m, fmt.Errorf("error parsing step %q: expected kHz, like 12.5", v)
+		}
+		if m.RXStepSize, err = units.StepIndex(khz); err != nil {
+			return m, err
+		}
+	}
+	if v := get("channel"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			return m, fmt.Errorf("error parsing channel number %q", v)
+		}
+		m.Number = uint16(n)
+	}
+	if v := get("tx"); v != "" {
+		tx, err := units.ParseMHz(v)
+		if err != nil {
+			return m, err
+		}
+		split, err := sheetYesNo("split", get("split"))
+		if err != nil {
+			return m, err
+		}
+		m.setTX(tx, split)
+	} else if v := get("offset"); v != "" {
+		m.ShiftDirection = 1
+		if strings.HasPrefix(v, "-") {
+			m.ShiftDirection = 2
+		}
+		if m.OffsetFrequency, err = units.ParseMHz(strings.TrimLeft(v, "+-")); err != nil {
+			return m, err
+		}
+		if m.OffsetFrequency == 0 {
+			m.ShiftDirection = 0
+		}
+	}
+	if v := get("tone"); v != "" {
+		hz, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return m, fmt.Errorf("error parsing tone %q", v)
+		}
+		idx, err := units.ToneIndex(hz)
+		if err != nil {
+			return m, err
+		}
+		m.ToneEnabled, m.ToneFrequency = 1, uint16(idx)
+	}
+	if v := get("ctcss"); v != "" {
+		hz, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return m, fmt.Errorf("error parsing CTCSS tone %q", v)
+		}
+		idx, err := units.ToneIndex(hz)
+		if err != nil {
+			return m, err
+		}
+		m.CTCSSEnabled, m.CTCSSFrequency = 1, uint16(idx)
+	}
+	if v := get("dcs"); v != "" {
+		code, err := strconv.ParseUint(strings.TrimLeft(v, "dD"), 10, 16)
+		if err != nil {
+			return m, fmt.Errorf("error parsing DCS code %q", v)
+		}
+		idx, err := units.DCSIndex(uint16(code))
+		if err != nil {
+			return m, err
+		}
+		m.DCSEnabled, m.DCSFrequency = 1, uint16(idx)
+	}
+	if v := get("mode"); v != "" {
+		if m.Mode, err = units.ParseMode(v); err != nil {
+			return m, err
+		}
+	}
+	lockout, err := sheetYesNo("lockout", get("lockout"))
+	if err != nil {
+		return m, err
+	}
+	if lockout {
+		m.LockOut = 1
+	}
+	m.Name = get("name")
+	if len(m.Name) > units.MaxNameLength {
+		m.Name = m.Name[:units.MaxNameLength]
+	}
+	return m, nil
+}
@@ -0,0 +1,134 @@
+package kenwoodutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCSVMapping(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want CSVMapping
+	}{
+		{"", CSVMapping{}},
+		{"  ", CSVMapping{}},
+		{"freq=Frequency MHz", CSVMapping{"freq": "Frequency MHz"}},
+		{" freq = Frequency MHz , name=Label", CSVMapping{"freq": "Frequency MHz", "name": "Label"}},
+		{"name=a=b", CSVMapping{"name": "a=b"}},
+		{"channel=", CSVMapping{"channel": ""}},
+	} {
+		got, err := ParseCSVMapping(tc.in)
+		if err != nil {
+			t.Errorf("ParseCSVMapping(%q): %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseCSVMapping(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+	for _, in := range []string{"freq", "freq=Frequency,", "frequency=MHz", "=Label", "freq=MHz,,name=Label"} {
+		if got, err := ParseCSVMapping(in); err == nil {
+			t.Errorf("ParseCSVMapping(%q) succeeded, got %v", in, got)
+		}
+	}
+}
+
+func TestReadCSVChannels(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		sheet      string
+		mapping    CSVMapping
+		want       []MemoryEntry
+		hasNumbers bool
+	}{
+		{"own column names", "channel,freq,tone,name\n5,145.600,88.5,SR9A\n", nil,
+			[]MemoryEntry{{Number: 5, RXFrequency: 145600000, ToneEnabled: 1, ToneFrequency: 8, Name: "SR9A"}}, true},
+		{"mapped, any case", "Label,FREQUENCY MHZ\nSR9A, 145.600\n", CSVMapping{"freq": "Frequency MHz", "name": "Label"},
+			[]MemoryEntry{{RXFrequency: 145600000, Name: "SR9A"}}, false},
+		{"rows without a frequency skipped", "freq,name\n,heading\n145.5,A\n\n", nil,
+			[]MemoryEntry{{RXFrequency: 145500000, Name: "A"}}, false},
+		{"short rows", "name,freq,tone\nA,145.5\n", nil,
+			[]MemoryEntry{{RXFrequency: 145500000, Name: "A"}}, false},
+		{"offset sign", "freq,offset\n439.150,-7.6\n145.600,+0.6\n145.500,0\n", nil,
+			[]MemoryEntry{
+				{RXFrequency: 439150000, ShiftDirection: 2, OffsetFrequency: 7600000},
+				{RXFrequency: 145600000, ShiftDirection: 1, OffsetFrequency: 600000},
+				{RXFrequency: 145500000},
+			}, false},
+		{"tx at the widest shift and past it", "freq,tx\n145.000,174.950\n145.000,174.950001\n", nil,
+			[]MemoryEntry{
+				{RXFrequency: 145000000, ShiftDirection: 1, OffsetFrequency: 29950000},
+				{RXFrequency: 145000000, Split: true, TXFrequency: 174950001, TXStepSize: 0},
+			}, false},
+		{"DCS with and without D", "freq,dcs\n446.00625,D023\n446.00625,754\n", nil,
+			[]MemoryEntry{
+				{RXFrequency: 446006250, RXStepSize: 1, DCSEnabled: 1},
+				{RXFrequency: 446006250, RXStepSize: 1, DCSEnabled: 1, DCSFrequency: 103},
+			}, false},
+		{"name cut to fit", "freq,name\n145.5,ABCDEFGHIJ\n", nil,
+			[]MemoryEntry{{RXFrequency: 145500000, Name: "ABCDEFGH"}}, false},
+		{"highest channel number", "channel,freq\n65535,145.5\n", nil,
+			[]MemoryEntry{{Number: 65535, RXFrequency: 145500000}}, true},
+	} {
+		got, hasNumbers, err := ReadCSVChannels(strings.NewReader(tc.sheet), tc.mapping, nil)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if hasNumbers != tc.hasNumbers {
+			t.Errorf("%s: hasNumbers = %v, want %v", tc.name, hasNumbers, tc.hasNumbers)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestReadCSVChannelsErrors(t *testing.T) {
+	for _, sheet := range []string{
+		"",
+		"name,frequency\nA,145.5\n",
+		"freq,name\n\"145.5,A\n",
+		"freq\n-145.5\n",
+		"freq\n5000\n",
+		"freq,step\n145.5,7\n",
+		"freq,step\n145.5,kHz\n",
+		"channel,freq\n65536,145.5\n",
+		"channel,freq\n-1,145.5\n",
+		"freq,tone\n145.5,88\n",
+		"freq,ctcss\n145.5,x\n",
+		"freq,dcs\n145.5,D024\n",
+		"freq,dcs\n145.5,DCS\n",
+		"freq,mode\n145.5,USB\n",
+		"freq,offset\n145.5,-x\n",
+		"freq,tx\n145.5,tx\n",
+		"freq,tx,split\n145.5,145.6,maybe\n",
+		"freq,lockout\n145.5,skip\n",
+	} {
+		if got, _, err := ReadCSVChannels(strings.NewReader(sheet), nil, nil); err == nil {
+			t.Errorf("ReadCSVChannels(%q) succeeded, got %+v", sheet, got)
+		}
+	}
+}
+
+func TestDefaultSheetColumnsRoundTrip(t *testing.T) {
+	channels := []MemoryEntry{
+		{Number: 1, RXFrequency: 145500000, Mode: 0, Name: "CALL"},
+		{Number: 12, RXFrequency: 439150000, RXStepSize: 4, ShiftDirection: 2, OffsetFrequency: 7600000, ToneEnabled: 1, ToneFrequency: 12, Name: "CLUB"},
+		{Number: 40, RXFrequency: 145600000, Split: true, TXFrequency: 145000000, CTCSSEnabled: 1, CTCSSFrequency: 8, LockOut: 1},
+		{Number: 41, RXFrequency: 433000000, Split: true, TXFrequency: 1240000000, DCSEnabled: 1, DCSFrequency: 3, Mode: 1, RXStepSize: 5},
+	}
+	got, hasNumbers, err := SheetChannels(SheetRows(channels, DefaultSheetColumns), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasNumbers || len(got) != len(channels) {
+		t.Fatalf("SheetChannels(SheetRows(...)) = %+v, %v", got, hasNumbers)
+	}
+	for i := range channels {
+		if !got[i].Equal(channels[i]) {
+			t.Errorf("channel %d came back as %+v, want %+v", channels[i].Number, got[i], channels[i])
+		}
+	}
+}
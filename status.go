@@ -0,0 +1,188 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+const (
+	VMFormat        = "VM %1d,%1d"
+	VMCommandFormat = "VM %1d\r"
+	MCFormat        = "MC %1d,%03d"
+	MCCommandFormat = "MC %1d\r"
+	SMFormat        = "SM %1d,%02d"
+	SMCommandFormat = "SM %1d\r"
+	BYFormat        = "BY %1d,%1d"
+	BYCommandFormat = "BY %1d\r"
+)
+
+const (
+	VFOMode = iota
+	MemoryMode
+	CallMode
+	WeatherMode
+)
+
+var vfoModeNames = []string{"VFO", "MR", "CALL", "WX"}
+
+func VFOModeName(mode int) string {
+	if mode >= 0 && mode < len(vfoModeNames) {
+		return vfoModeNames[mode]
+	}
+	return strconv.Itoa(mode)
+}
+
+func ParseVFOMode(s string) (int, error) {
+	for i, n := range vfoModeNames {
+		if strings.EqualFold(n, s) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("error parsing band mode %q: expected one of %s", s, strings.Join(vfoModeNames, ", "))
+}
+
+type BandStatus struct {
+	Band       int
+	Mode       int
+	Channel    int    `json:",omitempty"`
+	Name       string `json:",omitempty"`
+	Frequency  uint32
+	Modulation uint8
+	SMeter     int
+}
+
+func (r *Radio) queryInt(command, format string, band int) (int, error) {
+	var b, v int
+	line, err := r.WriteReadString(fmt.Sprintf(command, band))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := fmt.Sscanf(line, format, &b, &v); err != nil {
+		return 0, parseError("reply", line, "")
+	}
+	return v, nil
+}
+
+func (r *Radio) GetVFOMode(band int) (int, error) {
+	v, err := r.queryInt(VMCommandFormat, VMFormat, band)
+	if err != nil {
+		return 0, fmt.Errorf("error reading VFO/memory mode of band %s: %w", units.BandName(band), err)
+	}
+	return v, nil
+}
+
+func (r *Radio) GetMemoryChannel(band int) (int, error) {
+	v, err := r.queryInt(MCCommandFormat, MCFormat, band)
+	if err != nil {
+		return 0, fmt.Errorf("error reading memory channel of band %s: %w", units.BandName(band), err)
+	}
+	return v, nil
+}
+
+func (r *Radio) GetSMeter(band int) (int, error) {
+	v, err := r.queryInt(SMCommandFormat, SMFormat, band)
+	if err != nil {
+		return 0, fmt.Errorf("error reading S-meter of band %s: %w", units.BandName(band), err)
+	}
+	return v, nil
+}
+
+// GetBusy reports whether squelch is open on band.
+func (r *Radio) GetBusy(band int) (bool, error) {
+	v, err := r.queryInt(BYCommandFormat, BYFormat, band)
+	if err != nil {
+		return false, fmt.Errorf("error reading busy state of band %s: %w", units.BandName(band), err)
+	}
+	return v == 1, nil
+}
+
+func (r *Radio) SetVFOMode(band, mode int) error {
+	if _, err := r.WriteReadString(fmt.Sprintf(VMFormat, band, mode) + "\r"); err != nil {
+		return fmt.Errorf("error switching band %s to %s mode: %w", units.BandName(band), VFOModeName(mode), err)
+	}
+	return nil
+}
+
+// SelectMemoryChannel switches band to memory mode and tunes it to channel.
+func (r *Radio) SelectMemoryChannel(band, channel int) error {
+	if err := r.SetVFOMode(band, MemoryMode); err != nil {
+		return err
+	}
+	if _, err := r.WriteReadString(fmt.Sprintf(MCFormat, band, channel) + "\r"); err != nil {
+		return fmt.Errorf("error selecting memory channel %03d on band %s: %w", channel, units.BandName(band), err)
+	}
+	return nil
+}
+
+func (r *Radio) BandStatus(band int) (s BandStatus, err error) {
+	s.Band = band
+	if s.Mode, err = r.GetVFOMode(band); err != nil {
+		return s, err
+	}
+	if s.Mode == MemoryMode {
+		if s.Channel, err = r.GetMemoryChannel(band); err != nil {
+			return s, err
+		}
+		m, err := r.ReadChannel(s.Channel)
+		if err != nil {
+			return s, err
+		}
+		s.Name, s.Frequency, s.Modulation = m.Name, m.RXFrequency, m.Mode
+	} else {
+		v, err := r.GetVFO(band)
+		if err != nil {
+			return s, err
+		}
+		s.Frequency, s.Modulation = v.Frequency, v.Mode
+	}
+	if s.SMeter, err = r.GetSMeter(band); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+func (s BandStatus) String() string {
+	str := fmt.Sprintf("%s %-4s %s MHz %-3s", units.BandName(s.Band), VFOModeName(s.Mode), units.FormatMHz(s.Frequency), units.ModeName(s.Modulation))
+	if s.Mode == MemoryMode {
+		str += fmt.Sprintf(" ch %03d %-8s", s.Channel, s.Name)
+	}
+	return str + fmt.Sprintf(" S%d", s.SMeter)
+}
+
+// SignalSample is what a band receives at one moment.
+type SignalSample struct {
+	Time time.Time
+	BandStatus
+	Busy bool
+}
+
+// Sample polls band status, S-meter and squelch of band.
+func (r *Radio) Sample(band int) (s SignalSample, err error) {
+	s.Time = time.Now()
+	if s.BandStatus, err = r.BandStatus(band); err != nil {
+		return s, err
+	}
+	if s.Busy, err = r.GetBusy(band); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// sMeterMax is the strongest S-meter reading.
+const sMeterMax = 5
+
+func (s SignalSample) String() string {
+	squelch := "closed"
+	if s.Busy {
+		squelch = "OPEN"
+	}
+	n := s.SMeter
+	if n > sMeterMax {
+		n = sMeterMax
+	}
+	return fmt.Sprintf("%s %s [%-*s] %s", s.Time.Format("15:04:05"), s.BandStatus, sMeterMax, strings.Repeat("#", n), squelch)
+}
@@ -0,0 +1,122 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Support tells by mnemonic whether the radio answers a command.
+type Support map[string]bool
+
+// Operation is a command of this tool talking to the radio, with the
+// protocol commands it cannot do without.
+type Operation struct {
+	Name  string
+	Needs []string
+}
+
+// Operations are checked by Require before they start, so that a partly
+// supported radio fails them upfront instead of halfway through a
+// transfer. Commands missing here are not checked.
+var Operations = []Operation{
+	{"read", []string{"ME", "MN"}},
+	{"write", []string{"ME", "MN"}},
+	{"restore", []string{"ME", "MN"}},
+	{"clone", []string{"ME", "MN"}},
+	{"reorganize", []string{"ME", "MN"}},
+	{"diff", []string{"ME", "MN"}},
+	{"pm", []string{"PM", "FO", "BC", "MU"}},
+	{"tnc", []string{"TN"}},
+	{"clock", []string{"CK"}},
+	{"lock", []string{"LK"}},
+	{"display", []string{"MU"}},
+	{"power", []string{"PS", "PC"}},
+	{"scan", []string{"SC", "MU"}},
+	{"band", []string{"BC", "VM"}},
+	{"vfo", []string{"BC", "FO"}},
+	{"quick", []string{"BC", "VM", "MC", "ME", "MN"}},
+	{"bookmark", []string{"BC", "VM", "MC", "FO", "ME", "MN"}},
+	{"status", []string{"BC", "VM", "MC", "FO", "SM", "ME"}},
+	{"watch", []string{"BC", "VM", "MC", "FO", "SM", "ME"}},
+	{"monitor", []string{"BC", "VM", "MC", "FO", "SM", "BY", "ME"}},
+	{"drivetest", []string{"BC", "VM", "MC", "FO", "SM", "BY", "ME"}},
+	{"survey", []string{"BC", "VM", "FO", "SM", "BY"}},
+	{"ptt", []string{"TX", "RX"}},
+}
+
+// Supports reports whether the radio answers mnemonic, as probed or else as
+// the command catalog has it. Commands the catalog does not know are taken
+// to be supported.
+func (r *Radio) Supports(mnemonic string) bool {
+	if r.Support != nil {
+		if ok, probed := r.Support[mnemonic]; probed {
+			return ok
+		}
+	}
+	c, ok := LookupCommand(mnemonic)
+	return !ok || c.Supports(r.Model)
+}
+
+// Missing returns the commands op needs that the radio does not answer.
+func (r *Radio) Missing(op Operation) []string {
+	var missing []string
+	for _, m := range op.Needs {
+		if !r.Supports(m) {
+			missing = append(missing, m)
+		}
+	}
+	return missing
+}
+
+// Require fails with ErrUnsupported when the radio lacks a command the
+// operation called name needs.
+func (r *Radio) Require(name string) error {
+	for _, op := range Operations {
+		if op.Name != name {
+			continue
+		}
+		if missing := r.Missing(op); len(missing) > 0 {
+			return fmt.Errorf("error: %s cannot %s, it does not answer %s: %w", r.Model, name, describeCommands(missing), ErrUnsupported)
+		}
+	}
+	return nil
+}
+
+// describeCommands names mnemonics with what they do, like "TN (built in
+// TNC mode)".
+func describeCommands(mnemonics []string) string {
+	var v []string
+	for _, m := range mnemonics {
+		if c, ok := LookupCommand(m); ok {
+			m += " (" + c.Description + ")"
+		}
+		v = append(v, m)
+	}
+	return strings.Join(v, ", ")
+}
+
+// ProbeSupport sends the probe query of every command of the catalog and
+// notes which ones the radio answers. Radios ignoring unknown commands cost
+// a reply timeout per command.
+func (r *Radio) ProbeSupport() (Support, error) {
+	s := Support{}
+	for _, c := range protocolTable {
+		if c.Probe == "" {
+			continue
+		}
+		_, err := r.WriteReadString(c.Probe + "\r")
+		switch {
+		case err == nil:
+			s[c.Mnemonic] = true
+		case errors.Is(err, ErrRadioNAK), errors.Is(err, ErrTimeout), errors.Is(err, ErrGarbage):
+			log.Debug().Str("command", c.Mnemonic).Err(err).Msg("command not supported")
+			s[c.Mnemonic] = false
+		default:
+			return nil, fmt.Errorf("error probing %s: %w", c.Mnemonic, err)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,146 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// Heard is the sidecar record of a channel stored by a band survey.
+type Heard struct {
+	First, Last time.Time
+	Count       int
+	PeakSMeter  int
+}
+
+// SurveyRange steps the VFO of band from from to to by step Hz, waiting
+// dwell on each frequency for the squelch to settle, and calls fn with the
+// signal found there until fn returns false or the range ends. The VFO is
+// tuned back where it was afterwards.
+func (r *Radio) SurveyRange(band int, from, to, step uint32, dwell time.Duration, fn func(s SignalSample) bool) (err error) {
+	span := r.startSpan("survey", "band", units.BandName(band), "from", units.FormatMHz(from), "to", units.FormatMHz(to))
+	defer func() { r.endSpan(span, err) }()
+	c := r.Capabilities()
+	if !c.CanReceive(from) || !c.CanReceive(to) {
+		return fmt.Errorf("error: %s-%s MHz is out of %s range: %w", units.FormatMHz(from), units.FormatMHz(to), r.Model, ErrOutOfRange)
+	}
+	if err := r.SetVFOMode(band, VFOMode); err != nil {
+		return err
+	}
+	v, err := r.GetVFO(band)
+	if err != nil {
+		return err
+	}
+	defer func(before VFO) {
+		if rerr := r.SetVFO(before); err == nil {
+			err = rerr
+		}
+	}(v)
+	stepIndex, err := units.StepIndex(float64(step) / 1e3)
+	if err != nil {
+		return err
+	}
+	v.StepSize = stepIndex
+	for hz := from; hz <= to && hz >= from; hz += step {
+		v.Frequency = hz
+		if err := r.SetVFO(v); err != nil {
+			return err
+		}
+		time.Sleep(dwell)
+		s, err := r.Sample(band)
+		if err != nil {
+			return err
+		}
+		if !fn(s) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ScratchBank stores the frequencies a survey finds active into a range of
+// memory channels, one channel per frequency, with when they were heard
+// kept in the dump metadata.
+type ScratchBank struct {
+	Radio    *Radio
+	Dump     *Dump
+	channels []int
+	byFreq   map[uint32]uint16
+}
+
+// NewScratchBank reads channels from the radio, so that frequencies already
+// stored there are counted again rather than stored twice.
+func NewScratchBank(r *Radio, d *Dump, channels []int) (*ScratchBank, error) {
+	b := &ScratchBank{Radio: r, Dump: d, channels: channels, byFreq: map[uint32]uint16{}}
+	for _, n := range channels {
+		if err := r.CheckChannel(n); err != nil {
+			return nil, err
+		}
+		m, err := r.readChannelRetrying(n)
+		if err != nil {
+			return nil, err
+		}
+		r.Memory[n] = m
+		r.reindex()
+		if m.RXFrequency != 0 {
+			b.byFreq[m.RXFrequency] = uint16(n)
+		}
+	}
+	return b, nil
+}
+
+// Store records an active signal, writing a channel for frequencies not
+// heard before. full is set when no channel is left for it.
+func (b *ScratchBank) Store(s SignalSample) (channel uint16, added, full bool, err error) {
+	channel, ok := b.byFreq[s.Frequency]
+	if !ok {
+		free := -1
+		for _, n := range b.channels {
+			if b.Radio.Memory[n].RXFrequency == 0 {
+				free = n
+				break
+			}
+		}
+		if free < 0 {
+			return 0, false, true, nil
+		}
+		channel = uint16(free)
+		m := MemoryEntry{
+			Number:      channel,
+			RXFrequency: s.Frequency,
+			RXStepSize:  units.CanonicalStep(s.Frequency, 0),
+			Mode:        s.Modulation,
+			// month and day the frequency was first heard
+			Name: s.Time.Format("0102"),
+		}
+		if err := b.Radio.SetChannel(m); err != nil {
+			return 0, false, false, err
+		}
+		if _, err := b.Radio.WriteChannel(int(channel)); err != nil {
+			return 0, false, false, err
+		}
+		b.byFreq[s.Frequency] = channel
+		memory := []MemoryEntry{m}
+		for _, old := range b.Dump.Memory {
+			if old.Number != channel {
+				memory = append(memory, old)
+			}
+		}
+		b.Dump.Memory = memory
+		sort.SliceStable(b.Dump.Memory, func(i, j int) bool { return b.Dump.Memory[i].Number < b.Dump.Memory[j].Number })
+		b.Dump.RecordImport(channel, "survey")
+		added = true
+	}
+	meta := b.Dump.ChannelMeta(channel)
+	if meta.Heard == nil {
+		meta.Heard = &Heard{First: s.Time}
+	}
+	meta.Heard.Last = s.Time
+	meta.Heard.Count++
+	if s.SMeter > meta.Heard.PeakSMeter {
+		meta.Heard.PeakSMeter = s.SMeter
+	}
+	return channel, added, false, nil
+}
@@ -0,0 +1,131 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrivateTag marks channels whose frequency and name are left out of
+// exports made to be shared.
+const PrivateTag = "private"
+
+// Tagged returns the channels tagged with tag, compared without case.
+func (d *Dump) Tagged(tag string) map[uint16]bool {
+	tagged := map[uint16]bool{}
+	for n, m := range d.Meta {
+		for _, t := range m.Tags {
+			if strings.EqualFold(t, tag) {
+				tagged[n] = true
+			}
+		}
+	}
+	return tagged
+}
+
+// Tags returns how many channels have each tag.
+func (d *Dump) Tags() map[string]int {
+	tags := map[string]int{}
+	for _, m := range d.Meta {
+		for _, t := range m.Tags {
+			tags[t]++
+		}
+	}
+	return tags
+}
+
+// SetTag tags or untags a channel. Only channels in the dump can be tagged.
+func (d *Dump) SetTag(number uint16, tag string, on bool) error {
+	if tag == "" || strings.ContainsAny(tag, ",: ") {
+		return fmt.Errorf("error: tag %q can not be empty nor have commas, colons or spaces", tag)
+	}
+	if _, ok := d.Channel(number); !ok && on {
+		return fmt.Errorf("error tagging channel %03d: %w", number, ErrEmptyChannel)
+	}
+	if !on && d.Meta[number] == nil {
+		return nil
+	}
+	meta := d.ChannelMeta(number)
+	var tags []string
+	for _, t := range meta.Tags {
+		if !strings.EqualFold(t, tag) {
+			tags = append(tags, t)
+		}
+	}
+	if on {
+		tags = append(tags, tag)
+		sort.Strings(tags)
+	}
+	meta.Tags = tags
+	return nil
+}
+
+// ChannelFilter selects channels of a dump by bank, group or tag, as in
+// "bank:3,tag:SOTA". Channels matching any of the terms are selected.
+type ChannelFilter []string
+
+func ParseChannelFilter(s string) (ChannelFilter, error) {
+	var f ChannelFilter
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		kind, value := term, ""
+		if i := strings.IndexByte(term, ':'); i >= 0 {
+			kind, value = term[:i], term[i+1:]
+		}
+		switch kind {
+		case "bank":
+			if n, err := strconv.Atoi(value); err != nil || n < 0 {
+				return nil, fmt.Errorf("error: bank %q is not a bank number", value)
+			}
+		case "group", "tag":
+			if value == "" {
+				return nil, fmt.Errorf("error: %s filter without a name", kind)
+			}
+		default:
+			return nil, fmt.Errorf("error: unknown filter %q, expected bank:n, group:name or tag:name", term)
+		}
+		f = append(f, kind+":"+value)
+	}
+	if len(f) == 0 {
+		return nil, errors.New("error: empty channel filter")
+	}
+	return f, nil
+}
+
+// Select returns the memory channels of d matching f.
+func (f ChannelFilter) Select(d *Dump) ([]MemoryEntry, error) {
+	var match []func(n uint16) bool
+	for _, term := range f {
+		i := strings.IndexByte(term, ':')
+		kind, value := term[:i], term[i+1:]
+		switch kind {
+		case "bank":
+			bank, _ := strconv.Atoi(value)
+			match = append(match, func(n uint16) bool { return int(n)/BankSize == bank })
+		case "group":
+			g, err := d.Group(value)
+			if err != nil {
+				return nil, err
+			}
+			match = append(match, g.Contains)
+		case "tag":
+			tagged := d.Tagged(value)
+			match = append(match, func(n uint16) bool { return tagged[n] })
+		}
+	}
+	var v []MemoryEntry
+	for _, m := range d.Memory {
+		for _, ok := range match {
+			if ok(m.Number) {
+				v = append(v, m)
+				break
+			}
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,69 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+const (
+	TNFormat        = "TN %1d,%1d"
+	TNCommandFormat = "TN\r"
+)
+
+const (
+	TNCOff = iota
+	TNCAPRS
+	TNCPacket
+)
+
+var tncModeNames = []string{"off", "APRS", "packet"}
+
+func TNCModeName(mode int) string {
+	if mode >= 0 && mode < len(tncModeNames) {
+		return tncModeNames[mode]
+	}
+	return fmt.Sprintf("mode %d", mode)
+}
+
+func ParseTNCMode(s string) (int, error) {
+	for i, n := range tncModeNames {
+		if strings.EqualFold(s, n) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("error parsing TNC mode %q: expected one of %s", s, strings.Join(tncModeNames, ", "))
+}
+
+func (r *Radio) checkTNC() error {
+	if !r.Capabilities().HasTNC {
+		return fmt.Errorf("error: %s has no built-in TNC", r.Model)
+	}
+	return nil
+}
+
+// GetTNC returns the mode of the built-in TNC and the band it works on.
+func (r *Radio) GetTNC() (mode, band int, err error) {
+	if err := r.checkTNC(); err != nil {
+		return 0, 0, err
+	}
+	line, err := r.WriteReadString(TNCommandFormat)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading TNC mode: %w", err)
+	}
+	if _, err := fmt.Sscanf(line, TNFormat, &mode, &band); err != nil {
+		return 0, 0, parseError("TNC line", line, "")
+	}
+	return mode, band, nil
+}
+
+func (r *Radio) SetTNC(mode, band int) error {
+	if err := r.checkTNC(); err != nil {
+		return err
+	}
+	if _, err := r.WriteReadString(fmt.Sprintf(TNFormat, mode, band) + "\r"); err != nil {
+		return fmt.Errorf("error switching TNC to %s on band %s: %w", TNCModeName(mode), units.BandName(band), err)
+	}
+	return nil
+}
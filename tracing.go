@@ -0,0 +1,270 @@
+package kenwoodutil
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TracingConfig points at an OpenTelemetry collector. When Endpoint is
+// empty, OTEL_EXPORTER_OTLP_ENDPOINT is used.
+type TracingConfig struct {
+	Endpoint string `json:",omitempty"`
+	Service  string `json:",omitempty"`
+}
+
+const (
+	otlpTracesPath   = "/v1/traces"
+	otlpEndpointEnv  = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	defaultService   = "kenwoodutil"
+	otlpStatusError  = 2
+	otlpKindInternal = 1
+	otlpKindClient   = 3
+
+	// finished spans are exported every traceFlushPeriod or once
+	// traceMaxBatch of them are waiting, and dropped beyond traceMaxPending
+	// when the collector does not keep up
+	traceFlushPeriod = 10 * time.Second
+	traceMaxBatch    = 256
+	traceMaxPending  = 4 * traceMaxBatch
+)
+
+// Tracer records spans of radio operations and exports them to an OTLP/HTTP
+// collector in its JSON encoding, in the background as they finish. A nil
+// Tracer records nothing.
+type Tracer struct {
+	Endpoint string
+	Service  string
+
+	mu      sync.Mutex
+	traceID [16]byte
+	pending []*Span
+	flush   chan struct{}
+}
+
+type Span struct {
+	tracer   *Tracer
+	id       [8]byte
+	parent   [8]byte
+	up       *Span
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    map[string]string
+	errorMsg string
+}
+
+// NewTracer returns a tracer for c, or nil when no endpoint is configured.
+func NewTracer(c TracingConfig) *Tracer {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv(otlpEndpointEnv)
+	}
+	if endpoint == "" {
+		return nil
+	}
+	t := &Tracer{
+		Endpoint: strings.TrimSuffix(endpoint, "/") + otlpTracesPath,
+		Service:  c.Service,
+		flush:    make(chan struct{}, 1),
+	}
+	if t.Service == "" {
+		t.Service = defaultService
+	}
+	rand.Read(t.traceID[:])
+	go t.run()
+	return t
+}
+
+// run exports the finished spans periodically, or sooner when a batch is
+// full. It lasts as long as the program.
+func (t *Tracer) run() {
+	tick := time.NewTicker(traceFlushPeriod)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+		case <-t.flush:
+		}
+		if err := t.Flush(); err != nil {
+			log.Warn().Err(err).Msg("traces were not exported")
+		}
+	}
+}
+
+// Start opens a span as a child of parent, or as a root span when parent is
+// nil. attrs are key, value pairs.
+func (t *Tracer) Start(parent *Span, name string, attrs ...string) *Span {
+	return t.start(parent, name, otlpKindInternal, attrs)
+}
+
+func (t *Tracer) start(parent *Span, name string, kind int, attrs []string) *Span {
+	if t == nil {
+		return nil
+	}
+	s := &Span{tracer: t, name: name, kind: kind, start: time.Now(), attrs: map[string]string{}}
+	rand.Read(s.id[:])
+	if parent != nil {
+		s.parent = parent.id
+	}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		s.attrs[attrs[i]] = attrs[i+1]
+	}
+	return s
+}
+
+// startSpan opens a span of an operation of r under the operation it runs
+// in, if any. Until endSpan closes it, it is the parent of the commands r
+// sends and of the operations started on r.
+func (r *Radio) startSpan(name string, attrs ...string) *Span {
+	if r.Tracer == nil {
+		return nil
+	}
+	r.spanMu.Lock()
+	defer r.spanMu.Unlock()
+	s := r.Tracer.Start(r.span, name, attrs...)
+	s.up = r.span
+	r.span = s
+	return s
+}
+
+// endSpan closes s, making its parent the current operation of r again.
+func (r *Radio) endSpan(s *Span, err error) {
+	if s == nil {
+		return
+	}
+	r.spanMu.Lock()
+	if r.span == s {
+		r.span = s.up
+	}
+	r.spanMu.Unlock()
+	s.End(err)
+}
+
+// operation returns the span of the operation r runs, nil when there is
+// none.
+func (r *Radio) operation() *Span {
+	r.spanMu.Lock()
+	defer r.spanMu.Unlock()
+	return r.span
+}
+
+func (s *Span) SetAttr(key, value string) {
+	if s == nil {
+		return
+	}
+	s.attrs[key] = value
+}
+
+// End closes s, marking it failed when err is not nil.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	if err != nil {
+		s.errorMsg = err.Error()
+	}
+	t := s.tracer
+	t.mu.Lock()
+	if len(t.pending) >= traceMaxPending {
+		t.pending = t.pending[1:]
+	}
+	t.pending = append(t.pending, s)
+	full := len(t.pending) >= traceMaxBatch
+	t.mu.Unlock()
+	if full {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type otlpAttr struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+func otlpAttrs(m map[string]string) []otlpAttr {
+	v := []otlpAttr{}
+	for k, val := range m {
+		a := otlpAttr{Key: k}
+		a.Value.StringValue = val
+		v = append(v, a)
+	}
+	return v
+}
+
+// Flush sends the finished spans to the collector. They are dropped when
+// that fails.
+func (t *Tracer) Flush() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	done := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+	if len(done) == 0 {
+		return nil
+	}
+
+	spans := make([]map[string]interface{}, 0, len(done))
+	traceID := hex.EncodeToString(t.traceID[:])
+	for _, s := range done {
+		span := map[string]interface{}{
+			"traceId":           traceID,
+			"spanId":            hex.EncodeToString(s.id[:]),
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        otlpAttrs(s.attrs),
+		}
+		if s.parent != [8]byte{} {
+			span["parentSpanId"] = hex.EncodeToString(s.parent[:])
+		}
+		if s.errorMsg != "" {
+			span["status"] = map[string]interface{}{"code": otlpStatusError, "message": s.errorMsg}
+		}
+		spans = append(spans, span)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": otlpAttrs(map[string]string{"service.name": t.Service}),
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": defaultService},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding traces: %w", err)
+	}
+	client := &http.Client{Timeout: remoteLogTimeout}
+	resp, err := client.Post(t.Endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error exporting traces: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("error exporting traces: %s", resp.Status)
+	}
+	return nil
+}
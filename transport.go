@@ -0,0 +1,92 @@
+package kenwoodutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const tcpScheme = "tcp://"
+
+// Port is the part of go.bug.st/serial's Port the radio actually needs, so
+// that transports other than a local serial port can be plugged in.
+type Port interface {
+	io.ReadWriteCloser
+	ResetInputBuffer() error
+	SetReadTimeout(t time.Duration) error
+}
+
+// OpenPort opens a local serial port, or a raw serial-over-TCP connection
+// (ser2net and alike) when path looks like tcp://host:port. Baud rate and
+// mode of a TCP connection are set on the remote end. A path like
+// replay://session.jsonl plays back a recorded session instead.
+func OpenPort(path string, baudrate int, mode SerialMode) (Port, error) {
+	if strings.HasPrefix(path, replayScheme) {
+		return openReplay(strings.TrimPrefix(path, replayScheme))
+	}
+	if strings.HasPrefix(path, tcpScheme) {
+		conn, err := net.DialTimeout("tcp", strings.TrimPrefix(path, tcpScheme), 10*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to %s: %w", path, err)
+		}
+		return &tcpPort{Conn: conn}, nil
+	}
+	return mode.open(path, baudrate)
+}
+
+// ReadTimeout is how long the radio gets to answer before ErrTimeout.
+const ReadTimeout = 3 * time.Second
+
+// timeoutReader turns the 0, nil a port returns on read timeout into
+// ErrTimeout, instead of letting bufio spin on it.
+type timeoutReader struct {
+	Port
+}
+
+func (t timeoutReader) Read(b []byte) (int, error) {
+	n, err := t.Port.Read(b)
+	if n == 0 && err == nil && len(b) > 0 {
+		return 0, ErrTimeout
+	}
+	return n, err
+}
+
+type tcpPort struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (p *tcpPort) Read(b []byte) (int, error) {
+	if p.timeout > 0 {
+		p.Conn.SetReadDeadline(time.Now().Add(p.timeout))
+	} else {
+		p.Conn.SetReadDeadline(time.Time{})
+	}
+	n, err := p.Conn.Read(b)
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		// serial ports return 0, nil on read timeout
+		return n, nil
+	}
+	return n, err
+}
+
+func (p *tcpPort) ResetInputBuffer() error {
+	buf := make([]byte, 256)
+	for {
+		p.Conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+		n, err := p.Conn.Read(buf)
+		if n == 0 || err != nil {
+			break
+		}
+	}
+	return p.Conn.SetReadDeadline(time.Time{})
+}
+
+func (p *tcpPort) SetReadTimeout(t time.Duration) error {
+	p.timeout = t
+	return nil
+}
@@ -0,0 +1,76 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TrashedChannel is a channel removed from a dump by a command, kept so
+// that a deletion by mistake does not silently spread to the radio.
+type TrashedChannel struct {
+	Removed time.Time
+	Reason  string `json:",omitempty"`
+	Channel MemoryEntry
+}
+
+// ReplaceMemory sets the channels of the dump, moving the ones no longer
+// there to the trash. It returns how many were trashed.
+func (d *Dump) ReplaceMemory(memory []MemoryEntry, reason string) (trashed int) {
+	kept := map[uint16]bool{}
+	for _, m := range memory {
+		if m.RXFrequency != 0 {
+			kept[m.Number] = true
+		}
+	}
+	now := time.Now()
+	for _, m := range d.Memory {
+		if m.RXFrequency != 0 && !kept[m.Number] {
+			d.Trash = append(d.Trash, TrashedChannel{Removed: now, Reason: reason, Channel: m})
+			trashed++
+		}
+	}
+	d.Memory = memory
+	return trashed
+}
+
+// Restore brings channels back from the trash to their old numbers, all of
+// them when channels is empty. When a channel was trashed more than once the
+// latest copy is restored. Channels whose number is taken again are refused.
+func (d *Dump) Restore(channels []int) (restored []uint16, err error) {
+	want := map[uint16]bool{}
+	for _, n := range channels {
+		want[uint16(n)] = true
+	}
+	used := map[uint16]bool{}
+	for _, m := range d.Memory {
+		if m.RXFrequency != 0 {
+			used[m.Number] = true
+		}
+	}
+	latest := map[uint16]int{}
+	for i, t := range d.Trash {
+		if len(want) == 0 || want[t.Channel.Number] {
+			latest[t.Channel.Number] = i
+		}
+	}
+	for n := range latest {
+		if used[n] {
+			return nil, fmt.Errorf("error: channel %03d is in use, move it away before restoring", n)
+		}
+	}
+
+	var trash []TrashedChannel
+	for i, t := range d.Trash {
+		if j, ok := latest[t.Channel.Number]; ok && i == j {
+			d.Memory = append(d.Memory, t.Channel)
+			restored = append(restored, t.Channel.Number)
+			continue
+		}
+		trash = append(trash, t)
+	}
+	d.Trash = trash
+	sort.Slice(d.Memory, func(i, j int) bool { return d.Memory[i].Number < d.Memory[j].Number })
+	sort.Slice(restored, func(i, j int) bool { return restored[i] < restored[j] })
+	return restored, nil
+}
@@ -0,0 +1,138 @@
+package kenwoodutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// TuneRecord is what a band was tuned to at some moment, as kept in the log
+// of the watch command.
+type TuneRecord struct {
+	Time       time.Time
+	Band       string
+	Mode       string
+	Channel    string `json:",omitempty"`
+	Name       string `json:",omitempty"`
+	Frequency  string
+	Modulation string
+}
+
+var TuneRecordHeader = []string{"time", "band", "mode", "channel", "name", "frequency", "modulation"}
+
+func NewTuneRecord(t time.Time, s BandStatus) TuneRecord {
+	rec := TuneRecord{
+		Time:       t,
+		Band:       units.BandName(s.Band),
+		Mode:       VFOModeName(s.Mode),
+		Frequency:  units.FormatMHz(s.Frequency),
+		Modulation: units.ModeName(s.Modulation),
+	}
+	if s.Mode == MemoryMode {
+		rec.Channel = fmt.Sprintf("%03d", s.Channel)
+		rec.Name = s.Name
+	}
+	return rec
+}
+
+// SameTuning reports whether rec and other differ only in time.
+func (rec TuneRecord) SameTuning(other TuneRecord) bool {
+	rec.Time = other.Time
+	return rec == other
+}
+
+func (rec TuneRecord) CSV() []string {
+	return []string{rec.Time.Format(time.RFC3339), rec.Band, rec.Mode, rec.Channel, rec.Name, rec.Frequency, rec.Modulation}
+}
+
+// OperatingLog appends to a JSON lines file what the bands are tuned to
+// whenever it changes, as evidence of activity.
+type OperatingLog struct {
+	Path string
+	last map[int]TuneRecord
+}
+
+// Poll reads what bands are tuned to and logs the ones that changed since
+// the last poll.
+func (l *OperatingLog) Poll(r *Radio, bands []int) error {
+	if l.last == nil {
+		l.last = map[int]TuneRecord{}
+	}
+	now := time.Now()
+	var changed []TuneRecord
+	for _, band := range bands {
+		s, err := r.BandStatus(band)
+		if err != nil {
+			return err
+		}
+		rec := NewTuneRecord(now, s)
+		if prev, ok := l.last[band]; ok && prev.SameTuning(rec) {
+			continue
+		}
+		l.last[band] = rec
+		changed = append(changed, rec)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening operating log: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, rec := range changed {
+		if err := enc.Encode(rec); err != nil {
+			f.Close()
+			return fmt.Errorf("error writing operating log: %w", err)
+		}
+	}
+	return f.Close()
+}
+
+// ReadTuneLog reads a JSON lines log of the watch command or daemon.
+func ReadTuneLog(r io.Reader) (v []TuneRecord, err error) {
+	dec := json.NewDecoder(r)
+	for {
+		var rec TuneRecord
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return v, nil
+		}
+		if err != nil {
+			return v, fmt.Errorf("error reading tune log record %d: %w", len(v)+1, err)
+		}
+		v = append(v, rec)
+	}
+}
+
+// TuneRecordsBetween returns the records of log from from to to, a zero to
+// meaning no end. The last record of each band before from comes first, as
+// that is what the band was on when the range starts.
+func TuneRecordsBetween(log []TuneRecord, from, to time.Time) (v []TuneRecord) {
+	before := map[string]TuneRecord{}
+	var bands []string
+	for _, rec := range log {
+		if !rec.Time.Before(from) {
+			continue
+		}
+		if _, ok := before[rec.Band]; !ok {
+			bands = append(bands, rec.Band)
+		}
+		before[rec.Band] = rec
+	}
+	for _, b := range bands {
+		v = append(v, before[b])
+	}
+	for _, rec := range log {
+		if rec.Time.Before(from) || (!to.IsZero() && rec.Time.After(to)) {
+			continue
+		}
+		v = append(v, rec)
+	}
+	return v
+}
@@ -0,0 +1,190 @@
+package kenwoodutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var calibrationPacings = []time.Duration{0, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond}
+
+var calibrationDepths = []int{2, 4, 8}
+
+type Tuning struct {
+	Pacing        time.Duration
+	PipelineDepth int
+	Latency       time.Duration
+	ErrorRate     float64
+	Calibrated    time.Time
+}
+
+// measure sends samples ID commands one by one and returns the average reply
+// latency and the ratio of failed exchanges.
+func (r *Radio) measure(samples int) (latency time.Duration, errorRate float64) {
+	var total time.Duration
+	var ok, failed int
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		line, err := r.WriteReadString(IDCommandFormat)
+		if err == nil && line == fmt.Sprintf(IDFormat, r.Model)+"\r" {
+			total += time.Since(start)
+			ok++
+			continue
+		}
+		failed++
+		if err := r.Resync(); err != nil {
+			log.Debug().Err(err).Msg("calibration")
+		}
+	}
+	if ok > 0 {
+		latency = total / time.Duration(ok)
+	}
+	return latency, float64(failed) / float64(samples)
+}
+
+// pipelines checks whether the radio answers depth commands sent back to back
+// without waiting for replies.
+func (r *Radio) pipelines(depth int) bool {
+	r.mu.Lock()
+	for i := 0; i < depth; i++ {
+		if err := r.WriteString(IDCommandFormat); err != nil {
+			r.mu.Unlock()
+			return false
+		}
+	}
+	healthy := true
+	for i := 0; i < depth; i++ {
+		if r.Echo {
+			if _, err := r.ReadString(); err != nil {
+				healthy = false
+				break
+			}
+		}
+		line, err := r.ReadString()
+		if err != nil || line != fmt.Sprintf(IDFormat, r.Model)+"\r" {
+			healthy = false
+			break
+		}
+	}
+	r.mu.Unlock()
+	if !healthy {
+		if err := r.Resync(); err != nil {
+			log.Debug().Err(err).Msg("calibration")
+		}
+	}
+	return healthy
+}
+
+func (r *Radio) Calibrate(samples int) (Tuning, error) {
+	if samples < 1 {
+		return Tuning{}, errors.New("error calibrating: need at least one sample")
+	}
+	saved := r.Tuning
+	defer func() { r.Tuning = saved }()
+
+	t := Tuning{PipelineDepth: 1, Calibrated: time.Now(), ErrorRate: 1}
+	for _, pacing := range calibrationPacings {
+		r.Tuning = Tuning{Pacing: pacing}
+		latency, errorRate := r.measure(samples)
+		log.Info().Dur("pacing", pacing).Dur("latency", latency).Float64("error rate", errorRate).Msg("calibration")
+		if errorRate < t.ErrorRate {
+			t.Pacing, t.Latency, t.ErrorRate = pacing, latency, errorRate
+		}
+		if errorRate == 0 {
+			break
+		}
+	}
+	if t.ErrorRate == 1 {
+		return Tuning{}, errors.New("error calibrating: radio did not answer at any pacing")
+	}
+
+	r.Tuning = Tuning{Pacing: t.Pacing}
+	for _, depth := range calibrationDepths {
+		if !r.pipelines(depth) {
+			break
+		}
+		t.PipelineDepth = depth
+	}
+	return t, nil
+}
+
+// RadioCache is what is learnt about a radio on a port, kept across runs
+// in the user cache directory.
+type RadioCache struct {
+	// Tuning is what Calibrate found
+	Tuning Tuning
+	// Support is what ProbeSupport found, for models not in models.json
+	Support Support `json:",omitempty"`
+}
+
+func radioCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating cache directory: %w", err)
+	}
+	return filepath.Join(dir, "kenwoodutil", "radios.json"), nil
+}
+
+func radioCacheKey(r *Radio) string {
+	return fmt.Sprintf("%s@%s:%d", r.Model, r.PortPath, r.BaudRate)
+}
+
+func loadRadioCaches() (map[string]RadioCache, error) {
+	caches := map[string]RadioCache{}
+	path, err := radioCachePath()
+	if err != nil {
+		return nil, err
+	}
+	j, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return caches, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading radio cache: %w", err)
+	}
+	if err := json.Unmarshal(j, &caches); err != nil {
+		return nil, fmt.Errorf("error parsing radio cache: %w", err)
+	}
+	return caches, nil
+}
+
+// LoadRadioCache returns the cache of r, by model, port and baud rate, and
+// whether there is one.
+func LoadRadioCache(r *Radio) (RadioCache, bool) {
+	caches, err := loadRadioCaches()
+	if err != nil {
+		log.Warn().Err(err).Msg("ignoring radio cache")
+		return RadioCache{}, false
+	}
+	c, ok := caches[radioCacheKey(r)]
+	return c, ok
+}
+
+// SaveRadioCache stores c as the cache of r.
+func SaveRadioCache(r *Radio, c RadioCache) error {
+	caches, err := loadRadioCaches()
+	if err != nil {
+		return err
+	}
+	caches[radioCacheKey(r)] = c
+	path, err := radioCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating cache directory: %w", err)
+	}
+	j, err := json.MarshalIndent(caches, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling radio cache: %w", err)
+	}
+	if err := WriteFileAtomic(path, j, 0644, false); err != nil {
+		return fmt.Errorf("error writing radio cache: %w", err)
+	}
+	return nil
+}
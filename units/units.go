@@ -0,0 +1,267 @@
+// Package units converts and validates channel fields the way Kenwood
+// radios store them: frequencies, steps, tones, DCS codes, modes and names.
+// It has no serial dependencies, so that frontends can check forms exactly
+// like the writer does.
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxNameLength is the channel name length of models not saying otherwise.
+const MaxNameLength = 8
+
+// ModeNames lists modulations by ME/FO index.
+var ModeNames = []string{"FM", "AM", "NFM"}
+
+// ME/FO modulation indexes.
+const (
+	ModeFM = iota
+	ModeAM
+	ModeNFM
+)
+
+// StepSizes maps ME/FO step indexes to step sizes in Hz. The 8.33 kHz
+// airband step is not an integer and is stored rounded.
+var StepSizes = []uint32{5000, 6250, 8330, 10000, 12500, 15000, 20000, 25000, 30000, 50000, 100000}
+
+const AirbandStepIndex = 2
+
+// CTCSSTones lists CTCSS and tone burst frequencies in Hz by ME/FO index.
+var CTCSSTones = []float64{
+	67.0, 69.3, 71.9, 74.4, 77.0, 79.7, 82.5, 85.4, 88.5, 91.5,
+	94.8, 97.4, 100.0, 103.5, 107.2, 110.9, 114.8, 118.8, 123.0, 127.3,
+	131.8, 136.5, 141.3, 146.2, 151.4, 156.7, 162.2, 167.9, 173.8, 179.9,
+	186.2, 192.8, 203.5, 206.5, 210.7, 218.1, 225.7, 229.1, 233.6, 241.8,
+	250.3, 254.1,
+}
+
+// DCSCodes lists DCS codes by ME/FO index.
+var DCSCodes = []uint16{
+	23, 25, 26, 31, 32, 36, 43, 47, 51, 53, 54, 65, 71, 72, 73, 74,
+	114, 115, 116, 122, 125, 131, 132, 134, 143, 145, 152, 155, 156, 162, 165, 172, 174,
+	205, 212, 223, 225, 226, 243, 244, 245, 246, 251, 252, 255, 261, 263, 265, 266, 271, 274,
+	306, 311, 315, 325, 331, 332, 343, 346, 351, 356, 364, 365, 371,
+	411, 412, 413, 423, 431, 432, 445, 446, 452, 454, 455, 462, 464, 465, 466,
+	503, 506, 516, 523, 526, 532, 546, 565,
+	606, 612, 624, 627, 631, 632, 654, 662, 664,
+	703, 712, 723, 731, 732, 734, 743, 754,
+}
+
+func StepHz(index uint8) (uint32, bool) {
+	if int(index) >= len(StepSizes) {
+		return 0, false
+	}
+	return StepSizes[index], true
+}
+
+// StepIndex returns the index of a step of khz kHz, like 12.5.
+func StepIndex(khz float64) (uint8, error) {
+	for i, hz := range StepSizes {
+		if math.Abs(float64(hz)-khz*1e3) < 1 {
+			return uint8(i), nil
+		}
+	}
+	return 0, fmt.Errorf("error: %g kHz is not a step the radio knows", khz)
+}
+
+// FitsStep reports whether frequency hz lies on the grid of step index.
+func FitsStep(hz uint32, index uint8) bool {
+	if index == AirbandStepIndex {
+		return true
+	}
+	step, ok := StepHz(index)
+	return ok && hz%step == 0
+}
+
+// CanonicalStep returns index if hz lies on its grid, or otherwise the
+// coarsest step that hz fits on.
+func CanonicalStep(hz uint32, index uint8) uint8 {
+	if hz == 0 || FitsStep(hz, index) {
+		return index
+	}
+	for i := len(StepSizes) - 1; i >= 0; i-- {
+		if i != AirbandStepIndex && FitsStep(hz, uint8(i)) {
+			return uint8(i)
+		}
+	}
+	return index
+}
+
+func ParseMHz(s string) (uint32, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || !(f >= 0 && f <= math.MaxUint32/1e6) {
+		return 0, fmt.Errorf("error parsing frequency %q: expected MHz, like 145.500", s)
+	}
+	return uint32(math.Round(f * 1e6)), nil
+}
+
+func FormatMHz(hz uint32) string {
+	return strconv.FormatFloat(float64(hz)/1e6, 'f', 4, 64)
+}
+
+func ParseMode(s string) (uint8, error) {
+	for i, n := range ModeNames {
+		if strings.EqualFold(n, s) {
+			return uint8(i), nil
+		}
+	}
+	return 0, fmt.Errorf("error parsing mode %q: expected one of %s", s, strings.Join(ModeNames, ", "))
+}
+
+func ModeName(mode uint8) string {
+	if int(mode) < len(ModeNames) {
+		return ModeNames[mode]
+	}
+	return strconv.Itoa(int(mode))
+}
+
+func ParseBand(s string) (int, error) {
+	switch strings.ToUpper(s) {
+	case "A", "0":
+		return 0, nil
+	case "B", "1":
+		return 1, nil
+	}
+	return 0, fmt.Errorf("error parsing band %q: expected A or B", s)
+}
+
+func BandName(band int) string {
+	return string(rune('A' + band))
+}
+
+func ToneIndex(hz float64) (uint8, error) {
+	for i, t := range CTCSSTones {
+		if math.Abs(t-hz) < 0.05 {
+			return uint8(i), nil
+		}
+	}
+	return 0, fmt.Errorf("error: %.1f Hz is not a standard CTCSS tone", hz)
+}
+
+// DCSIndex returns the ME/FO index of DCS code, like 23 for D023.
+func DCSIndex(code uint16) (uint8, error) {
+	for i, c := range DCSCodes {
+		if c == code {
+			return uint8(i), nil
+		}
+	}
+	return 0, fmt.Errorf("error: %03d is not a standard DCS code", code)
+}
+
+func ToneHz(index uint16) float64 {
+	if int(index) < len(CTCSSTones) {
+		return CTCSSTones[index]
+	}
+	return 0
+}
+
+// DCSCode returns the DCS code of an ME/FO index.
+func DCSCode(index uint16) (uint16, bool) {
+	if int(index) < len(DCSCodes) {
+		return DCSCodes[index], true
+	}
+	return 0, false
+}
+
+// NameCharset is what the display of models not saying otherwise renders in
+// channel names. The comma is left out, it separates MN command fields.
+const NameCharset = " !\"#$%&'()*+-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+
+// transliterations spell letters outside ASCII with ASCII ones.
+var transliterations = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Ą': "A", 'Æ': "AE",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ą': "a", 'æ': "ae",
+	'Ç': "C", 'Ć': "C", 'Č': "C", 'ç': "c", 'ć': "c", 'č': "c",
+	'Ď': "D", 'Đ': "D", 'ď': "d", 'đ': "d",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ę': "E", 'Ě': "E",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ę': "e", 'ě': "e",
+	'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'Ł': "L", 'ł': "l",
+	'Ñ': "N", 'Ń': "N", 'Ň': "N", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ő': "O",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ő': "o",
+	'Ř': "R", 'ř': "r",
+	'Ś': "S", 'Š': "S", 'ś': "s", 'š': "s", 'ß': "ss",
+	'Ť': "T", 'ť': "t",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ů': "U", 'Ű': "U",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ů': "u", 'ű': "u",
+	'Ý': "Y", 'ý': "y", 'ÿ': "y",
+	'Ź': "Z", 'Ż': "Z", 'Ž': "Z", 'ź': "z", 'ż': "z", 'ž': "z",
+	',': ".", '\t': " ",
+}
+
+// FitName makes name storable in a channel holding max characters of
+// charset: letters outside it are transliterated when they can be, and
+// replaced by an underscore, or left out if that is not in charset either,
+// when they cannot. The result is cut to max characters.
+func FitName(name string, max int, charset string) string {
+	var b strings.Builder
+	n := 0
+	put := func(c rune) {
+		if n < max {
+			b.WriteRune(c)
+			n++
+		}
+	}
+	for _, c := range name {
+		if strings.ContainsRune(charset, c) {
+			put(c)
+			continue
+		}
+		if t, ok := transliterations[c]; ok && strings.Trim(t, charset) == "" {
+			for _, tc := range t {
+				put(tc)
+			}
+			continue
+		}
+		if strings.ContainsRune(charset, '_') {
+			put('_')
+		}
+	}
+	return b.String()
+}
+
+// CheckName reports why name cannot be stored in a channel holding max
+// characters.
+func CheckName(name string, max int) error {
+	if len(name) > max {
+		return fmt.Errorf("name %q is longer than %d characters", name, max)
+	}
+	if strings.ContainsAny(name, ",\r") {
+		return fmt.Errorf("name %q contains a comma or line break", name)
+	}
+	return nil
+}
+
+// ParseChannelList parses channel lists like "10-20,30" into channel numbers.
+func ParseChannelList(s string) ([]int, error) {
+	var v []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		lo, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing channel list %q", s)
+		}
+		hi := lo
+		if len(bounds) == 2 {
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("error parsing channel list %q", s)
+			}
+		}
+		if lo < 0 || hi < lo {
+			return nil, fmt.Errorf("error parsing channel list %q: bad range %q", s, part)
+		}
+		for ch := lo; ch <= hi; ch++ {
+			v = append(v, ch)
+		}
+	}
+	return v, nil
+}
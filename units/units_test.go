@@ -0,0 +1,204 @@
+package units
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDCSIndex(t *testing.T) {
+	for _, tc := range []struct {
+		code uint16
+		want uint8
+	}{
+		{23, 0},
+		{25, 1},
+		{754, uint8(len(DCSCodes) - 1)},
+	} {
+		got, err := DCSIndex(tc.code)
+		if err != nil {
+			t.Errorf("DCSIndex(%d): %v", tc.code, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("DCSIndex(%d) = %d, want %d", tc.code, got, tc.want)
+		}
+		if back, _ := DCSCode(uint16(got)); back != tc.code {
+			t.Errorf("DCSCode(%d) = %d, want %d", got, back, tc.code)
+		}
+	}
+	for _, code := range []uint16{0, 22, 24, 755, 999} {
+		if got, err := DCSIndex(code); err == nil {
+			t.Errorf("DCSIndex(%d) succeeded, got %d", code, got)
+		}
+	}
+}
+
+func TestParseMHz(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want uint32
+	}{
+		{"145.5", 145500000},
+		{" 145.500 ", 145500000},
+		{"446.00625", 446006250},
+		{"118.00833", 118008330},
+		{"0", 0},
+		{"4294.967295", 4294967295},
+	} {
+		got, err := ParseMHz(tc.in)
+		if err != nil {
+			t.Errorf("ParseMHz(%q): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseMHz(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+	for _, in := range []string{"", "MHz", "145,5", "145.5 MHz", "-145.5", "4294.9673", "1e10", "NaN", "Inf"} {
+		if got, err := ParseMHz(in); err == nil {
+			t.Errorf("ParseMHz(%q) succeeded, got %d", in, got)
+		}
+	}
+}
+
+func TestParseModeAndBand(t *testing.T) {
+	for in, want := range map[string]uint8{"FM": ModeFM, "am": ModeAM, "Nfm": ModeNFM} {
+		if got, err := ParseMode(in); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "USB", "F M", "3"} {
+		if got, err := ParseMode(in); err == nil {
+			t.Errorf("ParseMode(%q) succeeded, got %d", in, got)
+		}
+	}
+	for in, want := range map[string]int{"A": 0, "a": 0, "0": 0, "B": 1, "b": 1, "1": 1} {
+		if got, err := ParseBand(in); err != nil || got != want {
+			t.Errorf("ParseBand(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "C", "2", "AB", "-1"} {
+		if got, err := ParseBand(in); err == nil {
+			t.Errorf("ParseBand(%q) succeeded, got %d", in, got)
+		}
+	}
+}
+
+func TestToneAndStepIndex(t *testing.T) {
+	for _, tc := range []struct {
+		hz   float64
+		want uint8
+	}{
+		{67.0, 0},
+		{88.5, 8},
+		{88.46, 8},
+		{254.1, uint8(len(CTCSSTones) - 1)},
+	} {
+		if got, err := ToneIndex(tc.hz); err != nil || got != tc.want {
+			t.Errorf("ToneIndex(%g) = %d, %v, want %d", tc.hz, got, err, tc.want)
+		}
+	}
+	for _, hz := range []float64{0, 66.9, 88.44, 88.56, 1750, -88.5} {
+		if got, err := ToneIndex(hz); err == nil {
+			t.Errorf("ToneIndex(%g) succeeded, got %d", hz, got)
+		}
+	}
+	for _, tc := range []struct {
+		khz  float64
+		want uint8
+	}{
+		{5, 0},
+		{6.25, 1},
+		{8.33, AirbandStepIndex},
+		{12.5, 4},
+		{100, uint8(len(StepSizes) - 1)},
+	} {
+		if got, err := StepIndex(tc.khz); err != nil || got != tc.want {
+			t.Errorf("StepIndex(%g) = %d, %v, want %d", tc.khz, got, err, tc.want)
+		}
+	}
+	for _, khz := range []float64{0, -5, 7, 12.4, 8.3, 200} {
+		if got, err := StepIndex(khz); err == nil {
+			t.Errorf("StepIndex(%g) succeeded, got %d", khz, got)
+		}
+	}
+}
+
+func TestCanonicalStep(t *testing.T) {
+	for _, tc := range []struct {
+		hz          uint32
+		index, want uint8
+	}{
+		{0, 3, 3},
+		{145500000, 0, 0},
+		{145500000, 4, 4},
+		{145506250, 0, 1},
+		{446006250, 4, 1},
+		{118008330, AirbandStepIndex, AirbandStepIndex},
+		{145000000, 10, 10},
+		{145000001, 4, 4},
+	} {
+		if got := CanonicalStep(tc.hz, tc.index); got != tc.want {
+			t.Errorf("CanonicalStep(%d, %d) = %d, want %d", tc.hz, tc.index, got, tc.want)
+		}
+	}
+}
+
+func TestParseChannelList(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []int
+	}{
+		{"", nil},
+		{"0", []int{0}},
+		{"10-12,30", []int{10, 11, 12, 30}},
+		{" 5 , ,7", []int{5, 7}},
+		{"999-999", []int{999}},
+	} {
+		got, err := ParseChannelList(tc.in)
+		if err != nil {
+			t.Errorf("ParseChannelList(%q): %v", tc.in, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("ParseChannelList(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+	for _, in := range []string{"a", "5-", "-5", "12-10", "1-2-3", "1;2", "5 - 7"} {
+		if got, err := ParseChannelList(in); err == nil {
+			t.Errorf("ParseChannelList(%q) succeeded, got %v", in, got)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		max     int
+		charset string
+		want    string
+	}{
+		{"SR9A", 8, NameCharset, "SR9A"},
+		{"ABCDEFGHIJ", 8, NameCharset, "ABCDEFGH"},
+		{"Łódź", 8, NameCharset, "Lodz"},
+		{"A,B", 8, NameCharset, "A.B"},
+		{"ab€", 8, NameCharset, "ab_"},
+		{"ab€", 8, "ab", "ab"},
+		{"ÆÆÆÆÆ", 8, NameCharset, "AEAEAEAE"},
+		{"", 8, NameCharset, ""},
+		{"SR9A", 0, NameCharset, ""},
+	} {
+		got := FitName(tc.name, tc.max, tc.charset)
+		if got != tc.want {
+			t.Errorf("FitName(%q, %d) = %q, want %q", tc.name, tc.max, got, tc.want)
+		}
+		if err := CheckName(got, tc.max); err != nil {
+			t.Errorf("CheckName(FitName(%q, %d)): %v", tc.name, tc.max, err)
+		}
+	}
+	for _, name := range []string{"ABCDEFGHI", "A,B", "A\rB"} {
+		if err := CheckName(name, 8); err == nil {
+			t.Errorf("CheckName(%q, 8) succeeded", name)
+		}
+	}
+}
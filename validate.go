@@ -0,0 +1,197 @@
+package kenwoodutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+type ValidationError struct {
+	Channel  uint16
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("channel %03d: %s", e.Channel, strings.Join(e.Problems, "; "))
+}
+
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, v := range e {
+		lines[i] = v.Error()
+	}
+	return fmt.Sprintf("%d invalid channels:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+func (m *MemoryEntry) Validate() error {
+	return m.ValidateFor(Capabilities{})
+}
+
+// ValidateFor checks that m can be written to a radio with capabilities c
+// and reports every problem found.
+func (m *MemoryEntry) ValidateFor(c Capabilities) error {
+	var p []string
+	flag := func(name string, v uint8) {
+		if v > 1 {
+			p = append(p, fmt.Sprintf("%s flag must be 0 or 1, not %d", name, v))
+		}
+	}
+
+	if m.RXFrequency == 0 {
+		p = append(p, "no RX frequency")
+	} else if !c.CanReceive(m.RXFrequency) {
+		p = append(p, fmt.Sprintf("RX frequency %s MHz is out of %s range", units.FormatMHz(m.RXFrequency), c.Model))
+	}
+	if _, ok := units.StepHz(m.RXStepSize); !ok {
+		p = append(p, fmt.Sprintf("invalid RX step index %d", m.RXStepSize))
+	} else if !units.FitsStep(m.RXFrequency, m.RXStepSize) {
+		p = append(p, fmt.Sprintf("RX frequency %s MHz is not on the %d Hz step", units.FormatMHz(m.RXFrequency), units.StepSizes[m.RXStepSize]))
+	}
+	if tx := m.ShiftedTX(); tx != 0 && !c.CanTransmit(tx) {
+		p = append(p, fmt.Sprintf("TX frequency %s MHz is out of the %s bands %s", units.FormatMHz(tx), c.Model, c.TXBandNames()))
+	}
+	switch {
+	case m.Split && m.TXFrequency == 0:
+		p = append(p, "split channel without TX frequency")
+	case m.Split && m.ShiftDirection != 0:
+		p = append(p, "split channel with a shift, use one or the other")
+	case m.Split:
+		if _, ok := units.StepHz(m.TXStepSize); !ok {
+			p = append(p, fmt.Sprintf("invalid TX step index %d", m.TXStepSize))
+		} else if !units.FitsStep(m.TXFrequency, m.TXStepSize) {
+			p = append(p, fmt.Sprintf("TX frequency %s MHz is not on the %d Hz step", units.FormatMHz(m.TXFrequency), units.StepSizes[m.TXStepSize]))
+		}
+	case m.TXFrequency != 0:
+		p = append(p, "TX frequency set on a channel that is not split")
+	}
+	if m.ShiftDirection > 2 {
+		p = append(p, fmt.Sprintf("invalid shift direction %d", m.ShiftDirection))
+	}
+	flag("reverse", m.ReverseEnabled)
+	flag("tone", m.ToneEnabled)
+	flag("CTCSS", m.CTCSSEnabled)
+	flag("DCS", m.DCSEnabled)
+	flag("lockout", m.LockOut)
+	if m.ToneEnabled+m.CTCSSEnabled+m.DCSEnabled > 1 {
+		p = append(p, "only one of tone, CTCSS and DCS can be enabled")
+	}
+	if int(m.ToneFrequency) >= len(units.CTCSSTones) {
+		p = append(p, fmt.Sprintf("tone index %d out of table", m.ToneFrequency))
+	}
+	if int(m.CTCSSFrequency) >= len(units.CTCSSTones) {
+		p = append(p, fmt.Sprintf("CTCSS index %d out of table", m.CTCSSFrequency))
+	}
+	if int(m.DCSFrequency) >= len(units.DCSCodes) {
+		p = append(p, fmt.Sprintf("DCS index %d out of table", m.DCSFrequency))
+	}
+	if int(m.Mode) >= len(units.ModeNames) {
+		p = append(p, fmt.Sprintf("invalid mode %d", m.Mode))
+	}
+
+	if len(p) > 0 {
+		return &ValidationError{Channel: m.Number, Problems: p}
+	}
+	return nil
+}
+
+// NameChange is a channel name altered to fit the model when written.
+type NameChange struct {
+	Channel  uint16
+	From, To string
+}
+
+// NameChanges lists the names of channels altered to fit the model when
+// written, see FitName.
+func (c Capabilities) NameChanges(channels []MemoryEntry) (v []NameChange) {
+	for _, m := range channels {
+		if m.RXFrequency == 0 || !c.SupportsName(m.Kind) {
+			continue
+		}
+		if to := c.FitName(m.Name); to != m.Name {
+			v = append(v, NameChange{Channel: m.Number, From: m.Name, To: to})
+		}
+	}
+	return v
+}
+
+// WarnNameChanges logs a warning for every name altered before it is
+// written.
+func WarnNameChanges(changes []NameChange) {
+	for _, c := range changes {
+		log.Warn().Str("channel", fmt.Sprintf("%03d", c.Channel)).Str("name", c.From).Str("written as", c.To).Msg("Name altered to fit the radio.")
+	}
+}
+
+// ValidateMemory checks all occupied channels against the radio model.
+func (r *Radio) ValidateMemory() error {
+	return r.validateChannels(r.OccupedChannels())
+}
+
+// validateChannels checks channels against the capabilities and the licence
+// of r.
+func (r *Radio) validateChannels(channels []MemoryEntry) error {
+	var errs ValidationErrors
+	c := r.Capabilities()
+	unlicensed := map[uint16]bool{}
+	if r.Licence != nil {
+		for _, m := range r.Licence.Unlicensed(channels, c) {
+			unlicensed[m.Number] = true
+		}
+	}
+	for _, m := range channels {
+		err, _ := m.ValidateFor(c).(*ValidationError)
+		if unlicensed[m.Number] {
+			if err == nil {
+				err = &ValidationError{Channel: m.Number}
+			}
+			err.Problems = append(err.Problems, licenceProblem(m))
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Validate checks every channel of the dump, and that memory channel
+// numbers are in range and used once.
+func (d *Dump) Validate() error {
+	var errs ValidationErrors
+	seen := map[uint16]bool{}
+	size := d.MemorySize()
+	for _, m := range d.Memory {
+		err, _ := m.Validate().(*ValidationError)
+		problem := func(p string) {
+			if err == nil {
+				err = &ValidationError{Channel: m.Number}
+			}
+			err.Problems = append(err.Problems, p)
+		}
+		if int(m.Number) >= size {
+			problem(fmt.Sprintf("channel number out of 000-%03d", size-1))
+		}
+		if seen[m.Number] {
+			problem("channel number used more than once")
+		}
+		seen[m.Number] = true
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, m := range d.Special {
+		if err := m.Validate(); err != nil {
+			errs = append(errs, err.(*ValidationError))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package kenwoodutil
+
+import (
+	"fmt"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+const (
+	FOFormat        = "FO %1d,%010d,%1d,%1d,%1d,%1d,%1d,%1d,%02d,%02d,%03d,%08d,%1d"
+	FOCommandFormat = "FO %1d\r"
+	BCFormat        = "BC %1d,%1d"
+	BCCommandFormat = "BC\r"
+)
+
+type VFO struct {
+	Band            uint8
+	Frequency       uint32
+	StepSize        uint8
+	ShiftDirection  uint8
+	ReverseEnabled  uint8
+	ToneEnabled     uint8
+	CTCSSEnabled    uint8
+	DCSEnabled      uint8
+	ToneFrequency   uint16
+	CTCSSFrequency  uint16
+	DCSFrequency    uint16
+	OffsetFrequency uint32
+	Mode            uint8
+}
+
+func (v *VFO) ReadLine(line string) error {
+	_, err := fmt.Sscanf(line, FOFormat,
+		&v.Band, &v.Frequency, &v.StepSize, &v.ShiftDirection, &v.ReverseEnabled,
+		&v.ToneEnabled, &v.CTCSSEnabled, &v.DCSEnabled, &v.ToneFrequency,
+		&v.CTCSSFrequency, &v.DCSFrequency, &v.OffsetFrequency, &v.Mode,
+	)
+	if err != nil {
+		return parseError("VFO line", line, "")
+	}
+	return nil
+}
+
+func (v *VFO) WriteLine() string {
+	return fmt.Sprintf(FOFormat,
+		v.Band, v.Frequency, v.StepSize, v.ShiftDirection, v.ReverseEnabled,
+		v.ToneEnabled, v.CTCSSEnabled, v.DCSEnabled, v.ToneFrequency,
+		v.CTCSSFrequency, v.DCSFrequency, v.OffsetFrequency, v.Mode,
+	)
+}
+
+func (r *Radio) GetVFO(band int) (v VFO, err error) {
+	line, err := r.WriteReadString(fmt.Sprintf(FOCommandFormat, band))
+	if err != nil {
+		return VFO{}, fmt.Errorf("error reading VFO of band %s: %w", units.BandName(band), err)
+	}
+	if err := v.ReadLine(line); err != nil {
+		return VFO{}, err
+	}
+	return v, nil
+}
+
+func (r *Radio) SetVFO(v VFO) error {
+	_, err := r.WriteReadString(v.WriteLine() + "\r")
+	if err != nil {
+		return fmt.Errorf("error setting VFO of band %s: %w", units.BandName(int(v.Band)), err)
+	}
+	return nil
+}
+
+func (r *Radio) GetFrequency(band int) (uint32, error) {
+	v, err := r.GetVFO(band)
+	return v.Frequency, err
+}
+
+// SetFrequency tunes band to hz, refusing frequencies the model does not
+// receive with ErrOutOfRange.
+func (r *Radio) SetFrequency(band int, hz uint32) error {
+	if !r.Capabilities().CanReceive(hz) {
+		return fmt.Errorf("error tuning band %s to %s MHz: %w", units.BandName(band), units.FormatMHz(hz), ErrOutOfRange)
+	}
+	v, err := r.GetVFO(band)
+	if err != nil {
+		return err
+	}
+	v.Frequency = hz
+	return r.SetVFO(v)
+}
+
+func (r *Radio) GetMode(band int) (uint8, error) {
+	v, err := r.GetVFO(band)
+	return v.Mode, err
+}
+
+func (r *Radio) SetMode(band int, mode uint8) error {
+	v, err := r.GetVFO(band)
+	if err != nil {
+		return err
+	}
+	v.Mode = mode
+	return r.SetVFO(v)
+}
+
+// GetBand returns the current control and PTT bands.
+func (r *Radio) GetBand() (control, ptt int, err error) {
+	line, err := r.WriteReadString(BCCommandFormat)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading band control: %w", err)
+	}
+	if _, err := fmt.Sscanf(line, BCFormat, &control, &ptt); err != nil {
+		return 0, 0, parseError("band control line", line, "")
+	}
+	return control, ptt, nil
+}
+
+// SelectBand makes band both the control and the PTT band.
+func (r *Radio) SelectBand(band int) error {
+	return r.SetBands(band, band)
+}
+
+// SetBands sets the control band, whose VFO the front panel changes, and
+// the PTT band, the one transmitting.
+func (r *Radio) SetBands(control, ptt int) error {
+	_, err := r.WriteReadString(fmt.Sprintf(BCFormat, control, ptt) + "\r")
+	if err != nil {
+		return fmt.Errorf("error selecting control band %s and PTT band %s: %w", units.BandName(control), units.BandName(ptt), err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package kenwoodutil
+
+import (
+	"fmt"
+
+	"github.com/skrzyp/kenwoodutil/units"
+)
+
+// WatchdogRule describes the state a band is expected to stay in. Either
+// Channel (memory mode) or Frequency (VFO mode) may be set, and Settings
+// lists settings that must keep their values.
+type WatchdogRule struct {
+	Name      string            `json:",omitempty"`
+	Band      string            `json:",omitempty"`
+	Channel   *int              `json:",omitempty"`
+	Frequency string            `json:",omitempty"`
+	Settings  map[string]string `json:",omitempty"`
+}
+
+// Enforce checks rule against the radio, restores what differs and returns
+// a description of every restored item.
+func (r *Radio) Enforce(rule WatchdogRule) (restored []string, err error) {
+	band := 0
+	if rule.Band != "" {
+		if band, err = units.ParseBand(rule.Band); err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.Channel != nil || rule.Frequency != "" {
+		mode, err := r.GetVFOMode(band)
+		if err != nil {
+			return nil, err
+		}
+		if rule.Channel != nil {
+			ch := -1
+			if mode == MemoryMode {
+				if ch, err = r.GetMemoryChannel(band); err != nil {
+					return nil, err
+				}
+			}
+			if ch != *rule.Channel {
+				if err := r.SelectMemoryChannel(band, *rule.Channel); err != nil {
+					return nil, err
+				}
+				restored = append(restored, fmt.Sprintf("band %s channel %03d", units.BandName(band), *rule.Channel))
+			}
+		} else {
+			want, err := units.ParseMHz(rule.Frequency)
+			if err != nil {
+				return nil, err
+			}
+			var hz uint32
+			if mode == VFOMode {
+				if hz, err = r.GetFrequency(band); err != nil {
+					return nil, err
+				}
+			}
+			if hz != want {
+				if mode != VFOMode {
+					if err := r.SetVFOMode(band, VFOMode); err != nil {
+						return nil, err
+					}
+				}
+				if err := r.SetFrequency(band, want); err != nil {
+					return nil, err
+				}
+				restored = append(restored, fmt.Sprintf("band %s frequency %s MHz", units.BandName(band), units.FormatMHz(want)))
+			}
+		}
+	}
+
+	if len(rule.Settings) > 0 {
+		changes, err := r.ApplySettings(rule.Settings)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range changes {
+			restored = append(restored, c.Key+"="+c.New)
+		}
+	}
+	return restored, nil
+}
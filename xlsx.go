@@ -0,0 +1,233 @@
+package kenwoodutil
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const xlsxRelationshipNS = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a shared or inline string, either plain or split in runs.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	s := t.T
+	for _, r := range t.Runs {
+		s += r.T
+	}
+	return s
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSXPart(z *zip.Reader, name string, v interface{}) error {
+	for _, f := range z.File {
+		if f.Name != name {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("error opening %s: %w", name, err)
+		}
+		defer r.Close()
+		if err := xml.NewDecoder(r).Decode(v); err != nil {
+			return fmt.Errorf("error parsing %s: %w", name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("error: workbook has no %s, is it an .xlsx file?", name)
+}
+
+// xlsxColumn returns the zero based column of a cell reference like "AB12".
+func xlsxColumn(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col*26 + int(c-'A'+1)
+		n++
+	}
+	return col - 1, n > 0
+}
+
+// ReadXLSXRows reads the cells of the first sheet of an Excel workbook as
+// text. Numbers are formatted without the binary rounding noise Excel
+// stores them with.
+func ReadXLSXRows(r io.ReaderAt, size int64) ([][]string, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("error reading workbook: %w", err)
+	}
+	var wb xlsxWorkbook
+	if err := readXLSXPart(z, "xl/workbook.xml", &wb); err != nil {
+		return nil, err
+	}
+	if len(wb.Sheets) == 0 {
+		return nil, fmt.Errorf("error: workbook has no sheets")
+	}
+	var rels xlsxRelationships
+	if err := readXLSXPart(z, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	sheetPath := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == wb.Sheets[0].ID {
+			sheetPath = rel.Target
+		}
+	}
+	if sheetPath == "" {
+		return nil, fmt.Errorf("error: workbook does not locate sheet %q", wb.Sheets[0].Name)
+	}
+	if strings.HasPrefix(sheetPath, "/") {
+		sheetPath = sheetPath[1:]
+	} else {
+		sheetPath = path.Join("xl", sheetPath)
+	}
+
+	var shared []string
+	var sst struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if err := readXLSXPart(z, "xl/sharedStrings.xml", &sst); err == nil {
+		for _, si := range sst.Items {
+			shared = append(shared, si.String())
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := readXLSXPart(z, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		// rows without cells may be left out of the sheet
+		for row.Number > len(rows)+1 {
+			rows = append(rows, nil)
+		}
+		var cells []string
+		for _, c := range row.Cells {
+			col, ok := xlsxColumn(c.Ref)
+			if !ok {
+				col = len(cells)
+			}
+			for col >= len(cells) {
+				cells = append(cells, "")
+			}
+			switch c.Type {
+			case "s":
+				i, err := strconv.Atoi(c.Value)
+				if err != nil || i < 0 || i >= len(shared) {
+					return nil, fmt.Errorf("error: cell %s refers to missing shared string %q", c.Ref, c.Value)
+				}
+				cells[col] = shared[i]
+			case "inlineStr":
+				cells[col] = c.Inline.String()
+			case "", "n":
+				cells[col] = c.Value
+				if f, err := strconv.ParseFloat(c.Value, 64); err == nil {
+					cells[col] = strconv.FormatFloat(f, 'g', 15, 64)
+				}
+			default:
+				cells[col] = c.Value
+			}
+		}
+		rows = append(rows, cells)
+	}
+	return rows, nil
+}
+
+var xlsxStaticParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="` + xlsxRelationshipNS + `"><sheets><sheet name="Channels" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="` + xlsxRelationshipNS + `/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// WriteXLSX writes rows as the only sheet of an Excel workbook. Cells are
+// stored as text so that channel numbers keep their leading zeros.
+func WriteXLSX(w io.Writer, rows [][]string) error {
+	z := zip.NewWriter(w)
+	for _, p := range xlsxStaticParts {
+		f, err := z.Create(p.name)
+		if err != nil {
+			return fmt.Errorf("error writing workbook: %w", err)
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return fmt.Errorf("error writing workbook: %w", err)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			fmt.Fprintf(&b, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, xlsxColumnName(j), i+1)
+			xml.EscapeText(&b, []byte(cell))
+			b.WriteString(`</t></is></c>`)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return fmt.Errorf("error writing workbook: %w", err)
+	}
+	if _, err := io.WriteString(f, b.String()); err != nil {
+		return fmt.Errorf("error writing workbook: %w", err)
+	}
+	if err := z.Close(); err != nil {
+		return fmt.Errorf("error writing workbook: %w", err)
+	}
+	return nil
+}
+
+// xlsxColumnName returns the letters of the zero based column col.
+func xlsxColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}